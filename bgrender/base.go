@@ -1,7 +1,7 @@
 package bgrender
 
 // 文件说明：背景渲染器统一包装层。
-// 主要职责：对外暴露专辑图、音量、尺寸和暂停等控制接口。
+// 主要职责：对外暴露专辑图、音量、节拍、尺寸和暂停等控制接口。
 
 import (
 	"image"
@@ -20,6 +20,8 @@ type Renderer interface {
 	Resume()
 	SetAlbum(albumSource any, isVideo ...bool) error
 	SetLowFreqVolume(volume float64)
+	SetBeatIntensity(intensity float64)
+	Pulse(strength float64)
 	SetHasLyric(hasLyric bool)
 	Update(dt time.Duration)
 	Draw(screen *ebiten.Image)
@@ -99,6 +101,20 @@ func (b *BackgroundRender) SetLowFreqVolume(volume float64) {
 	b.renderer.SetLowFreqVolume(volume)
 }
 
+func (b *BackgroundRender) SetBeatIntensity(intensity float64) {
+	if b == nil || b.renderer == nil {
+		return
+	}
+	b.renderer.SetBeatIntensity(intensity)
+}
+
+func (b *BackgroundRender) Pulse(strength float64) {
+	if b == nil || b.renderer == nil {
+		return
+	}
+	b.renderer.Pulse(strength)
+}
+
 func (b *BackgroundRender) SetHasLyric(hasLyric bool) {
 	if b == nil || b.renderer == nil {
 		return
@@ -0,0 +1,37 @@
+package bgrender
+
+// 文件说明：背景渲染器与事件总线的衔接。
+// 主要职责：把封面和低频音量主题转换为渲染器调用，并保证在主线程执行。
+
+import (
+	"image"
+	"log"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/evbus"
+)
+
+// Subscribe 把封面与低频音量主题接入 r。两个主题都按最新值合并，
+// 回调在 q.Drain 所在的 goroutine（通常是 Ebiten 主线程）执行。
+// 传入 nil 主题表示不订阅该项。
+func Subscribe(r Renderer, q *evbus.Queue, album *evbus.Topic[image.Image], volume *evbus.Topic[float64]) evbus.Unsubscribe {
+	if r == nil || q == nil {
+		return func() {}
+	}
+	var group evbus.Group
+	if album != nil {
+		group.Add(album.SubscribeLatest(q, func(img image.Image) {
+			if img == nil {
+				return
+			}
+			if err := r.SetAlbum(img); err != nil {
+				log.Printf("mesh renderer set album failed: %v", err)
+				return
+			}
+			log.Printf("mesh renderer set album success")
+		}))
+	}
+	if volume != nil {
+		group.Add(volume.SubscribeLatest(q, r.SetLowFreqVolume))
+	}
+	return group.Close
+}
@@ -55,6 +55,10 @@ type MeshGradientRenderer struct {
 	volume         float64
 	smoothedVolume float64
 
+	// 节拍触发的流动加速，flowBurst 随时间指数衰减
+	beatIntensity float64
+	flowBurst     float64
+
 	frameTimeMS  float64
 	frameElapsed time.Duration
 	staticStable bool
@@ -239,6 +243,29 @@ func (r *MeshGradientRenderer) SetLowFreqVolume(volume float64) {
 	r.volume = volume / 10.0
 }
 
+const (
+	// beatBurstBoost 是满强度节拍时流动速度的额外倍数。
+	beatBurstBoost = 4.0
+	beatBurstDecay = 180 * time.Millisecond
+)
+
+// SetBeatIntensity 设置节拍加速的强度，0 表示关闭。
+func (r *MeshGradientRenderer) SetBeatIntensity(intensity float64) {
+	r.beatIntensity = math.Max(0, math.Min(2, intensity))
+	if r.beatIntensity == 0 {
+		r.flowBurst = 0
+	}
+}
+
+// Pulse 在节拍到来时让背景流动短暂加速，strength 为 0-1。
+func (r *MeshGradientRenderer) Pulse(strength float64) {
+	if r.beatIntensity <= 0 || r.staticMode {
+		return
+	}
+	strength = math.Max(0, math.Min(1, strength))
+	r.flowBurst = math.Max(r.flowBurst, strength*r.beatIntensity)
+}
+
 func (r *MeshGradientRenderer) SetHasLyric(hasLyric bool) {
 	r.hasLyric = hasLyric
 }
@@ -265,7 +292,13 @@ func (r *MeshGradientRenderer) Update(dt time.Duration) {
 
 	frameDelta := r.frameElapsed
 	r.frameElapsed = 0
-	r.frameTimeMS += frameDelta.Seconds() * 1000 * r.flowSpeed
+	r.frameTimeMS += frameDelta.Seconds() * 1000 * r.flowSpeed * (1 + r.flowBurst*beatBurstBoost)
+	if r.flowBurst > 0 {
+		r.flowBurst *= math.Exp(-frameDelta.Seconds() / beatBurstDecay.Seconds())
+		if r.flowBurst < 0.001 {
+			r.flowBurst = 0
+		}
+	}
 
 	r.updatePerformance(frameDelta)
 	canBeStatic := r.onTick(frameDelta)
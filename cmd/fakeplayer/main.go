@@ -0,0 +1,82 @@
+package main
+
+// 文件说明：模拟播放器命令行工具。
+// 主要职责：解析参数组装 fakeplayer.Player，连接正在运行的 EbitenLyrics 并按剧本播放，
+// 开发时不必打开真实播放器。
+//
+// 示例：
+//
+//	go run ./cmd/fakeplayer -ttml song.ttml -cover cover.jpg -wav song.wav -seek 30s:1m -pause 1m10s:3s -loop
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/fakeplayer"
+	"github.com/xiaowumin-mark/EbitenLyrics/ws"
+)
+
+func main() {
+	p := fakeplayer.Player{}
+	var (
+		wavPath  string
+		artists  string
+		duration time.Duration
+	)
+	flag.StringVar(&p.URL, "url", fakeplayer.DefaultURL, "WebSocket address of the running app")
+	flag.StringVar(&p.TTMLPath, "ttml", "", "send lyrics parsed from this TTML `file` with setLyric")
+	flag.StringVar(&p.CoverPath, "cover", "", "send this image `file` as SetCoverData")
+	flag.StringVar(&wavPath, "wav", "", "stream OnAudioData decoded from this WAV `file`")
+	flag.Float64Var(&p.ToneHz, "tone", 220, "frequency of the generated tone when no -wav is given (0 disables audio)")
+	flag.Float64Var(&p.ToneBPM, "bpm", 120, "pulse rate of the generated tone (0 for a steady tone)")
+	flag.StringVar(&p.Music.MusicName, "title", "Fake Player", "song title sent with setMusic")
+	flag.StringVar(&artists, "artist", "EbitenLyrics", "comma separated artists sent with setMusic")
+	flag.StringVar(&p.Music.AlbumName, "album", "", "album name sent with setMusic")
+	flag.DurationVar(&duration, "duration", 0, "song duration (0 uses the audio or lyric length)")
+	flag.DurationVar(&p.ProgressInterval, "progress-interval", fakeplayer.DefaultProgressInterval, "interval between progress updates")
+	flag.Float64Var(&p.Speed, "speed", 1, "playback speed relative to wall time")
+	flag.BoolVar(&p.Loop, "loop", false, "restart from the beginning at the end of the song")
+	flag.Func("seek", "seek when playback reaches a position, as `at:to` (repeatable)", func(s string) error {
+		a, err := fakeplayer.ParseAction(fakeplayer.ActionSeek, s)
+		p.Actions = append(p.Actions, a)
+		return err
+	})
+	flag.Func("pause", "pause for a while when playback reaches a position, as `at:for` (repeatable)", func(s string) error {
+		a, err := fakeplayer.ParseAction(fakeplayer.ActionPause, s)
+		p.Actions = append(p.Actions, a)
+		return err
+	})
+	flag.Parse()
+
+	p.Music.Duration = duration.Milliseconds()
+	for _, name := range strings.Split(artists, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			p.Music.Artists = append(p.Music.Artists, ws.Artist{Name: name})
+		}
+	}
+
+	if wavPath != "" {
+		pcm, err := fakeplayer.LoadWAV(wavPath)
+		if err != nil {
+			log.Fatalf("load wav: %v", err)
+		}
+		p.Audio = pcm
+	}
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+
+	if err := p.Run(stop); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -21,18 +21,30 @@ import (
 
 const lyricsSwitchFadeDuration = 280 * time.Millisecond
 
+// ThemeSwitchDuration 是运行时切换主题的默认过渡时长。
+const ThemeSwitchDuration = 400 * time.Millisecond
+
 type LyricsComponent struct {
-	LyricsControl      *lyrics.Lyrics
-	AnimateManager     *anim.Manager
-	FontManager        *ft.FontManager
-	FontRequest        ft.FontRequest
-	Width, Height      float64
-	FontSize           float64
-	FD                 float64
+	LyricsControl  *lyrics.Lyrics
+	AnimateManager *anim.Manager
+	FontManager    *ft.FontManager
+	FontRequest    ft.FontRequest
+	Width, Height  float64
+	FontSize       float64
+	FD             float64
+	// FocusPosition 是当前行在滚动方向上的位置（横排 0 顶部、1 底部，竖排 0 右缘、1 左缘），
+	// 可见区域即组件的 Width×Height，超出的部分不会绘制。
+	FocusPosition      float64
+	Align              lyrics.LyricAlign
+	WritingMode        lyrics.WritingMode
 	SmartTranslateWrap bool
+	Theme              lyrics.Theme
 	Image              *ebiten.Image
 	StaticImage        *ebiten.Image
 	TransitionImage    *ebiten.Image
+	// DepthBlur / DepthBlurStrength 是非当前行的景深模糊开关与强度，修改请用 SetDepthBlur。
+	DepthBlur         bool
+	DepthBlurStrength float64
 
 	staticLayerSignature uint64
 	staticLayerReady     bool
@@ -50,7 +62,11 @@ func NewLyricsComponent(anim *anim.Manager, fontManager *ft.FontManager, req ft.
 		Height:             h,
 		FontSize:           fs,
 		FD:                 fd,
+		FocusPosition:      lyrics.DefaultFocusPosition,
 		SmartTranslateWrap: true,
+		Theme:              lyrics.DefaultTheme(),
+		DepthBlur:          true,
+		DepthBlurStrength:  lyrics.DefaultDepthBlurStrength,
 		switchFadeDuration: lyricsSwitchFadeDuration,
 	}
 }
@@ -284,6 +300,11 @@ func (l *LyricsComponent) SetLyrics(ls []ttml.LyricLine) *LyricsComponent {
 	l.LyricsControl = control
 	l.LyricsControl.AnimateManager = l.AnimateManager
 	l.LyricsControl.HighlightTime = time.Millisecond * 800
+	l.LyricsControl.SetTheme(l.Theme, 0)
+	l.LyricsControl.SetViewport(l.Width, l.Height, l.FocusPosition)
+	l.LyricsControl.SetAlign(l.Align)
+	l.LyricsControl.SetWritingMode(l.WritingMode)
+	l.LyricsControl.SetDepthBlur(l.DepthBlur, l.DepthBlurStrength)
 	for _, line := range l.LyricsControl.Lines {
 		line.SetSmartTranslateWrap(l.SmartTranslateWrap)
 	}
@@ -307,6 +328,71 @@ func (l *LyricsComponent) Update(t time.Duration) {
 	l.LyricsControl.Update(l.LyricsControl.Position)
 }
 
+// Kick 把节拍脉冲转交给当前歌词，strength 为 0-1。
+func (l *LyricsComponent) Kick(strength float64) {
+	if l.LyricsControl == nil {
+		return
+	}
+	l.LyricsControl.Kick(strength)
+}
+
+// Position 返回最近一次 Update 传入的播放进度。
+func (l *LyricsComponent) Position() time.Duration {
+	if l.LyricsControl == nil {
+		return 0
+	}
+	return l.LyricsControl.Position
+}
+
+// LineAt 返回屏幕坐标 (x, y) 处的歌词行下标，p 须与 Draw 使用的变换相同；没有命中返回 -1。
+// 切换歌词的淡入淡出期间不做命中检测。
+func (l *LyricsComponent) LineAt(x, y float64, p *lyrics.Position) int {
+	if l.LyricsControl == nil || l.switchFadeActive {
+		return -1
+	}
+	if p != nil {
+		geo := lyrics.TransformToGeoM(p)
+		if !geo.IsInvertible() {
+			return -1
+		}
+		geo.Invert()
+		x, y = geo.Apply(x, y)
+	}
+	return l.LyricsControl.HitTest(x, y)
+}
+
+// SetHoverLine 提亮下标为 index 的歌词行，-1 取消。
+func (l *LyricsComponent) SetHoverLine(index int) {
+	if l.LyricsControl == nil {
+		return
+	}
+	l.LyricsControl.SetHover(index)
+}
+
+// LineStartTime 返回下标为 index 的歌词行的开始时间。
+func (l *LyricsComponent) LineStartTime(index int) (time.Duration, bool) {
+	if l.LyricsControl == nil || index < 0 || index >= len(l.LyricsControl.Lines) {
+		return 0, false
+	}
+	return l.LyricsControl.Lines[index].StartTime, true
+}
+
+// ScrollBounds 返回手动滚动偏移的可达范围，见 lyrics.Lyrics.ScrollBounds。
+func (l *LyricsComponent) ScrollBounds() (lo, hi float64) {
+	if l.LyricsControl == nil {
+		return 0, 0
+	}
+	return l.LyricsControl.ScrollBounds()
+}
+
+// SetScrollOffset 设置手动滚动偏移，歌词在组件内按偏移绘制，滚到的行才会被渲染。
+func (l *LyricsComponent) SetScrollOffset(offset float64) {
+	if l.LyricsControl == nil {
+		return
+	}
+	l.LyricsControl.SetScrollOffset(offset)
+}
+
 func (l *LyricsComponent) Resize(w, h float64) {
 	if w <= 0 || h <= 0 {
 		return
@@ -316,10 +402,51 @@ func (l *LyricsComponent) Resize(w, h float64) {
 	if l.LyricsControl == nil {
 		return
 	}
+	l.LyricsControl.SetViewport(w, h, l.FocusPosition)
 	l.LyricsControl.Resize(w)
 	l.LyricsControl.Scroll(l.LyricsControl.GetNowLyrics(), 0)
 }
 
+// SetAlign 设置歌词的水平对齐方式，后续加载的歌词沿用该设置。
+func (l *LyricsComponent) SetAlign(align lyrics.LyricAlign) *LyricsComponent {
+	l.Align = align
+	if l.LyricsControl == nil {
+		return l
+	}
+	l.LyricsControl.SetAlign(align)
+	l.LyricsControl.Scroll(l.LyricsControl.GetNowLyrics(), 0)
+	l.staticLayerSignature = 0
+	l.staticLayerReady = false
+	return l
+}
+
+// SetWritingMode 切换横排 / 竖排，后续加载的歌词沿用该设置。
+// 切换会重建所有行，当前行动画从当前播放进度重新开始。
+func (l *LyricsComponent) SetWritingMode(mode lyrics.WritingMode) *LyricsComponent {
+	l.WritingMode = mode
+	if l.LyricsControl == nil || l.LyricsControl.WritingMode == mode {
+		return l
+	}
+	l.LyricsControl.SetWritingMode(mode)
+	l.LyricsControl.Update(l.LyricsControl.Position)
+	l.LyricsControl.Scroll(l.LyricsControl.GetNowLyrics(), 0)
+	l.staticLayerSignature = 0
+	l.staticLayerReady = false
+	return l
+}
+
+// SetFocusPosition 设置当前行在组件滚动方向上的位置，见 FocusPosition。
+func (l *LyricsComponent) SetFocusPosition(focus float64) *LyricsComponent {
+	l.FocusPosition = focus
+	if l.LyricsControl == nil {
+		return l
+	}
+	l.LyricsControl.SetViewport(l.Width, l.Height, focus)
+	l.FocusPosition = l.LyricsControl.FocusPosition
+	l.LyricsControl.Scroll(l.LyricsControl.GetNowLyrics(), 1)
+	return l
+}
+
 func (l *LyricsComponent) SetFontSize(fs float64) *LyricsComponent {
 	if l.LyricsControl == nil || fs <= 0 {
 		return l
@@ -386,6 +513,31 @@ func (l *LyricsComponent) SetSmartTranslateWrap(enabled bool) *LyricsComponent {
 	return l
 }
 
+// SetDepthBlur 开关景深模糊并设置强度，低配机器可关闭；后续加载的歌词沿用该设置。
+func (l *LyricsComponent) SetDepthBlur(enabled bool, strength float64) *LyricsComponent {
+	l.DepthBlur = enabled
+	l.DepthBlurStrength = max(strength, 0)
+	if l.LyricsControl == nil {
+		return l
+	}
+	l.LyricsControl.SetDepthBlur(enabled, l.DepthBlurStrength)
+	return l
+}
+
+// SetTheme 切换歌词配色，duration 为 0 时立即生效；后续加载的歌词沿用该主题。
+func (l *LyricsComponent) SetTheme(theme lyrics.Theme, duration time.Duration) *LyricsComponent {
+	l.Theme = theme
+	if l.LyricsControl == nil {
+		return l
+	}
+	l.LyricsControl.SetTheme(theme, duration)
+	l.staticLayerSignature = 0
+	l.staticLayerReady = false
+	return l
+}
+
+// Draw 把组件画到 screen 上，p 为组件左上角的变换，nil 表示画在原点。
+// 歌词先绘制到组件大小的图像中，因此被裁剪在组件的 Width×Height 区域内。
 func (l *LyricsComponent) Draw(screen *ebiten.Image, p *lyrics.Position) {
 	if screen == nil {
 		return
@@ -1,8 +1,243 @@
 package evbus
 
-// 文件说明：暴露全局事件总线实例。
-// 主要职责：为页面、歌词组件和 WebSocket 数据流提供松耦合通信入口。
+// 文件说明：类型安全的事件总线。
+// 主要职责：为页面、歌词组件和 WebSocket 数据流提供松耦合通信入口，
+// 支持取消订阅、主线程投递队列和“只保留最新值”的合并投递。
 
-import "github.com/asaskevich/EventBus"
+import (
+	"sync"
+	"sync/atomic"
+)
 
-var Bus = EventBus.New()
+// Unsubscribe 取消一次订阅。重复调用是安全的。
+type Unsubscribe func()
+
+// Topic 是一个带类型的事件主题。发布方与订阅方共享同一个 Topic 值，
+// 因此主题名拼写错误或回调签名不一致会在编译期暴露。
+type Topic[T any] struct {
+	name string
+
+	mu   sync.RWMutex
+	subs []*subscription[T]
+}
+
+type subscription[T any] struct {
+	deliver func(T)
+	active  atomic.Bool
+}
+
+// NewTopic 创建一个主题，name 仅用于日志与调试展示。
+func NewTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+func (t *Topic[T]) Name() string {
+	if t == nil {
+		return ""
+	}
+	return t.name
+}
+
+// Publish 把 value 同步交给所有订阅者。
+// 直接订阅在发布方 goroutine 中执行；队列订阅只负责入队。
+func (t *Topic[T]) Publish(value T) {
+	if t == nil {
+		return
+	}
+	t.mu.RLock()
+	subs := t.subs
+	t.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.active.Load() {
+			sub.deliver(value)
+		}
+	}
+}
+
+// Subscribers 返回当前仍然有效的订阅数量。
+func (t *Topic[T]) Subscribers() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.subs)
+}
+
+// Subscribe 注册一个在发布方 goroutine 中直接执行的回调。
+func (t *Topic[T]) Subscribe(fn func(T)) Unsubscribe {
+	if t == nil || fn == nil {
+		return func() {}
+	}
+	sub := &subscription[T]{}
+	sub.deliver = func(value T) {
+		fn(value)
+	}
+	return t.add(sub)
+}
+
+// SubscribeOn 把每一次发布都排入 q，由 q.Drain 所在的 goroutine（通常是主线程）执行回调。
+func (t *Topic[T]) SubscribeOn(q *Queue, fn func(T)) Unsubscribe {
+	if t == nil || q == nil || fn == nil {
+		return func() {}
+	}
+	sub := &subscription[T]{}
+	sub.deliver = func(value T) {
+		q.post(func() {
+			if sub.active.Load() {
+				fn(value)
+			}
+		})
+	}
+	return t.add(sub)
+}
+
+// SubscribeLatest 与 SubscribeOn 类似，但在两次 Drain 之间只保留最后一次发布的值。
+// 适合进度、音量、封面这类只关心最新状态的数据。
+func (t *Topic[T]) SubscribeLatest(q *Queue, fn func(T)) Unsubscribe {
+	if t == nil || q == nil || fn == nil {
+		return func() {}
+	}
+	sub := &subscription[T]{}
+	var (
+		slotMu sync.Mutex
+		latest T
+		queued bool
+	)
+	flush := func() {
+		slotMu.Lock()
+		value := latest
+		var zero T
+		latest = zero
+		queued = false
+		slotMu.Unlock()
+		if sub.active.Load() {
+			fn(value)
+		}
+	}
+	sub.deliver = func(value T) {
+		slotMu.Lock()
+		latest = value
+		needPost := !queued
+		queued = true
+		slotMu.Unlock()
+		if needPost {
+			q.post(flush)
+		}
+	}
+	return t.add(sub)
+}
+
+func (t *Topic[T]) add(sub *subscription[T]) Unsubscribe {
+	sub.active.Store(true)
+
+	t.mu.Lock()
+	next := make([]*subscription[T], 0, len(t.subs)+1)
+	next = append(next, t.subs...)
+	next = append(next, sub)
+	t.subs = next
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sub.active.Store(false)
+			t.remove(sub)
+		})
+	}
+}
+
+func (t *Topic[T]) remove(sub *subscription[T]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	next := make([]*subscription[T], 0, len(t.subs))
+	for _, it := range t.subs {
+		if it != sub {
+			next = append(next, it)
+		}
+	}
+	t.subs = next
+}
+
+// Queue 收集需要在指定 goroutine 上执行的事件回调。
+// 任意 goroutine 都可以向队列投递，只有调用 Drain 的 goroutine 会执行回调。
+type Queue struct {
+	mu      sync.Mutex
+	pending []func()
+	spare   []func()
+}
+
+func NewQueue() *Queue {
+	return &Queue{}
+}
+
+func (q *Queue) post(fn func()) {
+	q.mu.Lock()
+	q.pending = append(q.pending, fn)
+	q.mu.Unlock()
+}
+
+// Len 返回尚未执行的回调数量。
+func (q *Queue) Len() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Drain 按投递顺序执行当前积压的回调，返回执行的数量。
+// 回调执行期间新投递的事件留到下一次 Drain。
+func (q *Queue) Drain() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	batch := q.pending
+	q.pending = q.spare[:0]
+	q.spare = nil
+	q.mu.Unlock()
+
+	for i, fn := range batch {
+		fn()
+		batch[i] = nil
+	}
+
+	q.mu.Lock()
+	if q.spare == nil {
+		q.spare = batch[:0]
+	}
+	q.mu.Unlock()
+	return len(batch)
+}
+
+// Group 汇总多个订阅，便于在页面销毁时统一取消。
+type Group struct {
+	mu   sync.Mutex
+	subs []Unsubscribe
+}
+
+func (g *Group) Add(unsubscribe Unsubscribe) {
+	if g == nil || unsubscribe == nil {
+		return
+	}
+	g.mu.Lock()
+	g.subs = append(g.subs, unsubscribe)
+	g.mu.Unlock()
+}
+
+// Close 取消组内全部订阅，之后 Group 可以继续复用。
+func (g *Group) Close() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	subs := g.subs
+	g.subs = nil
+	g.mu.Unlock()
+	for _, unsubscribe := range subs {
+		unsubscribe()
+	}
+}
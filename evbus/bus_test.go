@@ -0,0 +1,129 @@
+package evbus
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTopicSubscribeAndUnsubscribe(t *testing.T) {
+	topic := NewTopic[int]("test:int")
+	var got []int
+	unsubscribe := topic.Subscribe(func(v int) {
+		got = append(got, v)
+	})
+
+	topic.Publish(1)
+	topic.Publish(2)
+	unsubscribe()
+	unsubscribe()
+	topic.Publish(3)
+
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected deliveries: %v", got)
+	}
+	if n := topic.Subscribers(); n != 0 {
+		t.Fatalf("Subscribers() = %d, want 0", n)
+	}
+}
+
+func TestSubscribeOnDefersUntilDrain(t *testing.T) {
+	topic := NewTopic[string]("test:string")
+	queue := NewQueue()
+	var got []string
+	topic.SubscribeOn(queue, func(v string) {
+		got = append(got, v)
+	})
+
+	topic.Publish("a")
+	topic.Publish("b")
+	if len(got) != 0 {
+		t.Fatalf("queued subscription ran before Drain: %v", got)
+	}
+	if n := queue.Drain(); n != 2 {
+		t.Fatalf("Drain() = %d, want 2", n)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("unexpected deliveries: %v", got)
+	}
+}
+
+func TestSubscribeLatestCoalesces(t *testing.T) {
+	topic := NewTopic[int]("test:latest")
+	queue := NewQueue()
+	var got []int
+	topic.SubscribeLatest(queue, func(v int) {
+		got = append(got, v)
+	})
+
+	for i := 1; i <= 5; i++ {
+		topic.Publish(i)
+	}
+	if n := queue.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1", n)
+	}
+	queue.Drain()
+	topic.Publish(6)
+	queue.Drain()
+
+	if len(got) != 2 || got[0] != 5 || got[1] != 6 {
+		t.Fatalf("unexpected deliveries: %v", got)
+	}
+}
+
+func TestUnsubscribeDropsQueuedDeliveries(t *testing.T) {
+	topic := NewTopic[int]("test:drop")
+	queue := NewQueue()
+	calls := 0
+	unsubscribe := topic.SubscribeOn(queue, func(int) {
+		calls++
+	})
+
+	topic.Publish(1)
+	unsubscribe()
+	queue.Drain()
+	if calls != 0 {
+		t.Fatalf("unsubscribed handler ran %d times", calls)
+	}
+}
+
+func TestGroupCloseCancelsAll(t *testing.T) {
+	a := NewTopic[int]("test:a")
+	b := NewTopic[bool]("test:b")
+	var group Group
+	calls := 0
+	group.Add(a.Subscribe(func(int) { calls++ }))
+	group.Add(b.Subscribe(func(bool) { calls++ }))
+
+	group.Close()
+	a.Publish(1)
+	b.Publish(true)
+	if calls != 0 {
+		t.Fatalf("handlers ran %d times after Close", calls)
+	}
+}
+
+func TestConcurrentPublishIsDrainedOnce(t *testing.T) {
+	topic := NewTopic[int]("test:concurrent")
+	queue := NewQueue()
+	total := 0
+	topic.SubscribeOn(queue, func(v int) {
+		total += v
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				topic.Publish(1)
+			}
+		}()
+	}
+	wg.Wait()
+	queue.Drain()
+
+	if total != 800 {
+		t.Fatalf("total = %d, want 800", total)
+	}
+}
@@ -0,0 +1,196 @@
+package fakeplayer
+
+// 文件说明：模拟播放器的音频来源。
+// 主要职责：解码本地 WAV 文件或生成带节拍的测试音，统一输出交错的 16 位 PCM，
+// 以便按播放位置切片作为 OnAudioData 发送。
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// PCM 是交错的有符号 16 位小端 PCM。
+type PCM struct {
+	Samples    []int16
+	Channels   int
+	SampleRate int
+}
+
+// Frames 返回每声道的采样数。
+func (p *PCM) Frames() int {
+	if p.Channels <= 0 {
+		return 0
+	}
+	return len(p.Samples) / p.Channels
+}
+
+// Duration 返回音频时长。
+func (p *PCM) Duration() time.Duration {
+	if p.SampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(p.Frames()) * time.Second / time.Duration(p.SampleRate)
+}
+
+// Slice 按时间区间 [from, to) 编码为小端字节，超出末尾的部分补静音。
+func (p *PCM) Slice(from, to time.Duration) []byte {
+	start := p.frameAt(from)
+	end := p.frameAt(to)
+	if end <= start {
+		return nil
+	}
+	out := make([]byte, 0, (end-start)*p.Channels*2)
+	total := p.Frames()
+	for f := start; f < end; f++ {
+		for c := 0; c < p.Channels; c++ {
+			var v int16
+			if f < total {
+				v = p.Samples[f*p.Channels+c]
+			}
+			out = binary.LittleEndian.AppendUint16(out, uint16(v))
+		}
+	}
+	return out
+}
+
+func (p *PCM) frameAt(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(int64(d) * int64(p.SampleRate) / int64(time.Second))
+}
+
+// Tone 生成 duration 长的立体声测试音：freq 的正弦波，按 bpm 做衰减包络，
+// 让频谱和节拍检测都有东西可看。bpm <= 0 时为持续的正弦波。
+func Tone(freq, bpm float64, duration time.Duration, sampleRate int) *PCM {
+	if sampleRate <= 0 {
+		sampleRate = 48000
+	}
+	frames := int(duration.Seconds() * float64(sampleRate))
+	p := &PCM{Samples: make([]int16, frames*2), Channels: 2, SampleRate: sampleRate}
+	for i := 0; i < frames; i++ {
+		t := float64(i) / float64(sampleRate)
+		amp := 0.5
+		if bpm > 0 {
+			phase := math.Mod(t, 60/bpm)
+			amp = 0.15 + 0.6*math.Exp(-phase*12)
+		}
+		v := int16(amp * math.Sin(2*math.Pi*freq*t) * 32767)
+		p.Samples[i*2] = v
+		p.Samples[i*2+1] = v
+	}
+	return p
+}
+
+// LoadWAV 读取 WAV 文件，见 DecodeWAV。
+func LoadWAV(path string) (*PCM, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeWAV(f)
+}
+
+// DecodeWAV 解码 RIFF/WAVE，支持 8/16/24/32 位整数与 32 位浮点 PCM，统一转换为 16 位。
+func DecodeWAV(r io.Reader) (*PCM, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("wav: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("wav: not a RIFF/WAVE file")
+	}
+
+	var (
+		format     uint16
+		channels   int
+		sampleRate int
+		bits       int
+		haveFmt    bool
+	)
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, errors.New("wav: missing data chunk")
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("wav: short fmt chunk")
+			}
+			buf := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+			format = binary.LittleEndian.Uint16(buf[0:2])
+			channels = int(binary.LittleEndian.Uint16(buf[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(buf[4:8]))
+			bits = int(binary.LittleEndian.Uint16(buf[14:16]))
+			if format == 0xFFFE && size >= 26 {
+				// WAVE_FORMAT_EXTENSIBLE：真实格式在子格式 GUID 的前两个字节
+				format = binary.LittleEndian.Uint16(buf[24:26])
+			}
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return nil, errors.New("wav: data chunk before fmt chunk")
+			}
+			// 流式写出的文件 size 可能是占位值，按实际读到的长度解码
+			data, err := io.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+			return decodeWAVData(data, format, channels, sampleRate, bits)
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+		}
+	}
+}
+
+func decodeWAVData(data []byte, format uint16, channels, sampleRate, bits int) (*PCM, error) {
+	if channels <= 0 || sampleRate <= 0 {
+		return nil, fmt.Errorf("wav: invalid %d channels at %d Hz", channels, sampleRate)
+	}
+	size := bits / 8
+	var sample func(b []byte) float64
+	switch {
+	case format == 1 && bits == 8:
+		sample = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == 1 && bits == 16:
+		sample = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == 1 && bits == 24:
+		sample = func(b []byte) float64 {
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			return float64(v) / 8388608
+		}
+	case format == 1 && bits == 32:
+		sample = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case format == 3 && bits == 32:
+		sample = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return nil, fmt.Errorf("wav: unsupported format %d with %d bits", format, bits)
+	}
+
+	count := len(data) / size
+	count -= count % channels
+	p := &PCM{Samples: make([]int16, count), Channels: channels, SampleRate: sampleRate}
+	for i := 0; i < count; i++ {
+		v := sample(data[i*size:])
+		if math.IsNaN(v) {
+			v = 0
+		}
+		v = math.Max(-1, math.Min(1, v))
+		p.Samples[i] = int16(v * 32767)
+	}
+	return p, nil
+}
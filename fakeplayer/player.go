@@ -0,0 +1,475 @@
+package fakeplayer
+
+// 文件说明：模拟播放器。
+// 主要职责：连接程序的 WebSocket 服务，按剧本发送 initialize、歌曲信息、歌词、封面、
+// 进度和 OnAudioData，并支持跳转、暂停与循环，代替真实播放器驱动界面；
+// 也会响应程序发来的 seekPlayProgress 指令。
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+	"github.com/xiaowumin-mark/EbitenLyrics/ws"
+)
+
+const (
+	// DefaultURL 是程序默认的 WebSocket 地址。
+	DefaultURL = "ws://127.0.0.1:11445"
+	// DefaultProgressInterval 是默认的进度上报间隔。
+	DefaultProgressInterval = 250 * time.Millisecond
+	// DefaultAudioFrame 是每条 OnAudioData 覆盖的时长。
+	DefaultAudioFrame = 20 * time.Millisecond
+	// DefaultDuration 在没有音频、歌词和歌曲时长时使用。
+	DefaultDuration = time.Minute
+
+	magicAudioData = 0
+	magicCoverData = 1
+)
+
+// ActionKind 是剧本动作的类型。
+type ActionKind string
+
+const (
+	// ActionSeek 跳转到 Value 指定的位置。
+	ActionSeek ActionKind = "seek"
+	// ActionPause 暂停 Value 指定的（真实）时长后继续。
+	ActionPause ActionKind = "pause"
+)
+
+// Action 在播放位置到达 At 时执行一次，循环播放时每一轮都会重新执行。
+type Action struct {
+	At    time.Duration
+	Kind  ActionKind
+	Value time.Duration
+}
+
+// ParseAction 解析 "at:value" 形式的动作参数，如 "30s:1m30s"。
+func ParseAction(kind ActionKind, s string) (Action, error) {
+	at, value, ok := strings.Cut(s, ":")
+	if !ok || at == "" || value == "" {
+		return Action{}, fmt.Errorf("%s: want at:value, got %q", kind, s)
+	}
+	a := Action{Kind: kind}
+	var err error
+	if a.At, err = time.ParseDuration(at); err != nil {
+		return Action{}, fmt.Errorf("%s: %w", kind, err)
+	}
+	if a.Value, err = time.ParseDuration(value); err != nil {
+		return Action{}, fmt.Errorf("%s: %w", kind, err)
+	}
+	return a, nil
+}
+
+// Player 是一次模拟播放的配置，零值字段使用默认值。
+type Player struct {
+	// URL 为空时使用 DefaultURL。
+	URL string
+	// Music 是 setMusic 的内容；Duration 为 0 时取音频或歌词的长度。
+	Music ws.MusicInfo
+	// TTMLPath 非空时解析该文件并以 setLyric 发送。
+	TTMLPath string
+	// CoverPath 非空时以 SetCoverData 发送该图片。
+	CoverPath string
+	// Audio 是 OnAudioData 的来源。
+	Audio *PCM
+	// Audio 为 nil 且 ToneHz > 0 时生成覆盖整首歌的测试音，见 Tone；两者都没有时不发送音频。
+	ToneHz  float64
+	ToneBPM float64
+	// ProgressInterval 是进度上报的间隔（播放时间）。
+	ProgressInterval time.Duration
+	// AudioFrame 是每条 OnAudioData 覆盖的时长（播放时间）。
+	AudioFrame time.Duration
+	// Speed 是相对真实时间的播放倍速，<= 0 时为 1。
+	Speed float64
+	// Loop 为 true 时播放到结尾后从头开始。
+	Loop bool
+	// Actions 是按播放位置触发的跳转与暂停。
+	Actions []Action
+}
+
+// Run 连接服务并按剧本播放，直到播放结束（非循环）、出错或 stop 被关闭。
+func (p *Player) Run(stop <-chan struct{}) error {
+	url := p.URL
+	if url == "" {
+		url = DefaultURL
+	}
+	lyric, err := p.loadLyric()
+	if err != nil {
+		return err
+	}
+	var cover []byte
+	if p.CoverPath != "" {
+		if cover, err = os.ReadFile(p.CoverPath); err != nil {
+			return fmt.Errorf("fakeplayer: read cover: %w", err)
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return fmt.Errorf("fakeplayer: dial %s: %w", url, err)
+	}
+	c := &client{conn: conn, failed: make(chan error, 1), seeks: make(chan time.Duration, 1)}
+	defer c.close()
+	go c.readReplies()
+
+	music := p.Music
+	if music.Duration <= 0 {
+		music.Duration = p.duration(lyric).Milliseconds()
+	}
+	if music.MusicId == "" {
+		music.MusicId = "fakeplayer"
+	}
+	if music.MusicName == "" {
+		music.MusicName = "Fake Player"
+	}
+
+	duration := time.Duration(music.Duration) * time.Millisecond
+	audio := p.Audio
+	if audio == nil && p.ToneHz > 0 {
+		audio = Tone(p.ToneHz, p.ToneBPM, duration, ws.DefaultAudioSampleRate)
+	}
+
+	initValue := map[string]interface{}{}
+	if audio != nil {
+		initValue["audioFormat"] = ws.AudioFormat{SampleFormat: ws.SampleFormatI16, Channels: audio.Channels, SampleRate: audio.SampleRate}
+	}
+	if err := c.sendJSON(ws.TypeInitialize, initValue); err != nil {
+		return err
+	}
+	if err := c.sendState("setMusic", music); err != nil {
+		return err
+	}
+	if lyric != nil {
+		if err := c.sendState("setLyric", map[string]interface{}{"lines": flattenLines(lyric.LyricLines)}); err != nil {
+			return err
+		}
+	}
+	if cover != nil {
+		if err := c.sendBinary(magicCoverData, cover); err != nil {
+			return err
+		}
+	}
+	return p.play(c, audio, duration, stop)
+}
+
+func (p *Player) loadLyric() (*ttml.TTMLLyric, error) {
+	if p.TTMLPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(p.TTMLPath)
+	if err != nil {
+		return nil, fmt.Errorf("fakeplayer: read ttml: %w", err)
+	}
+	lyric, err := ttml.ParseTTML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("fakeplayer: parse ttml: %w", err)
+	}
+	return &lyric, nil
+}
+
+// protocolLine 是 setLyric 中的一行。真实播放器不发送 bgs，背景行平铺在所属主行之后并以 isBG 标记。
+type protocolLine struct {
+	Words           []ttml.LyricWord `json:"words"`
+	TranslatedLyric string           `json:"translatedLyric"`
+	RomanLyric      string           `json:"romanLyric"`
+	IsBG            bool             `json:"isBG"`
+	IsDuet          bool             `json:"isDuet"`
+	StartTime       int              `json:"startTime"`
+	EndTime         int              `json:"endTime"`
+}
+
+// flattenLines 把解析出的主行及其 BGs 展开为协议中的平铺顺序，是 ws.MergeBGLines 的逆操作。
+func flattenLines(lines []ttml.LyricLine) []protocolLine {
+	out := make([]protocolLine, 0, len(lines))
+	var add func(line ttml.LyricLine, bg bool)
+	add = func(line ttml.LyricLine, bg bool) {
+		out = append(out, protocolLine{
+			Words:           line.Words,
+			TranslatedLyric: line.TranslatedLyric,
+			RomanLyric:      line.RomanLyric,
+			IsBG:            bg || line.IsBG,
+			IsDuet:          line.IsDuet,
+			StartTime:       line.StartTime,
+			EndTime:         line.EndTime,
+		})
+		for _, bgLine := range line.BGs {
+			add(bgLine, true)
+		}
+	}
+	for _, line := range lines {
+		add(line, false)
+	}
+	return out
+}
+
+// duration 依次取音频长度、最后一行歌词结束后 2 秒、DefaultDuration。
+func (p *Player) duration(lyric *ttml.TTMLLyric) time.Duration {
+	if p.Audio != nil && p.Audio.Duration() > 0 {
+		return p.Audio.Duration()
+	}
+	if lyric != nil && len(lyric.LyricLines) > 0 {
+		end := 0
+		for _, line := range lyric.LyricLines {
+			end = max(end, line.EndTime)
+		}
+		return time.Duration(end)*time.Millisecond + 2*time.Second
+	}
+	return DefaultDuration
+}
+
+// play 是播放循环：按真实时间推进位置，发送音频与进度，并在到达时执行动作。
+func (p *Player) play(c *client, audio *PCM, duration time.Duration, stop <-chan struct{}) error {
+	speed := p.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	frame := p.AudioFrame
+	if frame <= 0 {
+		frame = DefaultAudioFrame
+	}
+	progressEvery := p.ProgressInterval
+	if progressEvery <= 0 {
+		progressEvery = DefaultProgressInterval
+	}
+	actions := append([]Action(nil), p.Actions...)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].At < actions[j].At })
+
+	tick := time.Duration(float64(frame) / speed)
+	if tick < time.Millisecond {
+		tick = time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var pos, lastProgress time.Duration
+	next := 0 // 下一个待执行的动作
+	if err := c.sendState("resumed", nil); err != nil {
+		return err
+	}
+	if err := c.sendProgress(0); err != nil {
+		return err
+	}
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-c.failed:
+			return err
+		case target := <-c.seeks:
+			// 程序要求跳转，与剧本中的跳转一样从目标位置之后的动作继续
+			pos = min(max(target, 0), duration)
+			lastProgress = pos
+			next = sort.Search(len(actions), func(i int) bool { return actions[i].At >= pos })
+			if err := c.sendProgress(pos); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			// 不用 tick 自带的时间：暂停期间积压的 tick 会早于暂停结束的时刻
+			now := time.Now()
+			advance := time.Duration(float64(now.Sub(last)) * speed)
+			last = now
+			end := min(pos+advance, duration)
+			if next < len(actions) && actions[next].At < end {
+				end = max(pos, actions[next].At)
+			}
+
+			if audio != nil && end > pos {
+				if err := c.sendBinary(magicAudioData, audio.Slice(pos, end)); err != nil {
+					return err
+				}
+			}
+			pos = end
+			if pos-lastProgress >= progressEvery {
+				lastProgress = pos
+				if err := c.sendProgress(pos); err != nil {
+					return err
+				}
+			}
+
+			for next < len(actions) && actions[next].At <= pos {
+				a := actions[next]
+				next++
+				switch a.Kind {
+				case ActionSeek:
+					pos = min(a.Value, duration)
+					lastProgress = pos
+					// 跳转后从目标位置之后的动作继续
+					for next < len(actions) && actions[next].At < pos {
+						next++
+					}
+					if err := c.sendProgress(pos); err != nil {
+						return err
+					}
+				case ActionPause:
+					if err := c.pause(a.Value, stop); err != nil {
+						return err
+					}
+					last = time.Now()
+				}
+			}
+
+			if pos >= duration {
+				// 结尾总是上报一次，界面能走到最后一行
+				if lastProgress != pos {
+					if err := c.sendProgress(pos); err != nil {
+						return err
+					}
+				}
+				if !p.Loop {
+					return c.sendState("paused", nil)
+				}
+				pos, lastProgress, next = 0, 0, 0
+				if err := c.sendProgress(0); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// client 串行化对连接的写入，并在后台读取服务端的回复。
+type client struct {
+	conn     *websocket.Conn
+	mu       sync.Mutex
+	failed   chan error
+	failOnce sync.Once
+	// seeks 传递服务端发来的跳转指令，只保留最新的一条
+	seeks chan time.Duration
+}
+
+func (c *client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.conn.Close()
+}
+
+// readReplies 记录服务端发回的错误、转交跳转指令，连接断开时通知播放循环。
+func (c *client) readReplies() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure {
+				c.fail(fmt.Errorf("fakeplayer: connection lost: %w", err))
+			}
+			return
+		}
+		if pos, ok := parseSeekCommand(data); ok {
+			c.requestSeek(pos)
+			continue
+		}
+		log.Printf("fakeplayer: server replied %s", data)
+	}
+}
+
+func (c *client) requestSeek(pos time.Duration) {
+	for {
+		select {
+		case c.seeks <- pos:
+			return
+		default:
+		}
+		select {
+		case <-c.seeks:
+		default:
+		}
+	}
+}
+
+// parseSeekCommand 识别 {"type":"command","value":{"command":"seekPlayProgress","progress":毫秒}}。
+func parseSeekCommand(data []byte) (time.Duration, bool) {
+	var msg ws.GenericV2Payload
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != ws.TypeCommand {
+		return 0, false
+	}
+	var cmd struct {
+		Command  string `json:"command"`
+		Progress int64  `json:"progress"`
+	}
+	if err := json.Unmarshal(msg.Value, &cmd); err != nil || cmd.Command != ws.CommandSeekPlayProgress {
+		return 0, false
+	}
+	return time.Duration(cmd.Progress) * time.Millisecond, true
+}
+
+func (c *client) fail(err error) {
+	c.failOnce.Do(func() {
+		c.failed <- err
+	})
+}
+
+func (c *client) write(msgType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(msgType, data)
+}
+
+func (c *client) sendJSON(typ ws.V2PayloadType, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(ws.GenericV2Payload{Type: typ, Value: raw})
+	if err != nil {
+		return err
+	}
+	return c.write(websocket.TextMessage, data)
+}
+
+// sendState 发送 {"type":"state","value":{"update":update, ...fields}}。
+func (c *client) sendState(update string, fields interface{}) error {
+	value := map[string]interface{}{}
+	if fields != nil {
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+	}
+	value["update"] = update
+	return c.sendJSON(ws.TypeState, value)
+}
+
+func (c *client) sendProgress(pos time.Duration) error {
+	return c.sendState("progress", ws.ProgressUpdate{Progress: pos.Milliseconds()})
+}
+
+// sendBinary 发送 6 字节头（magic u16 + size u32，小端）加数据。
+func (c *client) sendBinary(magic uint16, data []byte) error {
+	msg := make([]byte, 0, 6+len(data))
+	msg = binary.LittleEndian.AppendUint16(msg, magic)
+	msg = binary.LittleEndian.AppendUint32(msg, uint32(len(data)))
+	msg = append(msg, data...)
+	return c.write(websocket.BinaryMessage, msg)
+}
+
+// pause 发送 paused，等待 d 后发送 resumed。
+func (c *client) pause(d time.Duration, stop <-chan struct{}) error {
+	if err := c.sendState("paused", nil); err != nil {
+		return err
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-stop:
+		return nil
+	case err := <-c.failed:
+		return err
+	}
+	return c.sendState("resumed", nil)
+}
@@ -0,0 +1,261 @@
+package fakeplayer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+	"github.com/xiaowumin-mark/EbitenLyrics/ws"
+)
+
+const testTTML = `<tt xmlns="http://www.w3.org/ns/ttml"><body><div>
+<p begin="00:00.200" end="00:00.800"><span begin="00:00.200" end="00:00.800">one</span></p>
+<p begin="00:01.000" end="00:01.800"><span begin="00:01.000" end="00:01.800">two</span></p>
+</div></body></tt>`
+
+// encodeWAV 把 16 位 PCM 写成最简单的 RIFF/WAVE。
+func encodeWAV(p *PCM) []byte {
+	var buf bytes.Buffer
+	data := p.Slice(0, p.Duration())
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16), uint16(1), uint16(p.Channels), uint32(p.SampleRate),
+		uint32(p.SampleRate * p.Channels * 2), uint16(p.Channels * 2), uint16(16),
+	} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestDecodeWAVRoundTrip(t *testing.T) {
+	tone := Tone(440, 0, 100*time.Millisecond, 8000)
+	pcm, err := DecodeWAV(bytes.NewReader(encodeWAV(tone)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pcm.Channels != 2 || pcm.SampleRate != 8000 || pcm.Frames() != 800 {
+		t.Fatalf("decoded %d ch %d Hz %d frames", pcm.Channels, pcm.SampleRate, pcm.Frames())
+	}
+	for i, v := range tone.Samples {
+		// 16 位来回转换最多差 1
+		if d := int(pcm.Samples[i]) - int(v); d < -1 || d > 1 {
+			t.Fatalf("sample %d = %d, want %d", i, pcm.Samples[i], v)
+		}
+	}
+	if _, err := DecodeWAV(strings.NewReader("RIFF\x00\x00\x00\x00AVI ")); err == nil {
+		t.Fatal("DecodeWAV accepted a non-WAVE file")
+	}
+}
+
+func TestParseAction(t *testing.T) {
+	a, err := ParseAction(ActionSeek, "30s:1m30s")
+	if err != nil || a.At != 30*time.Second || a.Value != 90*time.Second || a.Kind != ActionSeek {
+		t.Fatalf("ParseAction = %+v, %v", a, err)
+	}
+	if _, err := ParseAction(ActionPause, "10s"); err == nil {
+		t.Fatal("ParseAction accepted a value without ':'")
+	}
+}
+
+func TestFlattenLinesSendsFlatBackgroundLines(t *testing.T) {
+	words := []ttml.LyricWord{{StartTime: 0, EndTime: 500, Word: "la"}}
+	bg := ttml.LyricLine{Words: words, IsBG: true, StartTime: 100, EndTime: 500}
+	lines := []ttml.LyricLine{
+		{Words: words, StartTime: 0, EndTime: 500, BGs: []ttml.LyricLine{bg}},
+		{Words: words, StartTime: 600, EndTime: 900, IsDuet: true},
+	}
+	data, err := json.Marshal(flattenLines(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "bgs") {
+		t.Fatalf("setLyric lines should not carry bgs: %s", data)
+	}
+	var flat []ttml.LyricLine
+	if err := json.Unmarshal(data, &flat); err != nil {
+		t.Fatal(err)
+	}
+	if len(flat) != 3 || flat[0].IsBG || !flat[1].IsBG || flat[1].StartTime != 100 || flat[2].IsBG || !flat[2].IsDuet {
+		t.Fatalf("flattened lines = %+v", flat)
+	}
+	if merged := ws.MergeBGLines(flat); len(merged) != 2 || len(merged[0].BGs) != 1 || merged[0].BGs[0].StartTime != 100 {
+		t.Fatalf("merged back = %+v", merged)
+	}
+}
+
+// TestPlayerDrivesServer 用真实的 WebSocket 服务端接收模拟播放器的整段剧本。
+func TestPlayerDrivesServer(t *testing.T) {
+	msgChan := make(ws.MessageChannel, 1024)
+	server := ws.NewAMLLWebSocketServer()
+	srv := httptest.NewServer(server.Handler(msgChan))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"song.ttml": []byte(testTTML),
+		"cover.png": []byte("cover bytes"),
+		"song.wav":  encodeWAV(Tone(220, 120, 2*time.Second, 8000)),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audio, err := LoadWAV(filepath.Join(dir, "song.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Player{
+		URL:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Music:            ws.MusicInfo{MusicId: "test", MusicName: "Test"},
+		TTMLPath:         filepath.Join(dir, "song.ttml"),
+		CoverPath:        filepath.Join(dir, "cover.png"),
+		Audio:            audio,
+		ProgressInterval: 100 * time.Millisecond,
+		Speed:            8,
+		Actions: []Action{
+			{At: 500 * time.Millisecond, Kind: ActionSeek, Value: 1500 * time.Millisecond},
+			{At: 1700 * time.Millisecond, Kind: ActionPause, Value: 30 * time.Millisecond},
+		},
+	}
+	if err := p.Run(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []ws.ProtocolPayload
+	timeout := time.After(5 * time.Second)
+collect:
+	for {
+		select {
+		case msg := <-msgChan:
+			got = append(got, msg.Payload)
+			if _, ok := msg.Payload.(ws.SourceDisconnected); ok {
+				break collect
+			}
+		case <-timeout:
+			t.Fatalf("timed out after %d messages", len(got))
+		}
+	}
+
+	connected, ok := got[0].(ws.SourceConnected)
+	if !ok || connected.Protocol != ws.HybridV2 || connected.Audio != (ws.AudioFormat{SampleFormat: ws.SampleFormatI16, Channels: 2, SampleRate: 8000}) {
+		t.Fatalf("first message = %#v", got[0])
+	}
+
+	var (
+		music      *ws.SetMusicUpdate
+		lines      int
+		cover      []byte
+		audioBytes int
+		progress   []int64
+		playback   []string
+	)
+	for _, payload := range got {
+		switch v := payload.(type) {
+		case ws.SetMusicUpdate:
+			music = &v
+		case ws.SetLyricUpdate:
+			lines = len(v.Lines)
+		case ws.V2BinaryMessage:
+			switch v.Type {
+			case "SetCoverData":
+				cover = v.Data
+			case "OnAudioData":
+				if len(v.Data)%4 != 0 {
+					t.Fatalf("audio frame of %d bytes is not whole stereo i16 frames", len(v.Data))
+				}
+				audioBytes += len(v.Data)
+			}
+		case ws.ProgressUpdate:
+			progress = append(progress, v.Progress)
+		case ws.PausedUpdate:
+			playback = append(playback, "paused")
+		case ws.ResumedUpdate:
+			playback = append(playback, "resumed")
+		}
+	}
+
+	if music == nil || music.MusicName != "Test" || music.Duration != 2000 {
+		t.Fatalf("setMusic = %+v", music)
+	}
+	if lines != 2 {
+		t.Fatalf("setLyric lines = %d, want 2", lines)
+	}
+	if string(cover) != "cover bytes" {
+		t.Fatalf("cover = %q", cover)
+	}
+	// 跳过了 0.5s-1.5s，其余 1s 的音频都应送出（8kHz 立体声 16 位）
+	if want := 8000 * 4; audioBytes != want {
+		t.Fatalf("audio bytes = %d, want %d", audioBytes, want)
+	}
+	if strings.Join(playback, ",") != "resumed,paused,resumed,paused" {
+		t.Fatalf("playback events = %v", playback)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] < progress[i-1] {
+			t.Fatalf("progress went backwards: %v", progress)
+		}
+		if progress[i] > 500 && progress[i] < 1500 {
+			t.Fatalf("progress %d inside the skipped range: %v", progress[i], progress)
+		}
+	}
+	if progress[0] != 0 || progress[len(progress)-1] != 2000 {
+		t.Fatalf("progress = %v, want 0 ... 2000", progress)
+	}
+}
+
+func TestPlayerFollowsSeekCommand(t *testing.T) {
+	msgChan := make(ws.MessageChannel, 1024)
+	server := ws.NewAMLLWebSocketServer()
+	srv := httptest.NewServer(server.Handler(msgChan))
+	defer srv.Close()
+
+	p := &Player{
+		URL:              "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Music:            ws.MusicInfo{Duration: 60_000},
+		ProgressInterval: time.Second,
+	}
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- p.Run(stop) }()
+	defer func() {
+		close(stop)
+		if err := <-done; err != nil {
+			t.Errorf("Run() = %v", err)
+		}
+	}()
+
+	timeout := time.After(5 * time.Second)
+	sought := false
+	for {
+		select {
+		case msg := <-msgChan:
+			switch v := msg.Payload.(type) {
+			case ws.ResumedUpdate:
+				if err := server.Seek(msg.Source, 42*time.Second); err != nil {
+					t.Fatal(err)
+				}
+				sought = true
+			case ws.ProgressUpdate:
+				if sought && v.Progress >= 42_000 {
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatal("player did not report the requested position")
+		}
+	}
+}
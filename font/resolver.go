@@ -525,6 +525,11 @@ func (m *FontManager) GetFace(req FontRequest, size float64) (text.Face, error)
 }
 
 func (m *FontManager) GetFaceForText(req FontRequest, size float64, content string) (text.Face, error) {
+	return m.GetFaceForTextDirection(req, size, content, text.DirectionLeftToRight)
+}
+
+// GetFaceForTextDirection 与 GetFaceForText 相同，但按 dir 排版（如阿拉伯文、希伯来文使用 DirectionRightToLeft）。
+func (m *FontManager) GetFaceForTextDirection(req FontRequest, size float64, content string, dir text.Direction) (text.Face, error) {
 	if size <= 0 {
 		return nil, errors.New("font size must be positive")
 	}
@@ -546,7 +551,7 @@ func (m *FontManager) GetFaceForText(req FontRequest, size float64, content stri
 		if font == nil || font.Source == nil {
 			continue
 		}
-		faces = append(faces, &text.GoTextFace{Source: font.Source, Size: lp.LP(size)})
+		faces = append(faces, &text.GoTextFace{Source: font.Source, Size: lp.LP(size), Direction: dir})
 	}
 	if len(faces) == 0 {
 		return nil, errors.New("font chain contains no usable face")
@@ -3,13 +3,12 @@ module github.com/xiaowumin-mark/EbitenLyrics
 go 1.25.1
 
 require (
-	github.com/asaskevich/EventBus v0.0.0-20200907212545-49d423059eef
 	github.com/disintegration/imaging v1.6.2
 	github.com/ebitengine/debugui v0.2.0
 	github.com/ebitenui/ebitenui v0.7.3
 	github.com/edsrzf/mmap-go v1.2.0
 	github.com/go-text/typesetting v0.3.4
-	github.com/go-viper/mapstructure/v2 v2.5.0
+	github.com/godbus/dbus/v5 v5.2.2
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/hajimehoshi/ebiten/v2 v2.9.9
@@ -17,6 +16,7 @@ require (
 	github.com/tdewolff/font v0.0.0-20260314002930-9f995dac393e
 	golang.org/x/image v0.39.0
 	golang.org/x/sys v0.43.0
+	golang.org/x/text v0.36.0
 )
 
 require (
@@ -33,5 +33,4 @@ require (
 	github.com/tdewolff/parse/v2 v2.8.5 // indirect
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
 	golang.org/x/sync v0.20.0 // indirect
-	golang.org/x/text v0.36.0 // indirect
 )
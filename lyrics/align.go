@@ -0,0 +1,134 @@
+package lyrics
+
+// 文件说明：歌词水平对齐模式。
+// 主要职责：把对齐模式统一映射为文本对齐、行的水平位置与缩放原点，并支持运行时切换。
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// LyricAlign 表示歌词行的水平对齐方式。
+type LyricAlign int
+
+const (
+	// AlignDuetSplit 主唱靠左、对唱靠右（默认）。
+	AlignDuetSplit LyricAlign = iota
+	AlignStart
+	AlignCenter
+	AlignEnd
+)
+
+var lyricAlignNames = [...]string{"duet-split", "start", "center", "end"}
+
+func (a LyricAlign) String() string {
+	if a < 0 || int(a) >= len(lyricAlignNames) {
+		return fmt.Sprintf("LyricAlign(%d)", int(a))
+	}
+	return lyricAlignNames[a]
+}
+
+// LyricAligns 返回全部对齐模式，顺序与取值一致，便于做下拉选项。
+func LyricAligns() []LyricAlign {
+	return []LyricAlign{AlignDuetSplit, AlignStart, AlignCenter, AlignEnd}
+}
+
+// ParseLyricAlign 解析 duet-split、start、center、end（不区分大小写）。
+func ParseLyricAlign(s string) (LyricAlign, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range lyricAlignNames {
+		if s == name {
+			return LyricAlign(i), nil
+		}
+	}
+	return AlignDuetSplit, fmt.Errorf("unknown lyric align %q", s)
+}
+
+// textAlign 返回行内排版使用的（物理）对齐方式。start、end 随行方向而定：
+// 右到左的行 start 在右侧，因此默认的 duet-split 下阿拉伯文等主唱行靠右、对唱行靠左。
+func (a LyricAlign) textAlign(isDuet, rtl bool) text.Align {
+	align := text.AlignStart
+	switch a {
+	case AlignCenter:
+		return text.AlignCenter
+	case AlignEnd:
+		align = text.AlignEnd
+	case AlignDuetSplit:
+		if isDuet {
+			align = text.AlignEnd
+		}
+	}
+	if rtl {
+		if align == text.AlignStart {
+			return text.AlignEnd
+		}
+		return text.AlignStart
+	}
+	return align
+}
+
+// textAlign 返回本行的文本对齐方式。
+func (l *Line) textAlign() text.Align {
+	return l.Align.textAlign(l.IsDuet, l.RTL)
+}
+
+// alignOriginX 返回与对齐一致的缩放原点，使行缩放时贴住对齐的一侧。
+func (l *Line) alignOriginX() float64 {
+	switch l.textAlign() {
+	case text.AlignCenter:
+		return l.GetPosition().GetW() / 2
+	case text.AlignEnd:
+		return l.GetPosition().GetW()
+	default:
+		return 0
+	}
+}
+
+// placeX 按对齐方式把行放进宽度为 containerW 的区域。
+func (l *Line) placeX(containerW float64) {
+	if l.WritingMode == WritingVertical {
+		// 竖排时水平位置由滚动决定
+		return
+	}
+	switch l.textAlign() {
+	case text.AlignCenter:
+		l.GetPosition().SetX((containerW - l.GetPosition().GetW()) / 2)
+	case text.AlignEnd:
+		l.GetPosition().SetX(containerW - l.GetPosition().GetW())
+	default:
+		l.GetPosition().SetX(0)
+	}
+}
+
+// SetAlign 切换本行及背景行的对齐方式并重新排版，containerW 为歌词区域宽度。
+func (l *Line) SetAlign(align LyricAlign, containerW float64) {
+	if l == nil {
+		return
+	}
+	for _, bgLine := range l.BackgroundLines {
+		bgLine.SetAlign(align, containerW)
+	}
+	if l.Align == align {
+		return
+	}
+	l.Align = align
+	l.placeX(containerW)
+	lineLayoutLayer.GenerateLineTranslateImage(l)
+	lineLayoutLayer.LayoutLine(l)
+	if l.isShow {
+		lineRendererLayer.RecreateLineImage(l)
+	}
+}
+
+// SetAlign 切换整首歌词的对齐方式，调用方随后需要重新 Scroll 以更新纵向位置。
+func (l *Lyrics) SetAlign(align LyricAlign) {
+	if l == nil {
+		return
+	}
+	l.Align = align
+	for _, line := range l.Lines {
+		line.SetAlign(align, l.width)
+	}
+}
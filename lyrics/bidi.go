@@ -0,0 +1,171 @@
+package lyrics
+
+// 文件说明：双向文本（阿拉伯文、波斯文、希伯来文等）支持。
+// 主要职责：按 UAX #9 判定歌词行的段落方向与每个音节的方向，并把排好的音节在每一行内重排为视觉顺序。
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// paragraphIsRTL 按 UAX #9 规则 P2/P3 取第一个强方向字符判定段落方向，没有强字符时为左到右。
+func paragraphIsRTL(s string) bool {
+	for _, r := range s {
+		props, _ := bidi.LookupRune(r)
+		switch props.Class() {
+		case bidi.L:
+			return false
+		case bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+// hasRTLRune 判断文本是否含有右到左的强方向字符。
+func hasRTLRune(s string) bool {
+	for _, r := range s {
+		props, _ := bidi.LookupRune(r)
+		if c := props.Class(); c == bidi.R || c == bidi.AL {
+			return true
+		}
+	}
+	return false
+}
+
+// runeDirections 返回 s 中每个字符解析后的方向（true 为右到左），段落方向由 rtl 指定。
+// 数字、空格等弱/中性字符按前后文解析，例如阿拉伯文行内两个拉丁单词间的空格归入左到右片段。
+func runeDirections(s string, rtl bool) []bool {
+	out := make([]bool, utf8.RuneCountInString(s))
+	fallback := func() []bool {
+		for i := range out {
+			out[i] = rtl
+		}
+		return out
+	}
+	if len(out) == 0 {
+		return out
+	}
+
+	dir := bidi.LeftToRight
+	if rtl {
+		dir = bidi.RightToLeft
+	}
+	var p bidi.Paragraph
+	if _, err := p.SetString(s, bidi.DefaultDirection(dir)); err != nil {
+		return fallback()
+	}
+	ordering, err := p.Order()
+	if err != nil {
+		return fallback()
+	}
+	for i := 0; i < ordering.NumRuns(); i++ {
+		run := ordering.Run(i)
+		start, end := run.Pos()
+		for j := max(start, 0); j <= end && j < len(out); j++ {
+			out[j] = run.Direction() == bidi.RightToLeft
+		}
+	}
+	return out
+}
+
+func textDirection(rtl bool) text.Direction {
+	if rtl {
+		return text.DirectionRightToLeft
+	}
+	return text.DirectionLeftToRight
+}
+
+// analyzeBidi 按整行文本判定行方向，并把每个音节的方向写入音节及其图像。
+// 音节方向取其第一个非空白字符的解析结果，全空白音节取首字符。
+func (l *Line) analyzeBidi() {
+	l.RTL = paragraphIsRTL(l.Text)
+	dirs := runeDirections(l.Text, l.RTL)
+	index := 0
+	for _, syllable := range l.Syllables {
+		if syllable == nil {
+			continue
+		}
+		rtl := l.RTL
+		offset := strings.IndexFunc(syllable.Syllable, func(r rune) bool { return !unicode.IsSpace(r) })
+		if offset < 0 {
+			offset = 0
+		}
+		if at := index + utf8.RuneCountInString(syllable.Syllable[:offset]); at < len(dirs) {
+			rtl = dirs[at]
+		}
+		index += utf8.RuneCountInString(syllable.Syllable)
+
+		syllable.RTL = rtl
+		for _, element := range syllable.Elements {
+			if element != nil && element.SyllableImage != nil {
+				element.SyllableImage.SetRTL(rtl)
+			}
+		}
+	}
+}
+
+// bidiItem 是参与行内重排的一个音节位置。
+type bidiItem struct {
+	pos   *Position
+	rtl   bool
+	blank bool
+}
+
+// reorderBidi 把按逻辑顺序从左到右排好的音节重排为视觉顺序（UAX #9 规则 L2）。
+// 同一视觉行（Y 相同）内：右到左段落先整行翻转，再把其中的左到右片段翻回；
+// 左到右段落只翻转其中的右到左片段。翻转以片段内非空白音节的范围为轴，行的对齐位置保持不变。
+func reorderBidi(items []bidiItem, paragraphRTL bool) {
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && items[end].pos.GetY() == items[start].pos.GetY() {
+			end++
+		}
+		reorderBidiRow(items[start:end], paragraphRTL)
+		start = end
+	}
+}
+
+func reorderBidiRow(row []bidiItem, paragraphRTL bool) {
+	if paragraphRTL {
+		mirrorBidiItems(row)
+	}
+	// 段落为右到左时翻回左到右片段，否则翻转右到左片段
+	flip := !paragraphRTL
+	for start := 0; start < len(row); {
+		end := start + 1
+		for end < len(row) && row[end].rtl == row[start].rtl {
+			end++
+		}
+		if row[start].rtl == flip && end-start > 1 {
+			mirrorBidiItems(row[start:end])
+		}
+		start = end
+	}
+}
+
+func mirrorBidiItems(items []bidiItem) {
+	lo, hi, found := 0.0, 0.0, false
+	for _, item := range items {
+		if item.blank {
+			continue
+		}
+		x, w := item.pos.GetX(), item.pos.GetW()
+		if !found {
+			lo, hi, found = x, x+w, true
+			continue
+		}
+		lo = min(lo, x)
+		hi = max(hi, x+w)
+	}
+	if !found {
+		return
+	}
+	for _, item := range items {
+		item.pos.SetX(lo + hi - item.pos.GetX() - item.pos.GetW())
+	}
+}
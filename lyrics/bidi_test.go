@@ -0,0 +1,166 @@
+package lyrics
+
+import (
+	"testing"
+
+	ft "github.com/xiaowumin-mark/EbitenLyrics/font"
+	ttml "github.com/xiaowumin-mark/EbitenLyrics/ttml"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// layoutRow 按逻辑顺序从左到右依次摆放音节，模拟 AutoLayoutSyllable 的单行结果。
+func layoutRow(line *Line, y float64) []Position {
+	positions := make([]Position, 0, len(line.Syllables))
+	x := 0.0
+	for _, s := range line.Syllables {
+		w := s.Elements[0].SyllableImage.GetWidth()
+		positions = append(positions, NewPosition(x, y, w, 20))
+		x += w
+	}
+	return positions
+}
+
+func bidiTestLine(parts ...string) *Line {
+	line := &Line{}
+	syllables := make([]*LineSyllable, 0, len(parts))
+	for _, p := range parts {
+		w := 10.0
+		if p == " " {
+			w = 5
+		}
+		syllables = append(syllables, makeTestSyllable(p, w))
+	}
+	line.SetSyllables(syllables)
+	return line
+}
+
+func orderedXs(positions []Position) []float64 {
+	xs := make([]float64, len(positions))
+	for i, p := range positions {
+		xs[i] = p.GetX()
+	}
+	return xs
+}
+
+func indexes(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func TestParagraphDirectionAndRuneLevels(t *testing.T) {
+	if !paragraphIsRTL("  سلام hello") || paragraphIsRTL("hello سلام") || paragraphIsRTL("123 ...") {
+		t.Fatal("paragraph direction should follow the first strong character")
+	}
+	// 阿拉伯文中的拉丁单词及其间的空格解析为左到右，数字保持左到右
+	got := runeDirections("سلام hi yo عليكم", true)
+	want := []bool{true, true, true, true, true, false, false, false, false, false, true, true, true, true, true, true}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rune %d rtl = %v, want %v (%v)", i, got[i], want[i], got)
+		}
+	}
+	for i, rtl := range runeDirections("אני 12 שיר", true)[4:6] {
+		if rtl {
+			t.Fatalf("digit %d resolved to RTL", i)
+		}
+	}
+}
+
+func TestReorderBidiKeepsLatinRunsInsideArabicLine(t *testing.T) {
+	line := bidiTestLine("سلام", " ", "hello", " ", "world", " ", "عليكم")
+	if !line.RTL {
+		t.Fatal("line should be RTL")
+	}
+	if !line.Syllables[0].RTL || line.Syllables[2].RTL || line.Syllables[4].RTL || !line.Syllables[6].RTL {
+		t.Fatalf("unexpected syllable directions")
+	}
+	if !line.Syllables[0].Elements[0].SyllableImage.RTL {
+		t.Fatal("RTL syllable image should wipe right to left")
+	}
+
+	positions := layoutRow(line, 0)
+	lineLayoutLayer.reorderLineBidi(line, positions, indexes(len(positions)))
+
+	// 视觉顺序（从左到右）：عليكم، hello world، سلام
+	want := []float64{45, 40, 15, 25, 30, 10, 0}
+	for i, x := range orderedXs(positions) {
+		if x != want[i] {
+			t.Fatalf("x = %v, want %v", orderedXs(positions), want)
+		}
+	}
+}
+
+func TestReorderBidiFlipsArabicRunInsideLatinLineAndRowsIndependently(t *testing.T) {
+	line := bidiTestLine("hi", " ", "سلا", "م", " ", "yo")
+	if line.RTL {
+		t.Fatal("line should be LTR")
+	}
+	positions := layoutRow(line, 0)
+	// 最后一个音节换到第二行，不应参与第一行的翻转
+	positions[5] = NewPosition(0, 30, 10, 20)
+	lineLayoutLayer.reorderLineBidi(line, positions, indexes(len(positions)))
+
+	want := []float64{0, 10, 25, 15, 35, 0}
+	for i, x := range orderedXs(positions) {
+		if x != want[i] {
+			t.Fatalf("x = %v, want %v", orderedXs(positions), want)
+		}
+	}
+}
+
+func TestRTLLinesMirrorStartAndEnd(t *testing.T) {
+	cases := []struct {
+		align LyricAlign
+		duet  bool
+		want  text.Align
+	}{
+		{AlignDuetSplit, false, text.AlignEnd},
+		{AlignDuetSplit, true, text.AlignStart},
+		{AlignStart, false, text.AlignEnd},
+		{AlignEnd, false, text.AlignStart},
+		{AlignCenter, true, text.AlignCenter},
+	}
+	for _, tc := range cases {
+		if got := tc.align.textAlign(tc.duet, true); got != tc.want {
+			t.Errorf("%v duet=%v: align = %v, want %v", tc.align, tc.duet, got, tc.want)
+		}
+	}
+
+	line := bidiTestLine("שלום")
+	line.Position = NewPosition(0, 0, 900, 40)
+	line.placeX(1000)
+	if line.GetPosition().GetX() != 100 || line.alignOriginX() != 900 {
+		t.Fatalf("RTL main line should sit on the right: x=%v origin=%v", line.GetPosition().GetX(), line.alignOriginX())
+	}
+}
+
+func TestNewPlacesRTLLinesWithoutResize(t *testing.T) {
+	words := []ttml.LyricWord{{StartTime: 0, EndTime: 500, Word: "שלום"}, {StartTime: 500, EndTime: 1000, Word: " עולם"}}
+	lines := []ttml.LyricLine{
+		{StartTime: 0, EndTime: 1000, Words: words, BGs: []ttml.LyricLine{{StartTime: 0, EndTime: 1000, IsBG: true, Words: words}}},
+		{StartTime: 1000, EndTime: 2000, IsDuet: true, Words: words},
+	}
+	lyrics, err := New(lines, 1000, ft.NewFontManager(16), ft.DefaultRequest(), 48, 0.5)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// duet-split 下右到左的主唱行靠右、对唱行靠左，背景行跟随主行
+	mainLine, duet := lyrics.Lines[0], lyrics.Lines[1]
+	if !mainLine.RTL || mainLine.GetPosition().GetX() != 100 {
+		t.Fatalf("RTL main line: rtl=%v x=%v, want x=100", mainLine.RTL, mainLine.GetPosition().GetX())
+	}
+	if bg := mainLine.BackgroundLines[0]; bg.GetPosition().GetX() != 100 {
+		t.Fatalf("RTL background line x = %v, want 100", bg.GetPosition().GetX())
+	}
+	if !duet.RTL || duet.GetPosition().GetX() != 0 {
+		t.Fatalf("RTL duet line: rtl=%v x=%v, want x=0", duet.RTL, duet.GetPosition().GetX())
+	}
+}
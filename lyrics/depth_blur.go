@@ -0,0 +1,99 @@
+package lyrics
+
+// 文件说明：非当前行的景深模糊。
+// 主要职责：按与锚点行的距离给每行分配模糊量，并缓存模糊后的行位图，行位图不变时不会逐帧重复模糊。
+
+import (
+	"math"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/filters"
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// DefaultDepthBlurStrength 是每远离当前行一行增加的模糊量（逻辑像素）。
+	DefaultDepthBlurStrength = 1.0
+	// depthBlurMaxLines 行以外不再继续加深模糊，模糊量也不会超过行内边距太多。
+	depthBlurMaxLines = 4
+	// depthBlurQuantum 是模糊量的取整粒度，强度微调时不会让所有行都重新模糊。
+	depthBlurQuantum = 0.5
+)
+
+// depthBlurAmount 返回距当前行 distance 行时的模糊量。
+func depthBlurAmount(distance int, strength float64) float64 {
+	if distance <= 0 || strength <= 0 {
+		return 0
+	}
+	amount := strength * float64(min(distance, depthBlurMaxLines))
+	return math.Round(amount/depthBlurQuantum) * depthBlurQuantum
+}
+
+// SetDepthBlur 开关景深模糊并设置强度，strength 为每远离一行增加的模糊量，<= 0 时等同关闭。
+func (l *Lyrics) SetDepthBlur(enabled bool, strength float64) {
+	if l == nil {
+		return
+	}
+	l.DepthBlur = enabled
+	l.DepthBlurStrength = max(strength, 0)
+	l.updateDepthBlur()
+}
+
+// updateDepthBlur 按锚点行重新分配各行的模糊量；正在播放的行和手动滚动期间都不模糊。
+func (l *Lyrics) updateDepthBlur() {
+	strength := l.DepthBlurStrength
+	if !l.DepthBlur || l.scrollOffset != 0 {
+		strength = 0
+	}
+	for i, line := range l.Lines {
+		distance := i - l.anchorIndex
+		if distance < 0 {
+			distance = -distance
+		}
+		if hasInt(l.nowLyrics, i) {
+			distance = 0
+		}
+		amount := depthBlurAmount(distance, strength)
+		line.setDepthBlur(amount)
+		for _, bgLine := range line.BackgroundLines {
+			bgLine.setDepthBlur(amount)
+		}
+	}
+}
+
+func (l *Line) setDepthBlur(amount float64) {
+	if l == nil {
+		return
+	}
+	l.depthBlur = amount
+}
+
+// depthImage 返回绘制所用的行位图：需要模糊时返回缓存的模糊位图，模糊量或行位图变化后才重新生成。
+// 逐帧重绘的行不做模糊，避免每帧都跑一遍模糊。
+func (l *Line) depthImage() *ebiten.Image {
+	if l.depthBlur <= 0 || l.Image == nil || l.Status.RequiresRealtimeRender() {
+		return l.Image
+	}
+	if l.blurImage != nil && l.blurredFor == l.depthBlur {
+		return l.blurImage
+	}
+	l.releaseDepthImage()
+	l.blurImage = filters.BlurImageShader(l.Image, lp.LP(l.depthBlur))
+	l.blurredFor = l.depthBlur
+	return l.blurImage
+}
+
+// releaseDepthImage 丢弃缓存的模糊位图，行位图重绘后调用。
+func (l *Line) releaseDepthImage() {
+	if l.blurImage != nil {
+		l.blurImage.Deallocate()
+		l.blurImage = nil
+	}
+	l.blurredFor = 0
+}
+
+// GetDepthBlurImage 返回缓存的景深模糊位图，没有时为 nil。
+func (l *Line) GetDepthBlurImage() *ebiten.Image {
+	return l.blurImage
+}
@@ -0,0 +1,47 @@
+package lyrics
+
+import "testing"
+
+func TestDepthBlurFollowsDistanceFromAnchor(t *testing.T) {
+	lyrics := &Lyrics{anchorIndex: 2, nowLyrics: []int{2, 3}}
+	for i := 0; i < 9; i++ {
+		lyrics.Lines = append(lyrics.Lines, &Line{})
+	}
+	bg := &Line{}
+	lyrics.Lines[0].BackgroundLines = []*Line{bg}
+
+	lyrics.SetDepthBlur(true, 1)
+	want := []float64{2, 1, 0, 0, 2, 3, 4, 4, 4}
+	for i, line := range lyrics.Lines {
+		if line.depthBlur != want[i] {
+			t.Fatalf("line %d blur = %v, want %v", i, line.depthBlur, want[i])
+		}
+	}
+	if bg.depthBlur != 2 {
+		t.Fatalf("background line blur = %v, want its main line's 2", bg.depthBlur)
+	}
+
+	// 手动浏览期间全部清晰，回到当前行后恢复
+	lyrics.scrollOffset = 10
+	lyrics.updateDepthBlur()
+	if lyrics.Lines[6].depthBlur != 0 {
+		t.Fatalf("blur while browsing = %v, want 0", lyrics.Lines[6].depthBlur)
+	}
+	lyrics.scrollOffset = 0
+	lyrics.SetDepthBlur(false, 1)
+	if lyrics.Lines[6].depthBlur != 0 {
+		t.Fatalf("disabled blur = %v, want 0", lyrics.Lines[6].depthBlur)
+	}
+}
+
+func TestDepthBlurAmountIsQuantized(t *testing.T) {
+	if got := depthBlurAmount(1, 0.7); got != 0.5 {
+		t.Fatalf("amount = %v, want 0.5", got)
+	}
+	if got := depthBlurAmount(9, 1.3); got != 5 {
+		t.Fatalf("capped amount = %v, want 5", got)
+	}
+	if got := depthBlurAmount(3, 0); got != 0 {
+		t.Fatalf("zero strength amount = %v, want 0", got)
+	}
+}
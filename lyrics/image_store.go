@@ -28,6 +28,7 @@ type textMaskKey struct {
 	font fontKey
 	w    int
 	h    int
+	rtl  bool
 }
 
 type gradientKey struct {
@@ -96,7 +97,7 @@ func fontKeyFromRequest(fontManager *ft.FontManager, req ft.FontRequest, size fl
 	}
 }
 
-func (s *imageStore) acquireTextMask(text string, fontManager *ft.FontManager, req ft.FontRequest, size, w, h float64) (*ebiten.Image, textMaskKey) {
+func (s *imageStore) acquireTextMask(text string, fontManager *ft.FontManager, req ft.FontRequest, size, w, h float64, rtl bool) (*ebiten.Image, textMaskKey) {
 	if text == "" {
 		text = " "
 	}
@@ -106,6 +107,7 @@ func (s *imageStore) acquireTextMask(text string, fontManager *ft.FontManager, r
 		font: fontKeyFromRequest(fontManager, req, physicalSize),
 		w:    safeImageLength(w),
 		h:    safeImageLength(h),
+		rtl:  rtl,
 	}
 	if key.w < 1 {
 		key.w = 1
@@ -126,11 +128,11 @@ func (s *imageStore) acquireTextMask(text string, fontManager *ft.FontManager, r
 	}
 	s.mu.Unlock()
 
-	face, err := fontManager.GetFaceForText(req, size, text)
+	face, err := fontManager.GetFaceForTextDirection(req, size, text, textDirection(rtl))
 	if err != nil || face == nil {
 		return nil, key
 	}
-	img := CreateTextMask(text, face, w, h)
+	img := createTextMask(text, face, w, h, rtl)
 
 	s.mu.Lock()
 	if entry, ok := s.textMasks[key]; ok {
@@ -218,8 +220,8 @@ func (s *imageStore) releaseGradient(key gradientKey) {
 	}
 }
 
-func acquireTextMask(text string, fontManager *ft.FontManager, req ft.FontRequest, size, w, h float64) (*ebiten.Image, textMaskKey) {
-	return sharedImageStore.acquireTextMask(text, fontManager, req, size, w, h)
+func acquireTextMask(text string, fontManager *ft.FontManager, req ft.FontRequest, size, w, h float64, rtl bool) (*ebiten.Image, textMaskKey) {
+	return sharedImageStore.acquireTextMask(text, fontManager, req, size, w, h, rtl)
 }
 
 func releaseTextMask(key textMaskKey) {
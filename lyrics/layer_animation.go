@@ -12,10 +12,8 @@ import (
 	"unicode/utf8"
 
 	"github.com/xiaowumin-mark/EbitenLyrics/anim"
-	"github.com/xiaowumin-mark/EbitenLyrics/lp"
 
 	"github.com/google/uuid"
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 var CustomElastic = anim.NewEaseInElastic(1.03, 1.7)
@@ -51,6 +49,39 @@ func (l *Lyrics) Update(t time.Duration) {
 	lineAnimationLayer.UpdateLyrics(l, t)
 }
 
+const (
+	// maxKickScale 是满强度节拍时当前行额外放大的比例。
+	maxKickScale = 0.04
+	kickDecay    = 120 * time.Millisecond
+)
+
+// Kick 让当前行随节拍轻微放大后回落，strength 为 0-1。
+// 脉冲只在绘制时叠加，不影响行本身的缩放动画。
+func (l *Lyrics) Kick(strength float64) {
+	if l == nil || strength <= 0 {
+		return
+	}
+	now := time.Now()
+	// 上一次脉冲还没回落到比这次更小时不打断它
+	kick := math.Min(1, strength) * maxKickScale
+	if kick >= l.kickScale(now)-1 {
+		l.kickStrength = kick
+		l.kickAt = now
+	}
+}
+
+func (l *Lyrics) kickScale(now time.Time) float64 {
+	if l == nil || l.kickStrength <= 0 {
+		return 1
+	}
+	elapsed := now.Sub(l.kickAt)
+	if elapsed > 6*kickDecay {
+		l.kickStrength = 0
+		return 1
+	}
+	return 1 + l.kickStrength*math.Exp(-elapsed.Seconds()/kickDecay.Seconds())
+}
+
 func (l *Line) ToNormal(lyrics *Lyrics) {
 	lineAnimationLayer.NormalizeLine(l, lyrics)
 }
@@ -237,16 +268,16 @@ func predictedScrollAnchorIndex(lines []*Line, t time.Duration) int {
 	return findScrollAnchorIndexByTime(lines, t+scrollLeadTime())
 }
 
-func (AnimationLayer) ensureScrollAnimation(l *Line, lyrics *Lyrics, targetY float64, delay, duration time.Duration, ease anim.EaseFunc) {
+func (AnimationLayer) ensureScrollAnimation(l *Line, lyrics *Lyrics, axis scrollAxis, targetY float64, delay, duration time.Duration, ease anim.EaseFunc) {
 	if l == nil || lyrics == nil {
 		return
 	}
-	if math.Abs(l.GetPosition().GetY()-targetY) <= scrollReuseTargetEpsilon {
+	if math.Abs(axis.flow(l.GetPosition())-targetY) <= scrollReuseTargetEpsilon {
 		if l.ScrollAnimate != nil {
 			cancelManagedAnimation(lyrics.AnimateManager, l.ScrollAnimate)
 			l.ScrollAnimate = nil
 		}
-		l.GetPosition().SetY(targetY)
+		axis.setFlow(l.GetPosition(), targetY)
 		lineAnimationLayer.finishScrollAnimation(l)
 		return
 	}
@@ -265,11 +296,11 @@ func (AnimationLayer) ensureScrollAnimation(l *Line, lyrics *Lyrics, targetY flo
 		duration,
 		delay,
 		1,
-		l.GetPosition().GetY(),
+		axis.flow(l.GetPosition()),
 		targetY,
 		ease,
 		func(value float64) {
-			l.GetPosition().SetY(value)
+			axis.setFlow(l.GetPosition(), value)
 		},
 		func() {
 			lineAnimationLayer.finishScrollAnimation(l)
@@ -330,24 +361,26 @@ func (AnimationLayer) scrollLyricsTo(l *Lyrics, activeIndexes []int, anchorIndex
 		activeSet[anchorIndex] = struct{}{}
 	}
 
-	_, h := ebiten.WindowSize()
-	viewportHeight := lp.FromLP(float64(h))
-	offsetY := -viewportHeight / 4
+	// 横排沿 Y 滚动，竖排沿 X 从右向左滚动，下面的“Y/高度”都是流动方向上的坐标与长度。
+	axis := l.scrollAxis()
+	viewportHeight := axis.viewport
+	offsetY := -viewportHeight * l.FocusPosition
 	for i := 0; i < anchorIndex; i++ {
-		offsetY += l.Lines[i].Position.GetH()
+		offsetY += axis.extent(&l.Lines[i].Position)
 		if _, ok := activeSet[i]; ok && len(l.Lines[i].BackgroundLines) > 0 {
 			for _, bgLine := range l.Lines[i].BackgroundLines {
 				if !backgroundLineReservesSpace(bgLine) {
 					continue
 				}
-				offsetY += bgLine.Position.GetH()
+				offsetY += axis.extent(&bgLine.Position)
 			}
 		}
 	}
 
 	overscan := math.Max(120, viewportHeight*0.45)
-	viewportTop := -overscan
-	viewportBottom := viewportHeight + overscan
+	// 手动滚动平移了画面，裁剪窗口跟着反向平移
+	viewportTop := -overscan - l.cullOffset
+	viewportBottom := viewportHeight + overscan - l.cullOffset
 	isInitialPlacement := notInit == 0
 	cullTransitionDistance := overscan * 1.35
 	snapDistance := math.Max(viewportHeight*0.9, 320)
@@ -358,26 +391,26 @@ func (AnimationLayer) scrollLyricsTo(l *Lyrics, activeIndexes []int, anchorIndex
 		targetLineY := lastY - offsetY
 		_, isActive := activeSet[i]
 		isAnchor := i == anchorIndex
-		currentLineY := line.GetPosition().GetY()
+		currentLineY := axis.flow(line.GetPosition())
 		if isInitialPlacement {
 			currentLineY = targetLineY
 		}
-		lineHeight := line.GetPosition().GetH()
+		lineHeight := axis.extent(line.GetPosition())
 		shouldRender := lineVisibleAt(targetLineY, lineHeight, viewportTop, viewportBottom)
 		if !shouldRender && !isInitialPlacement && math.Abs(targetLineY-currentLineY) <= cullTransitionDistance {
 			shouldRender = lineVisibleAt(currentLineY, lineHeight, viewportTop, viewportBottom)
 		}
 
-		bgTargetY := targetLineY + line.Position.GetH()
+		bgTargetY := targetLineY + axis.extent(&line.Position)
 		for _, bg := range line.BackgroundLines {
 			if bg == nil {
 				continue
 			}
-			currentBgY := bg.GetPosition().GetY()
+			currentBgY := axis.flow(bg.GetPosition())
 			if isInitialPlacement {
 				currentBgY = bgTargetY
 			}
-			bgHeight := bg.GetPosition().GetH()
+			bgHeight := axis.extent(bg.GetPosition())
 			bgShouldRender := lineVisibleAt(bgTargetY, bgHeight, viewportTop, viewportBottom)
 			if !bgShouldRender && !isInitialPlacement && math.Abs(bgTargetY-currentBgY) <= cullTransitionDistance {
 				bgShouldRender = lineVisibleAt(currentBgY, bgHeight, viewportTop, viewportBottom)
@@ -394,51 +427,51 @@ func (AnimationLayer) scrollLyricsTo(l *Lyrics, activeIndexes []int, anchorIndex
 			renderSet[i] = struct{}{}
 		}
 
-		lineTravel := math.Abs(targetLineY - line.GetPosition().GetY())
+		lineTravel := math.Abs(targetLineY - axis.flow(line.GetPosition()))
 		if isInitialPlacement || !shouldRender || lineTravel > snapDistance {
 			if line.ScrollAnimate != nil {
 				cancelManagedAnimation(l.AnimateManager, line.ScrollAnimate)
 				line.ScrollAnimate = nil
 			}
-			line.GetPosition().SetY(targetLineY)
+			axis.setFlow(line.GetPosition(), targetLineY)
 			if !line.Status.RequiresRealtimeRender() {
 				lineAnimationLayer.syncPreviewState(line)
 			}
 		} else {
 			delay := scrollDelayForIndex(anchorIndex, i)
-			lineAnimationLayer.ensureScrollAnimation(line, l, targetLineY, delay, scrollDuration, scrollEase)
+			lineAnimationLayer.ensureScrollAnimation(line, l, axis, targetLineY, delay, scrollDuration, scrollEase)
 			if !line.Status.RequiresRealtimeRender() {
 				line.setStatus(LineStatusPreviewScrolling)
 			}
 		}
 
 		for _, bg := range line.BackgroundLines {
-			bgTravel := math.Abs(bgTargetY - bg.GetPosition().GetY())
+			bgTravel := math.Abs(bgTargetY - axis.flow(bg.GetPosition()))
 			if isInitialPlacement || !shouldRender || bgTravel > snapDistance {
 				if bg.ScrollAnimate != nil {
 					cancelManagedAnimation(l.AnimateManager, bg.ScrollAnimate)
 					bg.ScrollAnimate = nil
 				}
-				bg.GetPosition().SetY(bgTargetY)
+				axis.setFlow(bg.GetPosition(), bgTargetY)
 				if !bg.Status.RequiresRealtimeRender() {
 					lineAnimationLayer.syncPreviewState(bg)
 				}
 				continue
 			}
 			delay := scrollDelayForIndex(anchorIndex, i)
-			lineAnimationLayer.ensureScrollAnimation(bg, l, bgTargetY, delay, scrollDuration, scrollEase)
+			lineAnimationLayer.ensureScrollAnimation(bg, l, axis, bgTargetY, delay, scrollDuration, scrollEase)
 			if !bg.Status.RequiresRealtimeRender() {
 				bg.setStatus(LineStatusPreviewScrolling)
 			}
 		}
 
-		lastY += line.Position.GetH() + l.Margin
+		lastY += axis.extent(&line.Position) + l.Margin
 		if isActive && len(line.BackgroundLines) > 0 {
 			for _, bgLine := range line.BackgroundLines {
 				if !backgroundLineReservesSpace(bgLine) {
 					continue
 				}
-				lastY += bgLine.Position.GetH() + l.Margin
+				lastY += axis.extent(&bgLine.Position) + l.Margin
 			}
 		}
 	}
@@ -449,6 +482,7 @@ func (AnimationLayer) scrollLyricsTo(l *Lyrics, activeIndexes []int, anchorIndex
 	}
 	sort.Ints(renderIndex)
 	l.renderIndex = renderIndex
+	l.updateDepthBlur()
 
 	for i, el := range l.Lines {
 		if _, ok := renderSet[i]; ok {
@@ -999,6 +1033,7 @@ func (AnimationLayer) FrameAnimate(l *Line, lyrics *Lyrics, fd float64) {
 
 					if ele.BackgroundBlurText == nil {
 						ele.BackgroundBlurText = NewTextShadow(ele.Text, l.FontManager, l.FontRequest, l.fontsize)
+						ele.BackgroundBlurText.SetColor(l.theme.Shadow)
 					}
 					ele.BackgroundBlurText.Blur = hl
 
@@ -1137,11 +1172,16 @@ func (AnimationLayer) DisposeLyrics(l *Lyrics) {
 		return
 	}
 	lineAnimationLayer.DisposeLyricsAnimations(l)
+	if l.themeAnimate != nil {
+		cancelManagedAnimation(l.AnimateManager, l.themeAnimate)
+		l.themeAnimate = nil
+	}
 	for _, line := range l.Lines {
 		lineRendererLayer.DisposeLine(line)
 	}
 	l.nowLyrics = nil
 	l.renderIndex = nil
+	l.hoverLine = nil
 	l.Lines = nil
 }
 
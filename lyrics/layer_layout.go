@@ -69,11 +69,13 @@ func (LayoutLayer) LayoutLine(l *Line) {
 		}
 	}
 
-	align := text.AlignStart
-	if l.IsDuet {
-		align = text.AlignEnd
+	if l.WritingMode == WritingVertical {
+		lineLayoutLayer.layoutLineVertical(l, grouped)
+		return
 	}
 
+	align := l.textAlign()
+
 	maxWidth := w - l.Padding*2
 	if maxWidth < 1 {
 		maxWidth = 1
@@ -81,6 +83,7 @@ func (LayoutLayer) LayoutLine(l *Line) {
 
 	positions, height := AutoLayoutSyllable(grouped, face, maxWidth, l.lineHeight, 1, align)
 	height += l.Padding * 2
+	lineLayoutLayer.reorderLineBidi(l, positions, orderedIndexes)
 
 	for posIdx, pos := range positions {
 		if posIdx >= len(orderedIndexes) {
@@ -95,12 +98,23 @@ func (LayoutLayer) LayoutLine(l *Line) {
 		pos.SetX(pos.GetX() + l.Padding)
 		pos.SetY(pos.GetY() + l.Padding)
 		lastX := pos.GetX()
+		if syll.RTL {
+			// 右到左音节的元素从右向左排列
+			lastX += pos.GetW()
+		}
 		for _, element := range syll.Elements {
-			element.GetPosition().SetX(lastX)
-			element.GetPosition().SetY(pos.GetY())
+			elementW := 0.0
 			if element.SyllableImage != nil {
-				lastX += element.SyllableImage.GetWidth()
+				elementW = element.SyllableImage.GetWidth()
+			}
+			if syll.RTL {
+				lastX -= elementW
+				element.GetPosition().SetX(lastX)
+			} else {
+				element.GetPosition().SetX(lastX)
+				lastX += elementW
 			}
+			element.GetPosition().SetY(pos.GetY())
 		}
 	}
 
@@ -110,16 +124,81 @@ func (LayoutLayer) LayoutLine(l *Line) {
 
 	l.GetPosition().SetH(height + l.TranslateImageH)
 	l.GetPosition().SetOriginY(l.GetPosition().GetH() / 2)
-	if l.IsDuet {
-		l.GetPosition().SetOriginX(l.GetPosition().GetW())
-	} else {
-		l.GetPosition().SetOriginX(0)
-	}
+	l.GetPosition().SetOriginX(l.alignOriginX())
 	if l.IsBackground {
 		l.GetPosition().SetOriginY(l.GetPosition().GetH())
 	}
 }
 
+// reorderLineBidi 在行内含右到左文字时，把逻辑顺序排好的音节位置重排为视觉顺序。
+func (LayoutLayer) reorderLineBidi(l *Line, positions []Position, orderedIndexes []int) {
+	needed := l.RTL
+	items := make([]bidiItem, 0, len(positions))
+	for i := range positions {
+		if i >= len(orderedIndexes) {
+			break
+		}
+		syllableIndex := orderedIndexes[i]
+		if syllableIndex < 0 || syllableIndex >= len(l.Syllables) {
+			continue
+		}
+		syll := l.Syllables[syllableIndex]
+		needed = needed || syll.RTL
+		items = append(items, bidiItem{
+			pos:   &positions[i],
+			rtl:   syll.RTL,
+			blank: strings.TrimSpace(syll.Syllable) == "",
+		})
+	}
+	if needed {
+		reorderBidi(items, l.RTL)
+	}
+}
+
+// layoutLineVertical 竖排一行：正文列从右向左，翻译列放在正文左侧；行高固定，行宽由列数决定。
+func (LayoutLayer) layoutLineVertical(l *Line, grouped [][]*LineSyllable) {
+	maxHeight := l.GetPosition().GetH() - l.Padding*2
+	if maxHeight < 1 {
+		maxHeight = 1
+	}
+	if l.TranslatedText != "" && l.TranslateImageW == 0 {
+		lineLayoutLayer.GenerateLineTranslateImage(l)
+	}
+
+	columnGap := l.fontsize * verticalColumnGapRatio
+	positions, width := AutoLayoutSyllableVertical(grouped, l.fontsize, maxHeight, columnGap, l.textAlign())
+
+	offsetX := l.Padding
+	if l.TranslateImageW > 0 {
+		offsetX += l.TranslateImageW + columnGap
+	}
+	i := 0
+	for _, group := range grouped {
+		for _, syll := range group {
+			for _, element := range syll.Elements {
+				if element == nil || element.SyllableImage == nil || i >= len(positions) {
+					continue
+				}
+				element.GetPosition().SetX(positions[i].GetX() + offsetX)
+				element.GetPosition().SetY(positions[i].GetY() + l.Padding)
+				i++
+			}
+		}
+	}
+
+	l.GetPosition().SetW(offsetX + width + l.Padding)
+	// 首列在右侧，缩放贴住右边缘；纵向原点跟随列内对齐
+	l.GetPosition().SetOriginX(l.GetPosition().GetW())
+	switch l.textAlign() {
+	case text.AlignCenter:
+		l.GetPosition().SetOriginY(l.GetPosition().GetH() / 2)
+	case text.AlignEnd:
+		l.GetPosition().SetOriginY(l.GetPosition().GetH())
+	default:
+		l.GetPosition().SetOriginY(0)
+	}
+}
+
 func (LayoutLayer) GenerateLineTranslateImage(l *Line) {
 	if l == nil {
 		return
@@ -136,9 +215,23 @@ func (LayoutLayer) GenerateLineTranslateImage(l *Line) {
 		return
 	}
 
-	align := text.AlignStart
-	if l.IsDuet {
-		align = text.AlignEnd
+	align := l.textAlign()
+
+	if l.WritingMode == WritingVertical {
+		maxHeight := l.GetPosition().GetH() - l.Padding*2
+		if maxHeight < 1 {
+			maxHeight = 1
+		}
+		positions, w := AutoLayoutVertical(
+			l.TranslatedText,
+			translateFace,
+			maxHeight,
+			l.fontsize*verticalColumnGapRatio,
+			1,
+			align,
+		)
+		lineLayoutLayer.drawTranslateImage(l, translateFace, positions, w, maxHeight)
+		return
 	}
 
 	maxWidth := l.GetPosition().GetW() - l.Padding*2
@@ -164,7 +257,12 @@ func (LayoutLayer) GenerateLineTranslateImage(l *Line) {
 			align,
 		)
 	}
-	l.TranslateImageW = maxWidth
+	lineLayoutLayer.drawTranslateImage(l, translateFace, positions, maxWidth, h)
+}
+
+// drawTranslateImage 记录翻译尺寸，并在行可见时把排好的字符画进翻译位图。
+func (LayoutLayer) drawTranslateImage(l *Line, face text.Face, positions []LayoutLine, w, h float64) {
+	l.TranslateImageW = w
 	l.TranslateImageH = h
 
 	if !l.isShow {
@@ -178,13 +276,17 @@ func (LayoutLayer) GenerateLineTranslateImage(l *Line) {
 	if l.TranslateImage != nil {
 		l.TranslateImage.Deallocate()
 	}
-	l.TranslateImage = ebiten.NewImage(safeImageLength(maxWidth), safeImageLength(h))
+	l.TranslateImage = ebiten.NewImage(safeImageLength(w), safeImageLength(h))
+	rtl := paragraphIsRTL(l.TranslatedText)
 	for _, pos := range positions {
 		op := &text.DrawOptions{}
+		if rtl {
+			// 与文字遮罩相同：右到左排版时 AlignEnd 才贴左侧
+			op.PrimaryAlign = text.AlignEnd
+		}
 		op.GeoM.Translate(lp.LP(pos.X), lp.LP(pos.Y))
 		op.ColorScale.ScaleWithColor(color.White)
-		op.ColorScale.ScaleAlpha(0.4)
-		text.Draw(l.TranslateImage, pos.Text, translateFace, op)
+		text.Draw(l.TranslateImage, pos.Text, face, op)
 	}
 	l.markImageDirty()
 }
@@ -202,6 +304,7 @@ func (LayoutLayer) SetLineFont(l *Line, fontManager *ft.FontManager, req ft.Font
 	for _, syllable := range l.Syllables {
 		syllable.SetFont(fontManager, l.FontRequest, l.fontsize)
 	}
+	l.shapeWords()
 	lineLayoutLayer.GenerateLineTranslateImage(l)
 	lineLayoutLayer.LayoutLine(l)
 	lineRendererLayer.RecreateLineImage(l)
@@ -219,6 +322,7 @@ func (LayoutLayer) SetLineFontSize(l *Line, fontsize float64) {
 	for _, syllable := range l.Syllables {
 		syllable.SetFont(l.FontManager, l.FontRequest, fontsize)
 	}
+	l.shapeWords()
 	lineLayoutLayer.GenerateLineTranslateImage(l)
 	lineLayoutLayer.LayoutLine(l)
 	lineRendererLayer.RecreateLineImage(l)
@@ -228,9 +332,9 @@ func (LayoutLayer) ResizeLine(l *Line, width float64) {
 	if l == nil || width <= 0 {
 		return
 	}
-	l.GetPosition().SetW(width * 0.9)
-	if l.IsDuet {
-		l.GetPosition().SetX(width - l.GetPosition().GetW())
+	if l.WritingMode != WritingVertical {
+		l.GetPosition().SetW(width * 0.9)
+		l.placeX(width)
 	}
 	lineLayoutLayer.GenerateLineTranslateImage(l)
 	lineLayoutLayer.LayoutLine(l)
@@ -246,7 +350,13 @@ func (LayoutLayer) ResizeLyrics(l *Lyrics, w float64) {
 	if l == nil {
 		return
 	}
+	l.width = w
+	viewport := l.ViewportHeight
 	for _, line := range l.Lines {
+		if l.WritingMode == WritingVertical {
+			// 竖排行高跟随可见区域高度
+			line.applyWritingBox(w, viewport)
+		}
 		lineLayoutLayer.ResizeLine(line, w)
 	}
 }
@@ -4,6 +4,8 @@ package lyrics
 // 主要职责：根据当前状态把歌词行与音节绘制到目标画面。
 
 import (
+	"time"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/xiaowumin-mark/EbitenLyrics/lp"
 )
@@ -44,6 +46,7 @@ func (RendererLayer) RecreateLineImage(l *Line) {
 		l.Image.Deallocate()
 		l.Image = nil
 	}
+	l.releaseDepthImage()
 	if !l.isShow {
 		return
 	}
@@ -60,16 +63,24 @@ func (RendererLayer) redrawLineImage(l *Line) {
 	}
 
 	l.Image.Clear()
+	l.releaseDepthImage()
 	for _, syllable := range l.Syllables {
 		syllable.Draw(l.Image)
 	}
 
 	if l.TranslateImage != nil {
 		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(
-			lp.LP(l.Padding),
-			lp.LP(l.GetPosition().GetH()-l.TranslateImageH-l.Padding),
-		)
+		if l.WritingMode == WritingVertical {
+			// 竖排翻译列位于正文左侧
+			op.GeoM.Translate(lp.LP(l.Padding), lp.LP(l.Padding))
+		} else {
+			op.GeoM.Translate(
+				lp.LP(l.Padding),
+				lp.LP(l.GetPosition().GetH()-l.TranslateImageH-l.Padding),
+			)
+		}
+		// 翻译位图以白色生成，颜色在合成时按主题染色，切换主题无需重新排版。
+		op.ColorScale.ScaleWithColor(l.theme.Translation)
 		l.Image.DrawImage(l.TranslateImage, op)
 	}
 
@@ -77,6 +88,11 @@ func (RendererLayer) redrawLineImage(l *Line) {
 }
 
 func (RendererLayer) DrawLine(l *Line, screen *ebiten.Image) {
+	lineRendererLayer.drawLineScaled(l, screen, 1, 0, 0)
+}
+
+// drawLineScaled 在行自身变换的基础上再按 scale 缩放（节拍脉冲）并平移 (dx, dy)（手动滚动），不改动行的 Position。
+func (RendererLayer) drawLineScaled(l *Line, screen *ebiten.Image, scale, dx, dy float64) {
 	if l == nil || screen == nil || !l.isShow {
 		return
 	}
@@ -94,11 +110,20 @@ func (RendererLayer) DrawLine(l *Line, screen *ebiten.Image) {
 		lineRendererLayer.redrawLineImage(l)
 	}
 
+	pos := l.GetPosition()
+	if scale != 1 {
+		scaled := *pos
+		scaled.ScaleX *= scale
+		scaled.ScaleY *= scale
+		pos = &scaled
+	}
+	geo := TransformToGeoM(pos)
+	geo.Translate(lp.LP(dx), lp.LP(dy))
 	drawImageResample4x4(
 		screen,
-		l.Image,
-		TransformToGeoM(l.GetPosition()),
-		float32(l.GetPosition().GetAlpha()),
+		l.depthImage(),
+		geo,
+		float32(l.hoverAlpha(l.GetPosition().GetAlpha(), time.Now())),
 		ebiten.BlendLighter,
 	)
 }
@@ -107,6 +132,7 @@ func (l *Line) canUseStaticLayer() bool {
 	return l != nil &&
 		l.isShow &&
 		l.Status == LineStatusPreviewStatic &&
+		!l.hoverActive() &&
 		l.GetPosition().GetAlpha() > 0
 }
 
@@ -139,6 +165,7 @@ func (RendererLayer) DisposeLine(l *Line) {
 		l.Image.Deallocate()
 		l.Image = nil
 	}
+	l.releaseDepthImage()
 	l.isShow = false
 	l.imageDirty = true
 	l.setStatus(LineStatusHidden)
@@ -200,19 +227,25 @@ func (RendererLayer) drawLyricsFiltered(l *Lyrics, screen *ebiten.Image, include
 	if l == nil || screen == nil {
 		return
 	}
+	kick := l.kickScale(time.Now())
+	dx, dy := l.scrollShift()
 	for _, i := range l.renderIndex {
 		if i < 0 || i >= len(l.Lines) {
 			continue
 		}
 		line := l.Lines[i]
-		if include == nil || include(line) {
-			lineRendererLayer.DrawLine(line, screen)
+		if (include == nil || include(line)) && l.inViewport(line, dx, dy) {
+			scale := 1.0
+			if kick != 1 && hasInt(l.nowLyrics, i) {
+				scale = kick
+			}
+			lineRendererLayer.drawLineScaled(line, screen, scale, dx, dy)
 		}
 		for _, bgLine := range line.BackgroundLines {
-			if include != nil && !include(bgLine) {
+			if (include != nil && !include(bgLine)) || !l.inViewport(bgLine, dx, dy) {
 				continue
 			}
-			lineRendererLayer.DrawLine(bgLine, screen)
+			lineRendererLayer.drawLineScaled(bgLine, screen, 1, dx, dy)
 		}
 	}
 }
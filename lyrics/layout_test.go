@@ -124,3 +124,95 @@ func TestAutoLayoutSmartBalancesTranslationWrap(t *testing.T) {
 		t.Fatalf("unexpected second line: %q", lines[1].Text)
 	}
 }
+
+func TestLyricAlignPlacesLinesAndOrigins(t *testing.T) {
+	for _, align := range LyricAligns() {
+		parsed, err := ParseLyricAlign(strings.ToUpper(align.String()))
+		if err != nil || parsed != align {
+			t.Fatalf("ParseLyricAlign(%q) = %v, %v", align.String(), parsed, err)
+		}
+	}
+	if _, err := ParseLyricAlign("middle"); err == nil {
+		t.Fatal("ParseLyricAlign accepted an unknown mode")
+	}
+
+	cases := []struct {
+		align   LyricAlign
+		duet    bool
+		wantX   float64
+		wantOrg float64
+	}{
+		{AlignDuetSplit, false, 0, 0},
+		{AlignDuetSplit, true, 100, 900},
+		{AlignStart, true, 0, 0},
+		{AlignCenter, false, 50, 450},
+		{AlignCenter, true, 50, 450},
+		{AlignEnd, false, 100, 900},
+	}
+	for _, tc := range cases {
+		line := &Line{IsDuet: tc.duet, Align: tc.align, Position: NewPosition(0, 0, 900, 40)}
+		line.placeX(1000)
+		if got := line.GetPosition().GetX(); got != tc.wantX {
+			t.Errorf("%v duet=%v: X = %v, want %v", tc.align, tc.duet, got, tc.wantX)
+		}
+		if got := line.alignOriginX(); got != tc.wantOrg {
+			t.Errorf("%v duet=%v: origin = %v, want %v", tc.align, tc.duet, got, tc.wantOrg)
+		}
+	}
+}
+
+func TestAutoLayoutSyllableVerticalStacksColumnsRightToLeft(t *testing.T) {
+	layoutData := [][]*LineSyllable{
+		{makeTestSyllable("日", 20)},
+		{makeTestSyllable("本", 20)},
+		{makeTestSyllable("a", 8), makeTestSyllable("b", 8)},
+	}
+	positions, width := AutoLayoutSyllableVertical(layoutData, 20, 50, 5, text.AlignStart)
+	if len(positions) != 4 {
+		t.Fatalf("expected 4 positions, got %d", len(positions))
+	}
+	// 两列，每列宽 20，列距 5
+	if width != 45 {
+		t.Fatalf("width = %v, want 45", width)
+	}
+	if positions[0].GetX() != 25 || positions[0].GetY() != 0 || positions[1].GetX() != 25 || positions[1].GetY() != 20 {
+		t.Fatalf("first column misplaced: %+v %+v", positions[0], positions[1])
+	}
+	// 拉丁字母组放不下时整体换到左侧新列，步进至少 0.6 个字号，并在列内水平居中
+	a, b := positions[2], positions[3]
+	if a.GetX() != 6 || b.GetX() != 6 {
+		t.Fatalf("latin group should start a new column on the left: a=%+v b=%+v", a, b)
+	}
+	if b.GetY()-a.GetY() != 12 {
+		t.Fatalf("latin advance = %v, want 12", b.GetY()-a.GetY())
+	}
+
+	centered, centeredWidth := AutoLayoutSyllableVertical(layoutData[:1], 20, 50, 5, text.AlignCenter)
+	if centeredWidth != 20 || centered[0].GetY() != 15 {
+		t.Fatalf("centered column: width=%v y=%v", centeredWidth, centered[0].GetY())
+	}
+}
+
+func TestWritingModeScrollAxis(t *testing.T) {
+	for _, mode := range WritingModes() {
+		parsed, err := ParseWritingMode(" " + strings.ToUpper(mode.String()))
+		if err != nil || parsed != mode {
+			t.Fatalf("ParseWritingMode(%q) = %v, %v", mode.String(), parsed, err)
+		}
+	}
+	if _, err := ParseWritingMode("diagonal"); err == nil {
+		t.Fatal("ParseWritingMode accepted an unknown mode")
+	}
+
+	pos := NewPosition(0, 0, 120, 600)
+	vertical := scrollAxis{vertical: true, viewport: 1000}
+	vertical.setFlow(&pos, 300)
+	if pos.GetX() != 580 || vertical.flow(&pos) != 300 || vertical.extent(&pos) != 120 {
+		t.Fatalf("vertical axis: x=%v flow=%v extent=%v", pos.GetX(), vertical.flow(&pos), vertical.extent(&pos))
+	}
+	horizontal := scrollAxis{viewport: 800}
+	horizontal.setFlow(&pos, 42)
+	if pos.GetY() != 42 || horizontal.flow(&pos) != 42 || horizontal.extent(&pos) != 600 {
+		t.Fatalf("horizontal axis: y=%v extent=%v", pos.GetY(), horizontal.extent(&pos))
+	}
+}
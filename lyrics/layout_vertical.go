@@ -0,0 +1,223 @@
+package lyrics
+
+// 文件说明：竖排（縦書き）布局算法。
+// 主要职责：把音节元素或翻译文本自上而下排成列、列从右向左排列，并按对齐方式在列内定位。
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// 竖排中非方块字（拉丁字母、数字等）直立堆叠时的最小步进，相对字号。
+const verticalMinAdvanceRatio = 0.6
+
+// verticalCell 是竖排中的一个字符单元。
+type verticalCell struct {
+	width   float64
+	height  float64
+	advance float64
+	space   bool
+}
+
+// verticalAdvance 返回竖排时一个单元占用的纵向长度：汉字、假名、谚文和空白按字宽，
+// 其余字符至少占 verticalMinAdvanceRatio 个字号，避免直立的字母互相重叠。
+func verticalAdvance(s string, width, fontSize float64) float64 {
+	r, _ := utf8.DecodeRuneInString(s)
+	if isCJKLayoutRune(r) || unicode.IsSpace(r) || isFullwidthPunct(r) {
+		return width
+	}
+	return math.Max(width, fontSize*verticalMinAdvanceRatio)
+}
+
+// layoutVerticalCells 把单元按组排成列：同一组尽量不跨列，nil 组表示强制换列。
+// 返回每个单元的左上角位置（与 groups 展开顺序一致）和所有列的总宽度。
+func layoutVerticalCells(groups [][]verticalCell, maxHeight, columnSpacing float64, align text.Align) ([]Position, float64) {
+	if maxHeight < 1 {
+		maxHeight = 1
+	}
+	type placed struct {
+		cell   verticalCell
+		column int
+		y      float64
+	}
+	var (
+		cells     []placed
+		columnLen []float64
+		trailing  []float64 // 每列末尾空白的长度，对齐时不计入
+		colWidth  float64
+	)
+	newColumn := func() {
+		columnLen = append(columnLen, 0)
+		trailing = append(trailing, 0)
+	}
+	current := func() int { return len(columnLen) - 1 }
+	newColumn()
+
+	for _, group := range groups {
+		if group == nil {
+			if columnLen[current()] > 0 {
+				newColumn()
+			}
+			continue
+		}
+		groupLen := 0.0
+		for _, c := range group {
+			groupLen += c.advance
+		}
+		if columnLen[current()] > 0 && columnLen[current()]+groupLen > maxHeight {
+			newColumn()
+		}
+		for _, c := range group {
+			col := current()
+			if columnLen[col] > 0 && columnLen[col]+c.advance > maxHeight {
+				newColumn()
+				col = current()
+			}
+			colWidth = math.Max(colWidth, c.width)
+			if c.space && columnLen[col] == 0 {
+				// 列首空白不占位置
+				cells = append(cells, placed{cell: c, column: col})
+				continue
+			}
+			cells = append(cells, placed{cell: c, column: col, y: columnLen[col]})
+			columnLen[col] += c.advance
+			if c.space {
+				trailing[col] += c.advance
+			} else {
+				trailing[col] = 0
+			}
+		}
+	}
+	if len(cells) == 0 {
+		return nil, 0
+	}
+
+	columns := len(columnLen)
+	totalWidth := float64(columns)*colWidth + float64(columns-1)*columnSpacing
+	positions := make([]Position, 0, len(cells))
+	for _, p := range cells {
+		colX := totalWidth - float64(p.column+1)*colWidth - float64(p.column)*columnSpacing
+		shift := 0.0
+		used := columnLen[p.column] - trailing[p.column]
+		switch align {
+		case text.AlignCenter:
+			shift = (maxHeight - used) / 2
+		case text.AlignEnd:
+			shift = maxHeight - used
+		}
+		if shift < 0 {
+			shift = 0
+		}
+		// 元素图像比步进高（含行距），上下居中到步进格内
+		x := colX + (colWidth-p.cell.width)/2
+		y := shift + p.y + (p.cell.advance-p.cell.height)/2
+		positions = append(positions, NewPosition(x, y, p.cell.width, p.cell.height))
+	}
+	return positions, totalWidth
+}
+
+// AutoLayoutSyllableVertical 竖排音节：每个元素占一个单元，同一分组尽量留在同一列。
+// 返回每个元素的位置（按 layoutData、音节、元素顺序展开）与总宽度。
+func AutoLayoutSyllableVertical(
+	layoutData [][]*LineSyllable,
+	fontSize float64,
+	maxHeight float64,
+	columnSpacing float64,
+	align text.Align,
+) ([]Position, float64) {
+	groups := make([][]verticalCell, 0, len(layoutData))
+	for _, group := range layoutData {
+		var cells []verticalCell
+		for _, syllable := range group {
+			if syllable == nil {
+				continue
+			}
+			for _, element := range syllable.Elements {
+				if element == nil || element.SyllableImage == nil {
+					continue
+				}
+				img := element.SyllableImage
+				cells = append(cells, verticalCell{
+					width:   img.GetWidth(),
+					height:  img.GetHeight(),
+					advance: verticalAdvance(element.Text, img.GetWidth(), fontSize),
+					space:   isBlankText(element.Text),
+				})
+			}
+		}
+		if len(cells) > 0 {
+			groups = append(groups, cells)
+		}
+	}
+	return layoutVerticalCells(groups, maxHeight, columnSpacing, align)
+}
+
+// AutoLayoutVertical 竖排纯文本（用于翻译），每个字符一个 LayoutLine，换行符强制换列。
+// 返回字符位置与总宽度。
+func AutoLayoutVertical(
+	textStr string,
+	face text.Face,
+	maxHeight float64,
+	columnSpacing float64,
+	fh float64,
+	align text.Align,
+) ([]LayoutLine, float64) {
+	if face == nil {
+		return nil, 0
+	}
+	metrics := face.Metrics()
+	height := lp.FromLP((metrics.HAscent + metrics.HDescent) * fh)
+	fontSize := lp.FromLP(metrics.HAscent * fh)
+
+	var runes []string
+	var groups [][]verticalCell
+	for i, paragraph := range tokenizeTextParagraphsForLayout(textStr) {
+		if i > 0 {
+			groups = append(groups, nil)
+		}
+		for _, token := range paragraph {
+			var cells []verticalCell
+			for _, r := range token {
+				s := string(r)
+				w, _ := text.Measure(s, face, 0)
+				width := lp.FromLP(w * fh)
+				runes = append(runes, s)
+				cells = append(cells, verticalCell{
+					width:   width,
+					height:  height,
+					advance: verticalAdvance(s, width, fontSize),
+					space:   unicode.IsSpace(r),
+				})
+			}
+			if len(cells) > 0 {
+				groups = append(groups, cells)
+			}
+		}
+	}
+
+	positions, width := layoutVerticalCells(groups, maxHeight, columnSpacing, align)
+	layout := make([]LayoutLine, 0, len(positions))
+	for i, pos := range positions {
+		layout = append(layout, LayoutLine{Text: runes[i], X: pos.GetX(), Y: pos.GetY()})
+	}
+	return layout, width
+}
+
+// isFullwidthPunct 判断 CJK 标点（、。「」等）与全角字符。
+func isFullwidthPunct(r rune) bool {
+	return (r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef)
+}
+
+func isBlankText(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
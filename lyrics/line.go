@@ -39,6 +39,7 @@ func NewLine(st, et time.Duration, isduet, isbg bool, ts string, fontManager *ft
 		FontManager:        fontManager,
 		FontRequest:        req.Normalized(),
 		Position:           pos,
+		theme:              DefaultTheme(),
 	}
 }
 
@@ -68,7 +69,16 @@ func (l *Line) translatedFace() text.Face {
 	if l == nil {
 		return nil
 	}
-	return l.composeFaceForText(l.TranslatedText, l.fontsize/2)
+	// 翻译的方向与正文无关，按翻译文本自身判定
+	if l.FontManager == nil || l.fontsize <= 0 {
+		return nil
+	}
+	dir := textDirection(paragraphIsRTL(l.TranslatedText))
+	face, err := l.FontManager.GetFaceForTextDirection(l.FontRequest, l.fontsize/2, l.TranslatedText, dir)
+	if err != nil {
+		return nil
+	}
+	return face
 }
 
 func safeImageLength(v float64) int {
@@ -104,6 +114,8 @@ func (l *Line) SetSyllables(syllables []*LineSyllable) {
 		}
 	}
 	l.OuterSyllableElements = outerSyllableElements
+	l.analyzeBidi()
+	l.shapeWords()
 	l.markImageDirty()
 }
 
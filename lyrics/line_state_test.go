@@ -121,7 +121,7 @@ func TestEnsureScrollAnimationRetargetDoesNotReapplyDelay(t *testing.T) {
 	line.GetPosition().SetY(0)
 
 	const delay = 40 * time.Millisecond
-	lineAnimationLayer.ensureScrollAnimation(line, lyrics, 100, delay, 100*time.Millisecond, anim.Linear)
+	lineAnimationLayer.ensureScrollAnimation(line, lyrics, scrollAxis{}, 100, delay, 100*time.Millisecond, anim.Linear)
 	manager.Update(50 * time.Millisecond)
 
 	firstY := line.GetPosition().GetY()
@@ -129,7 +129,7 @@ func TestEnsureScrollAnimationRetargetDoesNotReapplyDelay(t *testing.T) {
 		t.Fatalf("initial scroll did not advance, y = %v", firstY)
 	}
 
-	lineAnimationLayer.ensureScrollAnimation(line, lyrics, 200, delay, 100*time.Millisecond, anim.Linear)
+	lineAnimationLayer.ensureScrollAnimation(line, lyrics, scrollAxis{}, 200, delay, 100*time.Millisecond, anim.Linear)
 	manager.Update(20 * time.Millisecond)
 
 	if got := line.GetPosition().GetY(); got <= firstY {
@@ -296,3 +296,23 @@ func TestStaticLayerClassification(t *testing.T) {
 		t.Fatal("active line should be drawn dynamically")
 	}
 }
+
+func TestSetViewportClampsFocusAndDrivesScrollAxis(t *testing.T) {
+	lyrics := &Lyrics{}
+	lyrics.SetViewport(240, 320, 1.5)
+	if lyrics.FocusPosition != 1 {
+		t.Fatalf("FocusPosition = %v, want 1", lyrics.FocusPosition)
+	}
+	if got := lyrics.scrollAxis().viewport; got != 320 {
+		t.Fatalf("horizontal scroll viewport = %v, want 320", got)
+	}
+	// 竖排沿宽度滚动
+	lyrics.WritingMode = WritingVertical
+	if got := lyrics.scrollAxis().viewport; got != 240 {
+		t.Fatalf("vertical scroll viewport = %v, want 240", got)
+	}
+	lyrics.SetViewport(240, 320, -0.2)
+	if lyrics.FocusPosition != 0 {
+		t.Fatalf("FocusPosition = %v, want 0", lyrics.FocusPosition)
+	}
+}
@@ -48,8 +48,11 @@ func (l *Lyrics) StaticLayerSignature() (uint64, bool, bool) {
 		mixStaticLayerSignature(&signature, staticLayerFloatBits(line.GetPosition().GetScaleX()))
 		mixStaticLayerSignature(&signature, staticLayerFloatBits(line.GetPosition().GetScaleY()))
 		mixStaticLayerSignature(&signature, staticLayerFloatBits(line.GetPosition().GetAlpha()))
+		mixStaticLayerSignature(&signature, staticLayerFloatBits(line.depthBlur))
 	}
 
+	// 静态层按手动滚动偏移绘制
+	mixStaticLayerSignature(&signature, staticLayerFloatBits(l.scrollOffset))
 	for _, i := range l.renderIndex {
 		if i < 0 || i >= len(l.Lines) {
 			continue
@@ -0,0 +1,66 @@
+package lyrics
+
+// 文件说明：页面手动滚动与歌词裁剪的衔接。
+// 主要职责：提供手动滚动的可达范围，让裁剪窗口跟随手动滚动偏移，滚到的行能被渲染出来；
+// 绘制与命中检测时叠加该偏移，并剔除可见区域之外的行。
+
+import (
+	"math"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+)
+
+// ScrollBounds 返回手动滚动偏移的范围（流动方向，逻辑像素），偏移为正时露出前面的行。
+// 上限让第一行停在焦点位置，下限让最后一行停在焦点位置；范围总是包含 0。
+func (l *Lyrics) ScrollBounds() (lo, hi float64) {
+	if l == nil || len(l.Lines) == 0 {
+		return 0, 0
+	}
+	axis := l.scrollAxis()
+	focus := axis.viewport * l.FocusPosition
+	hi = focus - axis.flow(l.Lines[0].GetPosition())
+	lo = focus - axis.flow(l.Lines[len(l.Lines)-1].GetPosition())
+	return math.Min(lo, 0), math.Max(hi, 0)
+}
+
+// SetScrollOffset 设置绘制时叠加的手动滚动偏移（与 ScrollBounds 同一坐标）。
+// 偏移累计超过一段距离才重新裁剪，回到 0 时总会重新裁剪。
+func (l *Lyrics) SetScrollOffset(offset float64) {
+	if l == nil || offset == l.scrollOffset {
+		return
+	}
+	// 手动浏览时取消景深模糊，回到 0 时恢复
+	browsingChanged := (offset != 0) != (l.scrollOffset != 0)
+	l.scrollOffset = offset
+	if browsingChanged {
+		l.updateDepthBlur()
+	}
+	step := math.Max(40, l.scrollAxis().viewport*0.15)
+	if math.Abs(offset-l.cullOffset) < step && (offset != 0 || l.cullOffset == 0) {
+		return
+	}
+	l.cullOffset = offset
+	if l.anchorIndex < 0 || l.anchorIndex >= len(l.Lines) {
+		return
+	}
+	lineAnimationLayer.scrollLyricsTo(l, l.nowLyrics, l.anchorIndex, 1)
+}
+
+// scrollShift 返回手动滚动偏移在绘制坐标中的平移：横排沿 Y 向下，竖排后续行在左侧，偏移为正时向左。
+func (l *Lyrics) scrollShift() (dx, dy float64) {
+	if l.WritingMode == WritingVertical {
+		return -l.scrollOffset, 0
+	}
+	return 0, l.scrollOffset
+}
+
+// inViewport 判断行平移 (dx, dy)（逻辑像素）后是否与可见区域相交，未设置可见区域时不剔除。
+func (l *Lyrics) inViewport(line *Line, dx, dy float64) bool {
+	if l.ViewportWidth <= 0 || l.ViewportHeight <= 0 {
+		return true
+	}
+	// GetAABB 为物理像素
+	minX, minY, maxX, maxY := GetAABB(line.GetPosition())
+	dx, dy = lp.LP(dx), lp.LP(dy)
+	return maxX+dx > 0 && minX+dx < lp.LP(l.ViewportWidth) && maxY+dy > 0 && minY+dy < lp.LP(l.ViewportHeight)
+}
@@ -0,0 +1,35 @@
+package lyrics
+
+import "testing"
+
+func TestScrollBoundsStopFirstAndLastLineAtFocus(t *testing.T) {
+	lyrics := &Lyrics{
+		ViewportHeight: 400,
+		FocusPosition:  0.25,
+		Lines: []*Line{
+			{Position: NewPosition(0, -100, 400, 60)},
+			{Position: NewPosition(0, 100, 400, 60)},
+			{Position: NewPosition(0, 500, 400, 60)},
+		},
+	}
+	if lo, hi := lyrics.ScrollBounds(); lo != -400 || hi != 200 {
+		t.Fatalf("bounds = [%v, %v], want [-400, 200]", lo, hi)
+	}
+
+	// 只有当前行时范围退化为 0
+	lyrics.Lines = lyrics.Lines[1:2]
+	if lo, hi := lyrics.ScrollBounds(); lo != 0 || hi != 0 {
+		t.Fatalf("single line bounds = [%v, %v], want [0, 0]", lo, hi)
+	}
+
+	lyrics.WritingMode = WritingVertical
+	lyrics.ViewportWidth = 800
+	lyrics.Lines = []*Line{
+		{Position: NewPosition(700, 0, 50, 400)},
+		{Position: NewPosition(400, 0, 50, 400)},
+	}
+	// 竖排焦点在 200：第一行流动坐标 50，最后一行 350
+	if lo, hi := lyrics.ScrollBounds(); lo != -150 || hi != 150 {
+		t.Fatalf("vertical bounds = [%v, %v], want [-150, 150]", lo, hi)
+	}
+}
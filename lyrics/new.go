@@ -2,7 +2,6 @@ package lyrics
 
 import (
 	"errors"
-	"image/color"
 	"strings"
 	"time"
 	"unicode"
@@ -184,8 +183,11 @@ func splitTokenByRuneLimit(token string, limit int) []string {
 	return out
 }
 
-func createLineModeSyllables(ts []ttml.LyricWord, line *Line, fd float64, alpha uint8) ([]*LineSyllable, error) {
+func createLineModeSyllables(ts []ttml.LyricWord, line *Line, fd float64) ([]*LineSyllable, error) {
 	var syllables []*LineSyllable
+	colors := line.theme.colorsFor(line)
+	// 竖排逐字堆叠，整行模式下也要拆成单字
+	vertical := line.WritingMode == WritingVertical
 
 	for _, word := range ts {
 		parts := tokenizeLineWordForLayout(word.Word)
@@ -209,13 +211,14 @@ func createLineModeSyllables(ts []ttml.LyricWord, line *Line, fd float64, alpha
 				line.FontRequest,
 				line.fontsize,
 				fd,
-				color.RGBA{255, 255, 255, alpha},
-				color.RGBA{255, 255, 255, 60},
-				false,
+				gradientColor(colors.Active),
+				gradientColor(colors.Inactive),
+				vertical,
 			)
 			if err != nil {
 				return nil, err
 			}
+			syllable.setVertical(vertical)
 			for _, element := range syllable.Elements {
 				if element == nil {
 					continue
@@ -236,13 +239,14 @@ func createLineModeSyllables(ts []ttml.LyricWord, line *Line, fd float64, alpha
 			line.FontRequest,
 			line.fontsize,
 			fd,
-			color.RGBA{255, 255, 255, alpha},
-			color.RGBA{255, 255, 255, 60},
+			gradientColor(colors.Active),
+			gradientColor(colors.Inactive),
 			false,
 		)
 		if err != nil {
 			return nil, err
 		}
+		placeholder.setVertical(vertical)
 		for _, element := range placeholder.Elements {
 			if element == nil {
 				continue
@@ -260,7 +264,10 @@ func New(ttmllines []ttml.LyricLine, screenW float64, fontManager *ft.FontManage
 	var lyrics Lyrics
 	lyrics.FD = fd
 	lyrics.anchorIndex = -1
+	lyrics.Theme = DefaultTheme()
+	lyrics.FocusPosition = DefaultFocusPosition
 	lyrics.RenderMode = detectRenderMode(ttmllines)
+	lyrics.width = screenW
 	for _, line := range ttmllines {
 		lineEnd := time.Duration(maxLineEndWithBackground(line)) * time.Millisecond
 		l := NewLine(
@@ -276,13 +283,12 @@ func New(ttmllines []ttml.LyricLine, screenW float64, fontManager *ft.FontManage
 		l.RenderMode = lyrics.RenderMode
 		l.Position.SetW(screenW * 0.9)
 		l.SetPadding(20)
-		if line.IsDuet {
-			l.Position.SetX(screenW - l.Position.GetW())
-		}
 		if err := CreateSyllable(line.Words, l, fd); err != nil {
 			return nil, err
 		}
 		l.Layout()
+		// 行方向在 CreateSyllable 中才确定，放置要在其后
+		l.placeX(screenW)
 
 		for _, bgline := range line.BGs {
 			lbg := NewLine(
@@ -298,13 +304,11 @@ func New(ttmllines []ttml.LyricLine, screenW float64, fontManager *ft.FontManage
 			lbg.RenderMode = lyrics.RenderMode
 			lbg.Position.SetW(screenW * 0.9)
 			lbg.SetPadding(20)
-			if bgline.IsDuet {
-				lbg.Position.SetX(screenW - lbg.Position.GetW())
-			}
 			if err := CreateSyllable(bgline.Words, lbg, fd); err != nil {
 				return nil, err
 			}
 			lbg.Layout()
+			lbg.placeX(screenW)
 			lbg.Position.SetAlpha(0)
 			l.AddBackgroundLine(lbg)
 		}
@@ -333,14 +337,13 @@ func CreateSyllable(ts []ttml.LyricWord, line *Line, fd float64) error {
 		return errors.New("line face is nil")
 	}
 
+	line.words = ts
 	var syllables []*LineSyllable
-	ap := uint8(255)
-	if line.IsBackground {
-		ap = 130
-	}
+	colors := line.theme.colorsFor(line)
+	vertical := line.WritingMode == WritingVertical
 
 	if line.RenderMode == RenderModeLine {
-		lineModeSyllables, err := createLineModeSyllables(ts, line, fd, ap)
+		lineModeSyllables, err := createLineModeSyllables(ts, line, fd)
 		if err != nil {
 			return err
 		}
@@ -354,10 +357,12 @@ func CreateSyllable(ts []ttml.LyricWord, line *Line, fd float64) error {
 		for _, idx := range group {
 			duration += time.Duration(ts[idx].EndTime-ts[idx].StartTime) * time.Millisecond
 		}
-		needSplitCharsByDuration := duration >= 800*time.Millisecond
+		needSplitCharsByDuration := duration >= 800*time.Millisecond || vertical
 
 		for _, idx := range group {
 			w := ts[idx]
+			// 横排拆分后由整词整形保持连写；竖排不整形，右到左文字保持整个音节
+			needSplit := needSplitCharsByDuration && !(vertical && hasRTLRune(w.Word))
 			syllable, err := NewSyllable(
 				w.Word,
 				time.Duration(w.StartTime)*time.Millisecond,
@@ -366,13 +371,14 @@ func CreateSyllable(ts []ttml.LyricWord, line *Line, fd float64) error {
 				line.FontRequest,
 				line.fontsize,
 				fd,
-				color.RGBA{255, 255, 255, ap},
-				color.RGBA{255, 255, 255, 60},
-				needSplitCharsByDuration,
+				gradientColor(colors.Active),
+				gradientColor(colors.Inactive),
+				needSplit,
 			)
 			if err != nil {
 				return err
 			}
+			syllable.setVertical(vertical)
 			syllables = append(syllables, syllable)
 		}
 	}
@@ -0,0 +1,113 @@
+package lyrics
+
+// 文件说明：歌词行的指针命中检测与悬停提亮。
+// 主要职责：按行的包围盒找出指针下的歌词行，并让悬停行在绘制时淡入提亮。
+
+import (
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+)
+
+const (
+	// hoverFade 是悬停提亮淡入淡出的时长。
+	hoverFade = 150 * time.Millisecond
+	// hoverBoost 是完全悬停时行透明度向 1 靠拢的比例。
+	hoverBoost = 0.5
+)
+
+// HitTest 返回点 (x, y) 所在的可见主行下标，坐标为可见区域内的像素坐标（与 GetAABB 一致），会扣除手动滚动偏移。
+// 落在背景行上时返回其主行；可见区域之外或没有命中返回 -1。
+func (l *Lyrics) HitTest(x, y float64) int {
+	if l == nil {
+		return -1
+	}
+	if l.ViewportWidth > 0 && l.ViewportHeight > 0 && (x < 0 || y < 0 || x >= lp.LP(l.ViewportWidth) || y >= lp.LP(l.ViewportHeight)) {
+		return -1
+	}
+	dx, dy := l.scrollShift()
+	x, y = x-lp.LP(dx), y-lp.LP(dy)
+	for _, i := range l.renderIndex {
+		if i < 0 || i >= len(l.Lines) {
+			continue
+		}
+		line := l.Lines[i]
+		if line.containsPoint(x, y) {
+			return i
+		}
+		for _, bgLine := range line.BackgroundLines {
+			if bgLine.containsPoint(x, y) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (l *Line) containsPoint(x, y float64) bool {
+	if l == nil || !l.isShow || l.GetPosition().GetAlpha() <= 0 {
+		return false
+	}
+	minX, minY, maxX, maxY := GetAABB(l.GetPosition())
+	return x >= minX && x < maxX && y >= minY && y < maxY
+}
+
+// SetHover 设置悬停提亮的主行（连同其背景行），index 越界时取消悬停。
+func (l *Lyrics) SetHover(index int) {
+	if l == nil {
+		return
+	}
+	var line *Line
+	if index >= 0 && index < len(l.Lines) {
+		line = l.Lines[index]
+	}
+	if line == l.hoverLine {
+		return
+	}
+	now := time.Now()
+	l.hoverLine.setHovered(false, now)
+	line.setHovered(true, now)
+	l.hoverLine = line
+}
+
+func (l *Line) setHovered(hovered bool, now time.Time) {
+	if l == nil {
+		return
+	}
+	target := 0.0
+	if hovered {
+		target = 1
+	}
+	l.hoverFrom = l.hoverAmount(now)
+	l.hoverTo = target
+	l.hoverAt = now
+	for _, bgLine := range l.BackgroundLines {
+		bgLine.setHovered(hovered, now)
+	}
+}
+
+// hoverAmount 返回 now 时刻的悬停程度（0-1），淡变结束后收敛到目标值。
+func (l *Line) hoverAmount(now time.Time) float64 {
+	if l.hoverFrom == l.hoverTo {
+		return l.hoverTo
+	}
+	t := float64(now.Sub(l.hoverAt)) / float64(hoverFade)
+	if t >= 1 {
+		l.hoverFrom = l.hoverTo
+		return l.hoverTo
+	}
+	return l.hoverFrom + (l.hoverTo-l.hoverFrom)*max(t, 0)
+}
+
+// hoverActive 表示行处于悬停或淡变中，这期间按动态行逐帧绘制。
+func (l *Line) hoverActive() bool {
+	return l.hoverFrom > 0 || l.hoverTo > 0
+}
+
+// hoverAlpha 在行自身透明度的基础上叠加悬停提亮。
+func (l *Line) hoverAlpha(alpha float64, now time.Time) float64 {
+	if !l.hoverActive() {
+		return alpha
+	}
+	return alpha + (1-alpha)*hoverBoost*l.hoverAmount(now)
+}
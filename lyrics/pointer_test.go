@@ -0,0 +1,87 @@
+package lyrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+)
+
+func pointerTestLyrics() *Lyrics {
+	mainLine := &Line{isShow: true, Status: LineStatusPreviewStatic, Position: NewPosition(0, 100, 400, 60)}
+	bg := &Line{isShow: true, Position: NewPosition(0, 160, 400, 30)}
+	mainLine.BackgroundLines = []*Line{bg}
+	hidden := &Line{Position: NewPosition(0, 200, 400, 60)}
+	return &Lyrics{Lines: []*Line{mainLine, hidden}, renderIndex: []int{0, 1}}
+}
+
+func TestHitTestMapsBackgroundLinesToMainLine(t *testing.T) {
+	lyrics := pointerTestLyrics()
+	at := func(x, y float64) int { return lyrics.HitTest(lp.LP(x), lp.LP(y)) }
+
+	if got := at(10, 120); got != 0 {
+		t.Fatalf("mainLine line hit = %d, want 0", got)
+	}
+	if got := at(10, 170); got != 0 {
+		t.Fatalf("background line hit = %d, want its mainLine line 0", got)
+	}
+	// 未显示的行不参与命中
+	if got := at(10, 230); got != -1 {
+		t.Fatalf("hidden line hit = %d, want -1", got)
+	}
+	lyrics.Lines[0].Position.SetAlpha(0)
+	lyrics.Lines[0].BackgroundLines[0].Position.SetAlpha(0)
+	if got := at(10, 120); got != -1 {
+		t.Fatalf("transparent line hit = %d, want -1", got)
+	}
+}
+
+func TestHitTestFollowsScrollOffsetAndViewport(t *testing.T) {
+	lyrics := pointerTestLyrics()
+	lyrics.SetViewport(400, 300, DefaultFocusPosition)
+	at := func(x, y float64) int { return lyrics.HitTest(lp.LP(x), lp.LP(y)) }
+
+	// 向下滚动 100 后主行画在 200-260
+	lyrics.scrollOffset = 100
+	if got := at(10, 120); got != -1 {
+		t.Fatalf("hit at the unscrolled position = %d, want -1", got)
+	}
+	if got := at(10, 220); got != 0 {
+		t.Fatalf("hit at the scrolled position = %d, want 0", got)
+	}
+	// 可见区域之外（背景行滚到 260-290 之下的 310）不命中
+	lyrics.scrollOffset = 150
+	if got := at(10, 310); got != -1 {
+		t.Fatalf("hit outside the viewport = %d, want -1", got)
+	}
+	if lyrics.inViewport(lyrics.Lines[0], 0, 250) || !lyrics.inViewport(lyrics.Lines[0], 0, 150) {
+		t.Fatal("lines should be culled to the viewport")
+	}
+}
+
+func TestHoverFadesInAndOut(t *testing.T) {
+	lyrics := pointerTestLyrics()
+	mainLine := lyrics.Lines[0]
+	bg := mainLine.BackgroundLines[0]
+
+	lyrics.SetHover(0)
+	if !mainLine.hoverActive() || !bg.hoverActive() || mainLine.canUseStaticLayer() {
+		t.Fatal("hovered line and its background line should render dynamically")
+	}
+	start := mainLine.hoverAt
+	full := 0.4 + (1-0.4)*hoverBoost
+	if got := mainLine.hoverAlpha(0.4, start.Add(hoverFade/2)); got <= 0.4 || got >= full {
+		t.Fatalf("half-faded alpha = %v", got)
+	}
+	if got := mainLine.hoverAlpha(0.4, start.Add(hoverFade)); got != full {
+		t.Fatalf("hovered alpha = %v, want %v", got, full)
+	}
+
+	lyrics.SetHover(-1)
+	if got := mainLine.hoverAmount(mainLine.hoverAt.Add(time.Hour)); got != 0 || mainLine.hoverActive() {
+		t.Fatalf("hover should fade out, amount = %v", got)
+	}
+	if lyrics.hoverLine != nil {
+		t.Fatal("hover line should be cleared")
+	}
+}
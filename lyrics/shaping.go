@@ -0,0 +1,180 @@
+package lyrics
+
+// 文件说明：复杂文字的整词整形。
+// 主要职责：阿拉伯文连写、天城文合字、泰文附加符号等在逐音节切开后会断形，
+// 这里把整词一次整形绘制成共享遮罩，再按字形簇把它切成各元素的裁剪区域，逐音节高亮仍然可用。
+
+import (
+	"image"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// shapingScripts 是切开后字形会变化、需要整词整形的文字。
+var shapingScripts = []*unicode.RangeTable{
+	unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko,
+	unicode.Devanagari, unicode.Bengali, unicode.Gurmukhi, unicode.Gujarati,
+	unicode.Oriya, unicode.Tamil, unicode.Telugu, unicode.Kannada,
+	unicode.Malayalam, unicode.Sinhala, unicode.Thai, unicode.Lao,
+	unicode.Tibetan, unicode.Myanmar, unicode.Khmer, unicode.Mongolian,
+}
+
+// needsShaping 判断文本是否含有需要整词整形的文字或组合附加符号。
+func needsShaping(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, shapingScripts...) || unicode.In(r, unicode.Mn, unicode.Mc) {
+			return true
+		}
+	}
+	return false
+}
+
+// shapeClip 描述元素在整词遮罩中的位置：text 为整词文本，width 为整词宽度，x 为元素左边缘（逻辑像素）。
+type shapeClip struct {
+	text  string
+	width float64
+	x     float64
+	rtl   bool
+}
+
+// clip 从整词遮罩中取出元素对应的一段，width 为元素宽度（逻辑像素）。
+// 两端都按四舍五入取整，相邻元素的裁剪区域首尾相接、不重叠。
+func (c *shapeClip) clip(mask *ebiten.Image, width float64) *ebiten.Image {
+	if mask == nil {
+		return nil
+	}
+	b := mask.Bounds()
+	x0 := min(max(b.Min.X+int(math.Round(lp.LP(c.x))), b.Min.X), b.Max.X)
+	x1 := min(max(b.Min.X+int(math.Round(lp.LP(c.x+width))), x0), b.Max.X)
+	return mask.SubImage(image.Rect(x0, b.Min.Y, x1, b.Max.Y)).(*ebiten.Image)
+}
+
+// clusterRanges 把整形后的字形（视觉顺序，OriginX 递增）按字节起点归属到各元素，返回每个元素的 [左, 右) 范围。
+// starts 为各元素在整词中的起始字节，advance 为整词总宽度；字形簇被前一元素合并的元素宽度为 0。
+func clusterRanges(glyphs []text.Glyph, starts []int, advance float64) [][2]float64 {
+	ranges := make([][2]float64, len(starts))
+	found := make([]bool, len(starts))
+	if len(glyphs) == 0 || len(starts) == 0 {
+		return ranges
+	}
+	end := glyphs[0].OriginX + advance
+	for i, g := range glyphs {
+		left := g.OriginX
+		right := end
+		if i+1 < len(glyphs) {
+			right = glyphs[i+1].OriginX
+		}
+		owner := sort.Search(len(starts), func(k int) bool { return starts[k] > g.StartIndexInBytes }) - 1
+		if owner < 0 {
+			owner = 0
+		}
+		if !found[owner] {
+			ranges[owner] = [2]float64{left, right}
+			found[owner] = true
+			continue
+		}
+		ranges[owner][0] = math.Min(ranges[owner][0], left)
+		ranges[owner][1] = math.Max(ranges[owner][1], right)
+	}
+	return ranges
+}
+
+// shapeWords 对含复杂文字的多元素词做整词整形，其余词恢复逐元素独立绘制。
+// 需在音节方向确定之后、排版之前调用；竖排逐字堆叠，不做整形。
+func (l *Line) shapeWords() {
+	for _, group := range SplitBySpace(l, true) {
+		var (
+			elements []*SyllableElement
+			starts   []int
+			word     strings.Builder
+			rtl      bool
+			mixed    bool
+		)
+		for _, idx := range group {
+			if idx < 0 || idx >= len(l.Syllables) || l.Syllables[idx] == nil {
+				continue
+			}
+			syllable := l.Syllables[idx]
+			if len(elements) > 0 && syllable.RTL != rtl {
+				mixed = true
+			}
+			rtl = syllable.RTL
+			for _, element := range syllable.Elements {
+				if element == nil || element.SyllableImage == nil {
+					continue
+				}
+				elements = append(elements, element)
+				starts = append(starts, word.Len())
+				word.WriteString(element.SyllableImage.Text)
+			}
+		}
+		// 词内混有两个方向时各段由双向重排分别摆放，无法共用一条字形序列
+		if mixed || !l.shapeWord(elements, starts, word.String(), rtl) {
+			for _, element := range elements {
+				element.setShape(nil, 0, 0)
+			}
+		}
+	}
+}
+
+func (l *Line) shapeWord(elements []*SyllableElement, starts []int, word string, rtl bool) bool {
+	if l.WritingMode == WritingVertical || len(elements) < 2 || !needsShaping(word) {
+		return false
+	}
+	first := elements[0].SyllableImage
+	if first.FontManager == nil || first.FontSize <= 0 {
+		return false
+	}
+	face, err := first.FontManager.GetFaceForTextDirection(first.FontRequest, first.FontSize, word, textDirection(rtl))
+	if err != nil || face == nil {
+		return false
+	}
+
+	// 与 createTextMask 相同的对齐方式，字形坐标与遮罩一致
+	opts := &text.LayoutOptions{}
+	if rtl {
+		opts.PrimaryAlign = text.AlignEnd
+	}
+	glyphs := text.AppendGlyphs(nil, word, face, opts)
+	advance, height := text.Measure(word, face, 1.0)
+	if len(glyphs) == 0 || advance <= 0 {
+		return false
+	}
+
+	ranges := clusterRanges(glyphs, starts, advance)
+	width := lp.FromLP(advance)
+	for i, element := range elements {
+		clip := &shapeClip{text: word, width: width, x: lp.FromLP(ranges[i][0]), rtl: rtl}
+		element.setShape(clip, lp.FromLP(ranges[i][1]-ranges[i][0]), lp.FromLP(height))
+	}
+	return true
+}
+
+// setShape 切换元素的遮罩来源并同步元素尺寸；clip 为 nil 时恢复按自身文本测量。
+func (e *SyllableElement) setShape(clip *shapeClip, width, height float64) {
+	img := e.SyllableImage
+	if clip == nil && img.shape == nil {
+		return
+	}
+	img.shape = clip
+	img.releaseTextMask()
+	if clip != nil {
+		img.Width = width
+		img.Height = height
+	}
+	img.updateMetrics()
+	img.rebuildGradient()
+
+	e.NowOffset = img.GetOffset()
+	e.GetPosition().SetW(img.GetWidth())
+	e.GetPosition().SetH(img.GetHeight())
+	e.GetPosition().SetOriginX(e.GetPosition().GetW() / 2)
+	e.GetPosition().SetOriginY(e.GetPosition().GetH() * 6 / 5)
+}
@@ -0,0 +1,78 @@
+package lyrics
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+func TestNeedsShaping(t *testing.T) {
+	for _, s := range []string{"سلام", "नमस्ते", "สวัสดี", "é"} {
+		if !needsShaping(s) {
+			t.Errorf("%q should need shaping", s)
+		}
+	}
+	for _, s := range []string{"hello", "你好", "こんにちは", "שלום", " "} {
+		if needsShaping(s) {
+			t.Errorf("%q should not need shaping", s)
+		}
+	}
+}
+
+func TestClusterRanges(t *testing.T) {
+	cases := []struct {
+		name    string
+		glyphs  []text.Glyph
+		starts  []int
+		advance float64
+		want    [][2]float64
+	}{
+		{
+			name: "ltr",
+			glyphs: []text.Glyph{
+				{StartIndexInBytes: 0, OriginX: 0},
+				{StartIndexInBytes: 3, OriginX: 10},
+				{StartIndexInBytes: 6, OriginX: 18},
+			},
+			starts:  []int{0, 6},
+			advance: 30,
+			want:    [][2]float64{{0, 18}, {18, 30}},
+		},
+		{
+			// 右到左：视觉上第一个字形属于逻辑上最后一个元素
+			name: "rtl",
+			glyphs: []text.Glyph{
+				{StartIndexInBytes: 4, OriginX: 0},
+				{StartIndexInBytes: 2, OriginX: 8},
+				{StartIndexInBytes: 0, OriginX: 15},
+			},
+			starts:  []int{0, 4},
+			advance: 24,
+			want:    [][2]float64{{8, 24}, {0, 8}},
+		},
+		{
+			// 零宽附加符号与基字同簇，后一个元素没有自己的字形
+			name: "merged cluster",
+			glyphs: []text.Glyph{
+				{StartIndexInBytes: 0, OriginX: 0},
+				{StartIndexInBytes: 0, OriginX: 12},
+				{StartIndexInBytes: 6, OriginX: 12},
+			},
+			starts:  []int{0, 3, 6},
+			advance: 20,
+			want:    [][2]float64{{0, 12}, {0, 0}, {12, 20}},
+		},
+	}
+	for _, tc := range cases {
+		got := clusterRanges(tc.glyphs, tc.starts, tc.advance)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: len = %d, want %d", tc.name, len(got), len(tc.want))
+		}
+		for i := range tc.want {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: ranges = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -35,7 +35,13 @@ type SyllableImage struct {
 	FontManager            *ft.FontManager
 	FontRequest            ft.FontRequest
 	FontSize               float64
-	tempImage              *ebiten.Image
+	// Vertical 为真时渐变自上而下扫过（竖排），否则自左向右。
+	Vertical bool
+	// RTL 为真时按右到左排版字形，渐变自右向左扫过。
+	RTL bool
+	// shape 非空时遮罩取自整词整形结果中的一段，宽高由整形决定。
+	shape     *shapeClip
+	tempImage *ebiten.Image
 }
 
 func CreateSyllableImage(
@@ -80,6 +86,11 @@ func CreateSyllableImage(
 }
 
 func CreateTextMask(syllable string, font text.Face, w, h float64) *ebiten.Image {
+	return createTextMask(syllable, font, w, h, false)
+}
+
+// createTextMask 绘制文字遮罩；rtl 时 font 须按右到左排版，文字左边缘仍对齐遮罩左侧。
+func createTextMask(syllable string, font text.Face, w, h float64, rtl bool) *ebiten.Image {
 	if syllable == "" {
 		syllable = " "
 	}
@@ -90,6 +101,10 @@ func CreateTextMask(syllable string, font text.Face, w, h float64) *ebiten.Image
 	textMask.Fill(color.Transparent)
 
 	opts := &text.DrawOptions{}
+	if rtl {
+		// 右到左排版时 AlignStart 贴右侧，AlignEnd 才贴左侧
+		opts.PrimaryAlign = text.AlignEnd
+	}
 	opts.ColorScale.ScaleWithColor(color.White)
 	text.Draw(textMask, syllable, font, opts)
 
@@ -157,51 +172,90 @@ func (s *SyllableImage) updateMetrics() {
 	if s == nil {
 		return
 	}
-	font := s.resolveFace()
-	if font == nil {
-		return
+	if s.shape == nil {
+		font := s.resolveFace()
+		if font == nil {
+			return
+		}
+		tw, th := text.Measure(s.Text, font, 1.0)
+		tw = lp.FromLP(tw)
+		th = lp.FromLP(th)
+		if tw <= 0 {
+			tw = 1
+		}
+		if th <= 0 {
+			th = 1
+		}
+		s.Width = tw
+		s.Height = th
 	}
-	tw, th := text.Measure(s.Text, font, 1.0)
-	tw = lp.FromLP(tw)
-	th = lp.FromLP(th)
-	if tw <= 0 {
-		tw = 1
+	along, cross := s.fadeExtent()
+	_, _, _, offset := generateBackgroundFadeStyle(along, cross, s.Fd)
+	s.Offset = offset
+}
+
+// fadeExtent 返回渐变扫过方向上的长度和与之垂直的宽度。
+func (s *SyllableImage) fadeExtent() (float64, float64) {
+	if s.Vertical {
+		return s.Height, s.Width
 	}
-	if th <= 0 {
-		th = 1
+	return s.Width, s.Height
+}
+
+// SetVertical 切换渐变方向，偏移量按新的扫过长度重新计算。
+func (s *SyllableImage) SetVertical(vertical bool) {
+	if s == nil || s.Vertical == vertical {
+		return
 	}
-	s.Width = tw
-	s.Height = th
-	_, _, _, offset := generateBackgroundFadeStyle(tw, th, s.Fd)
-	s.Offset = offset
+	s.Vertical = vertical
+	s.rebuildGradient()
+}
+
+// SetRTL 切换字形排版与扫光方向，文字遮罩会按新方向重新生成。
+func (s *SyllableImage) SetRTL(rtl bool) {
+	if s == nil || s.RTL == rtl {
+		return
+	}
+	s.RTL = rtl
+	s.releaseTextMask()
 }
 
 func (s *SyllableImage) ensureResources() bool {
 	if s == nil {
 		return false
 	}
-	if s.Width <= 0 || s.Height <= 0 {
+	if s.shape == nil && (s.Width <= 0 || s.Height <= 0) {
 		s.updateMetrics()
 	}
 
 	targetW := safeImageLength(s.Width)
 	targetH := safeImageLength(s.Height)
+	along, cross := s.fadeExtent()
+	gradientW := safeImageLength(along)
+	gradientH := safeImageLength(cross)
 
 	if s.TextMask == nil {
-		img, key := acquireTextMask(s.Text, s.FontManager, s.FontRequest, s.FontSize, s.Width, s.Height)
-		s.TextMask = img
-		s.textMaskKey = key
-		s.hasTextKey = img != nil
+		if s.shape != nil {
+			img, key := acquireTextMask(s.shape.text, s.FontManager, s.FontRequest, s.FontSize, s.shape.width, s.Height, s.shape.rtl)
+			s.TextMask = s.shape.clip(img, s.Width)
+			s.textMaskKey = key
+			s.hasTextKey = img != nil
+		} else {
+			img, key := acquireTextMask(s.Text, s.FontManager, s.FontRequest, s.FontSize, s.Width, s.Height, s.RTL)
+			s.TextMask = img
+			s.textMaskKey = key
+			s.hasTextKey = img != nil
+		}
 	}
 	if s.GradientImage == nil {
-		img, key := acquireGradient(targetW, targetH, s.Fd, s.StartColor, s.EndColor)
+		img, key := acquireGradient(gradientW, gradientH, s.Fd, s.StartColor, s.EndColor)
 		s.GradientImage = img
 		s.gradientKey = key
 		s.hasGradKey = img != nil
 	}
 	if s.HighlightGradientImage == nil {
 		startColor, endColor := s.highlightGradientColors()
-		img, key := acquireGradient(targetW, targetH, s.Fd, startColor, endColor)
+		img, key := acquireGradient(gradientW, gradientH, s.Fd, startColor, endColor)
 		s.HighlightGradientImage = img
 		s.highlightGradientKey = key
 		s.hasHighlightGradKey = img != nil
@@ -286,6 +340,10 @@ func (s *SyllableImage) drawMasked(img, gradient *ebiten.Image, offset float64,
 	if s == nil || img == nil || pos == nil {
 		return
 	}
+	if s.shape != nil && s.Width <= 0 {
+		// 字形已并入前一元素，由前一元素绘制
+		return
+	}
 	if !s.ensureResources() {
 		return
 	}
@@ -296,10 +354,21 @@ func (s *SyllableImage) drawMasked(img, gradient *ebiten.Image, offset float64,
 	s.tempImage.Clear()
 	s.tempImage.DrawImage(s.TextMask, &ebiten.DrawImageOptions{})
 
+	_, cross := s.fadeExtent()
 	op := &ebiten.DrawImageOptions{}
 	op.Blend = ebiten.BlendSourceIn
 	op.GeoM.Translate(lp.LP(offset), 0)
-	op.GeoM.Scale(1, math.Max(1, lp.LP(s.Height)))
+	op.GeoM.Scale(1, math.Max(1, lp.LP(cross)))
+	if s.RTL {
+		// 以遮罩宽度为轴水平翻转，扫光从右侧开始。
+		op.GeoM.Scale(-1, 1)
+		op.GeoM.Translate(float64(s.TextMask.Bounds().Dx()), 0)
+	}
+	if s.Vertical {
+		// 横向渐变旋转 90° 后沿 y 轴扫过，再平移回元素区域内。
+		op.GeoM.Rotate(math.Pi / 2)
+		op.GeoM.Translate(math.Max(1, lp.LP(cross)), 0)
+	}
 	op.ColorScale.ScaleAlpha(float32(alpha))
 	s.tempImage.DrawImage(gradient, op)
 
@@ -369,6 +438,7 @@ func (s *SyllableImage) SetText(t string) {
 		return
 	}
 	s.Text = t
+	s.shape = nil
 	s.resetResources()
 	s.updateMetrics()
 }
@@ -383,6 +453,7 @@ func (s *SyllableImage) SetFont(fontManager *ft.FontManager, req ft.FontRequest,
 	s.FontManager = fontManager
 	s.FontRequest = req.Normalized()
 	s.FontSize = fontSize
+	s.shape = nil
 	s.resetResources()
 	s.updateMetrics()
 }
@@ -394,7 +465,8 @@ func (s *SyllableImage) rebuildGradient() {
 	if s.Width <= 0 || s.Height <= 0 {
 		s.updateMetrics()
 	}
-	_, _, _, offset := generateBackgroundFadeStyle(s.Width, s.Height, s.Fd)
+	along, cross := s.fadeExtent()
+	_, _, _, offset := generateBackgroundFadeStyle(along, cross, s.Fd)
 	s.Offset = offset
 
 	s.releaseGradient()
@@ -417,6 +489,16 @@ func (s *SyllableImage) SetEndColor(c color.RGBA) {
 	s.rebuildGradient()
 }
 
+// SetColors 同时替换起止颜色，只重建一次渐变。
+func (s *SyllableImage) SetColors(start, end color.RGBA) {
+	if s.StartColor == start && s.EndColor == end {
+		return
+	}
+	s.StartColor = start
+	s.EndColor = end
+	s.rebuildGradient()
+}
+
 func (s *SyllableImage) SetFd(fd float64) {
 	if s.Fd == fd {
 		return
@@ -486,7 +568,7 @@ func (s *SyllableImage) resolveFace() text.Face {
 	if s == nil || s.FontManager == nil || s.FontSize <= 0 {
 		return nil
 	}
-	face, err := s.FontManager.GetFaceForText(s.FontRequest, s.FontSize, s.Text)
+	face, err := s.FontManager.GetFaceForTextDirection(s.FontRequest, s.FontSize, s.Text, textDirection(s.RTL))
 	if err != nil {
 		return nil
 	}
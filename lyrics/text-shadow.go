@@ -15,7 +15,7 @@ import (
 )
 
 type TextShadow struct {
-	Color           color.RGBA
+	Color           color.NRGBA
 	LastBlur        float64
 	Blur            float64
 	Margin          float64
@@ -46,7 +46,7 @@ func NewTextShadow(texts string, fontManager *ft.FontManager, req ft.FontRequest
 		Text:        texts,
 		FontManager: fontManager,
 		FontRequest: req.Normalized(),
-		Color:       color.RGBA{255, 255, 255, 255},
+		Color:       DefaultTheme().Shadow,
 		Blur:        0.0,
 		Margin:      50.0,
 		TWidth:      tw,
@@ -74,12 +74,21 @@ func (ts *TextShadow) ensureOriginImage() bool {
 	ts.OriginImage = ebiten.NewImage(safeImageLength(ts.Width), safeImageLength(ts.Height))
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(lp.LP(ts.Margin), lp.LP(ts.Margin))
-	op.ColorScale.ScaleWithColor(color.White)
+	op.ColorScale.ScaleWithColor(ts.Color)
 	text.Draw(ts.OriginImage, ts.Text, face, op)
 
 	return true
 }
 
+// SetColor 修改阴影颜色，缓存的位图会在下次绘制时按新颜色重建。
+func (ts *TextShadow) SetColor(c color.NRGBA) {
+	if ts == nil || ts.Color == c {
+		return
+	}
+	ts.Color = c
+	ts.Dispose()
+}
+
 func (ts *TextShadow) updateImage() {
 	if !ts.ensureOriginImage() {
 		return
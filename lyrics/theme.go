@@ -0,0 +1,349 @@
+package lyrics
+
+// 文件说明：歌词配色主题。
+// 主要职责：定义主唱、对唱、背景人声、翻译与阴影的颜色，支持 JSON 加载、插值过渡和运行时切换。
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xiaowumin-mark/EbitenLyrics/anim"
+	"github.com/xiaowumin-mark/EbitenLyrics/palette"
+)
+
+// DefaultThemePath 是默认的主题文件位置，文件不存在时使用 DefaultTheme。
+const DefaultThemePath = "config/theme.json"
+
+// 主题过渡分段数，每跨过一段才重建一次渐变图，避免每帧重新生成纹理。
+const themeTransitionSteps = 12
+
+// ThemeColors 是一类歌词行的高亮色与底色。
+type ThemeColors struct {
+	Active   color.NRGBA // 已唱（高亮）部分
+	Inactive color.NRGBA // 未唱部分
+}
+
+// Theme 描述歌词渲染用到的全部颜色，均为非预乘颜色。
+type Theme struct {
+	Main        ThemeColors // 主唱行
+	Duet        ThemeColors // 对唱行（IsDuet）
+	Background  ThemeColors // 背景人声行
+	Translation color.NRGBA
+	Shadow      color.NRGBA // 长音高亮时的发光阴影
+}
+
+// DefaultTheme 返回与旧版硬编码白色一致的主题。
+func DefaultTheme() Theme {
+	main := ThemeColors{
+		Active:   color.NRGBA{255, 255, 255, 255},
+		Inactive: color.NRGBA{255, 255, 255, 60},
+	}
+	return Theme{
+		Main: main,
+		Duet: main,
+		Background: ThemeColors{
+			Active:   color.NRGBA{255, 255, 255, 130},
+			Inactive: color.NRGBA{255, 255, 255, 60},
+		},
+		Translation: color.NRGBA{255, 255, 255, 102},
+		Shadow:      color.NRGBA{255, 255, 255, 255},
+	}
+}
+
+// colorsFor 按行类型挑选配色，背景人声优先于对唱。
+func (t Theme) colorsFor(l *Line) ThemeColors {
+	switch {
+	case l != nil && l.IsBackground:
+		return t.Background
+	case l != nil && l.IsDuet:
+		return t.Duet
+	default:
+		return t.Main
+	}
+}
+
+// LerpTheme 在两个主题间线性插值，t 会被限制在 [0,1]。
+func LerpTheme(a, b Theme, t float64) Theme {
+	lerpColors := func(x, y ThemeColors) ThemeColors {
+		return ThemeColors{
+			Active:   palette.Mix(x.Active, y.Active, t),
+			Inactive: palette.Mix(x.Inactive, y.Inactive, t),
+		}
+	}
+	return Theme{
+		Main:        lerpColors(a.Main, b.Main),
+		Duet:        lerpColors(a.Duet, b.Duet),
+		Background:  lerpColors(a.Background, b.Background),
+		Translation: palette.Mix(a.Translation, b.Translation, t),
+		Shadow:      palette.Mix(a.Shadow, b.Shadow, t),
+	}
+}
+
+// 自动主题的最低对比度：高亮色按 WCAG AA 正文标准，底色与翻译只需可辨认。
+const (
+	autoThemeActiveContrast   = 4.5
+	autoThemeInactiveContrast = 3
+)
+
+// ThemeFromPalette 以封面主色作为背景估计调用 ThemeOnBackdrop。
+func ThemeFromPalette(p palette.Palette) Theme {
+	return ThemeOnBackdrop(p, p.Dominant.Color)
+}
+
+// ThemeOnBackdrop 根据封面调色板生成在 bg 上可读的主题，bg 应为歌词实际所在背景（如处理后的封面）的颜色。
+// 鲜艳色作高亮、柔和色作底色与翻译，对唱取与高亮色区别最大的另一种鲜艳色。
+// 调色板为空时返回 DefaultTheme。
+func ThemeOnBackdrop(p palette.Palette, bg color.NRGBA) Theme {
+	theme := DefaultTheme()
+	if p.Empty() {
+		return theme
+	}
+	readable := func(c color.NRGBA, alpha uint8, ratio float64) color.NRGBA {
+		c.A = alpha
+		return palette.Readable(c, bg, ratio)
+	}
+
+	active := readable(p.Vibrant.Color, theme.Main.Active.A, autoThemeActiveContrast)
+	inactive := readable(p.Muted.Color, theme.Main.Inactive.A, autoThemeInactiveContrast)
+	theme.Main = ThemeColors{Active: active, Inactive: inactive}
+
+	duet := p.Vibrant
+	bestDistance := 0.0
+	for _, s := range p.Swatches {
+		if s.Saturation < 0.25 {
+			continue
+		}
+		if d := hueDistance(s.Color, p.Vibrant.Color); d > bestDistance {
+			duet, bestDistance = s, d
+		}
+	}
+	theme.Duet = ThemeColors{
+		Active:   readable(duet.Color, theme.Duet.Active.A, autoThemeActiveContrast),
+		Inactive: inactive,
+	}
+
+	theme.Background = ThemeColors{
+		Active:   readable(active, theme.Background.Active.A, autoThemeActiveContrast),
+		Inactive: readable(inactive, theme.Background.Inactive.A, autoThemeInactiveContrast),
+	}
+	theme.Translation = readable(p.Muted.Color, theme.Translation.A, autoThemeInactiveContrast)
+	theme.Shadow = readable(p.Vibrant.Color, theme.Shadow.A, autoThemeInactiveContrast)
+	return theme
+}
+
+// hueDistance 返回两种颜色在色相环上的距离，0-0.5。
+func hueDistance(a, b color.NRGBA) float64 {
+	d := math.Abs(hue(a) - hue(b))
+	return math.Min(d, 1-d)
+}
+
+func hue(c color.NRGBA) float64 {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi := math.Max(r, math.Max(g, b))
+	lo := math.Min(r, math.Min(g, b))
+	d := hi - lo
+	if d == 0 {
+		return 0
+	}
+	var h float64
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h /= 6
+	if h < 0 {
+		h++
+	}
+	return h
+}
+
+// gradientColor 把主题色转换为渐变生成使用的颜色，CreateGradientImage 按非预乘处理 RGBA 字段。
+func gradientColor(c color.NRGBA) color.RGBA {
+	return color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+}
+
+// themeFile 是主题 JSON 的结构，缺省字段沿用默认主题。
+type themeFile struct {
+	Main        *themeColorsFile `json:"main"`
+	Duet        *themeColorsFile `json:"duet"`
+	Background  *themeColorsFile `json:"background"`
+	Translation string           `json:"translation"`
+	Shadow      string           `json:"shadow"`
+}
+
+type themeColorsFile struct {
+	Active   string `json:"active"`
+	Inactive string `json:"inactive"`
+}
+
+// ParseTheme 解析 JSON 主题，颜色写作 #RGB、#RGBA、#RRGGBB 或 #RRGGBBAA。
+// 未写出的字段保持默认值；只给出 main 时对唱也跟随 main。
+func ParseTheme(data []byte) (Theme, error) {
+	var f themeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Theme{}, fmt.Errorf("parse theme: %w", err)
+	}
+	theme := DefaultTheme()
+	apply := func(dst *color.NRGBA, field, value string) error {
+		if value == "" {
+			return nil
+		}
+		c, err := ParseHexColor(value)
+		if err != nil {
+			return fmt.Errorf("theme %s: %w", field, err)
+		}
+		*dst = c
+		return nil
+	}
+	applyColors := func(dst *ThemeColors, field string, src *themeColorsFile) error {
+		if src == nil {
+			return nil
+		}
+		if err := apply(&dst.Active, field+".active", src.Active); err != nil {
+			return err
+		}
+		return apply(&dst.Inactive, field+".inactive", src.Inactive)
+	}
+
+	if err := applyColors(&theme.Main, "main", f.Main); err != nil {
+		return Theme{}, err
+	}
+	if f.Duet == nil {
+		theme.Duet = theme.Main
+	} else if err := applyColors(&theme.Duet, "duet", f.Duet); err != nil {
+		return Theme{}, err
+	}
+	if err := applyColors(&theme.Background, "background", f.Background); err != nil {
+		return Theme{}, err
+	}
+	if err := apply(&theme.Translation, "translation", f.Translation); err != nil {
+		return Theme{}, err
+	}
+	if err := apply(&theme.Shadow, "shadow", f.Shadow); err != nil {
+		return Theme{}, err
+	}
+	return theme, nil
+}
+
+// LoadTheme 从 JSON 文件读取主题。
+func LoadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	return ParseTheme(data)
+}
+
+// ParseHexColor 解析 #RGB、#RGBA、#RRGGBB、#RRGGBBAA 形式的颜色，# 可省略。
+func ParseHexColor(s string) (color.NRGBA, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 || len(h) == 4 {
+		var b strings.Builder
+		for _, r := range h {
+			b.WriteRune(r)
+			b.WriteRune(r)
+		}
+		h = b.String()
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	raw, err := hex.DecodeString(h)
+	if err != nil || len(raw) != 4 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{raw[0], raw[1], raw[2], raw[3]}, nil
+}
+
+// SetTheme 切换主题，duration > 0 且有动画管理器时在两套配色间渐变。
+func (l *Lyrics) SetTheme(theme Theme, duration time.Duration) {
+	if l == nil {
+		return
+	}
+	if l.themeAnimate != nil {
+		cancelManagedAnimation(l.AnimateManager, l.themeAnimate)
+		l.themeAnimate = nil
+	}
+	if duration <= 0 || l.AnimateManager == nil || l.Theme == theme {
+		l.applyTheme(theme)
+		return
+	}
+
+	from := l.Theme
+	step := 0
+	l.themeAnimate = anim.NewTween(
+		uuid.NewString(),
+		duration,
+		0,
+		1,
+		0,
+		1,
+		anim.EaseInOut,
+		func(value float64) {
+			next := int(value * themeTransitionSteps)
+			if next == step {
+				return
+			}
+			step = next
+			l.applyTheme(LerpTheme(from, theme, float64(step)/themeTransitionSteps))
+		},
+		func() {
+			l.themeAnimate = nil
+			l.applyTheme(theme)
+		},
+	)
+	l.AnimateManager.Add(l.themeAnimate)
+}
+
+func (l *Lyrics) applyTheme(theme Theme) {
+	l.Theme = theme
+	for _, line := range l.Lines {
+		line.SetTheme(theme)
+	}
+}
+
+// SetTheme 立即把主题应用到本行及其背景行，已生成的渐变会在下次绘制时重建。
+func (l *Line) SetTheme(theme Theme) {
+	if l == nil {
+		return
+	}
+	l.theme = theme
+	colors := theme.colorsFor(l)
+	start, end := gradientColor(colors.Active), gradientColor(colors.Inactive)
+	for _, syllable := range l.Syllables {
+		if syllable == nil {
+			continue
+		}
+		for _, e := range syllable.Elements {
+			if e == nil {
+				continue
+			}
+			if e.SyllableImage != nil {
+				e.SyllableImage.SetColors(start, end)
+			}
+			if e.BackgroundBlurText != nil {
+				e.BackgroundBlurText.SetColor(theme.Shadow)
+			}
+		}
+	}
+	for _, bgLine := range l.BackgroundLines {
+		bgLine.SetTheme(theme)
+	}
+	l.markImageDirty()
+}
+
+// GetTheme 返回本行当前使用的主题。
+func (l *Line) GetTheme() Theme {
+	return l.theme
+}
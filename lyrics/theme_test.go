@@ -0,0 +1,153 @@
+package lyrics
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/palette"
+)
+
+func TestParseHexColorForms(t *testing.T) {
+	cases := map[string]color.NRGBA{
+		"#fff":      {255, 255, 255, 255},
+		"#f008":     {255, 0, 0, 136},
+		"3366cc":    {0x33, 0x66, 0xcc, 255},
+		"#3366cc80": {0x33, 0x66, 0xcc, 0x80},
+	}
+	for in, want := range cases {
+		got, err := ParseHexColor(in)
+		if err != nil || got != want {
+			t.Errorf("ParseHexColor(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "#12", "#12345", "#gggggg"} {
+		if _, err := ParseHexColor(bad); err == nil {
+			t.Errorf("ParseHexColor(%q) accepted", bad)
+		}
+	}
+}
+
+func TestParseThemeKeepsDefaultsAndFollowsMainForDuet(t *testing.T) {
+	theme, err := ParseTheme([]byte(`{"main":{"active":"#ffcc00"},"translation":"#00ff0080"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := DefaultTheme()
+	if theme.Main.Active != (color.NRGBA{255, 204, 0, 255}) || theme.Main.Inactive != def.Main.Inactive {
+		t.Fatalf("main = %+v", theme.Main)
+	}
+	if theme.Duet != theme.Main {
+		t.Fatalf("duet = %+v, want main colours when duet is omitted", theme.Duet)
+	}
+	if theme.Background != def.Background || theme.Shadow != def.Shadow {
+		t.Fatalf("unspecified fields changed: %+v", theme)
+	}
+	if theme.Translation != (color.NRGBA{0, 255, 0, 128}) {
+		t.Fatalf("translation = %v", theme.Translation)
+	}
+
+	if _, err := ParseTheme([]byte(`{"duet":{"inactive":"nope"}}`)); err == nil {
+		t.Fatal("ParseTheme accepted an invalid colour")
+	}
+}
+
+func TestLerpThemeEndpointsAndClamp(t *testing.T) {
+	a := DefaultTheme()
+	b := DefaultTheme()
+	b.Main.Active = color.NRGBA{0, 0, 0, 255}
+	b.Shadow = color.NRGBA{255, 0, 0, 0}
+
+	if LerpTheme(a, b, 0) != a || LerpTheme(a, b, 1) != b || LerpTheme(a, b, 2) != b {
+		t.Fatal("LerpTheme endpoints do not match the inputs")
+	}
+	mid := LerpTheme(a, b, 0.5)
+	if mid.Main.Active != (color.NRGBA{128, 128, 128, 255}) {
+		t.Fatalf("mid main active = %v", mid.Main.Active)
+	}
+	if mid.Shadow != (color.NRGBA{255, 128, 128, 128}) {
+		t.Fatalf("mid shadow = %v", mid.Shadow)
+	}
+}
+
+func TestLineSetThemePicksColoursByLineKind(t *testing.T) {
+	theme := DefaultTheme()
+	theme.Main.Active = color.NRGBA{1, 1, 1, 255}
+	theme.Duet.Active = color.NRGBA{2, 2, 2, 255}
+	theme.Background.Active = color.NRGBA{3, 3, 3, 255}
+
+	main := &Line{Syllables: []*LineSyllable{makeTestSyllable("a", 10)}}
+	duet := &Line{IsDuet: true, Syllables: []*LineSyllable{makeTestSyllable("b", 10)}}
+	bg := &Line{IsBackground: true, IsDuet: true, Syllables: []*LineSyllable{makeTestSyllable("c", 10)}}
+	main.AddBackgroundLine(bg)
+
+	main.SetTheme(theme)
+	duet.SetTheme(theme)
+
+	for _, tc := range []struct {
+		line *Line
+		want uint8
+	}{{main, 1}, {duet, 2}, {bg, 3}} {
+		img := tc.line.Syllables[0].Elements[0].SyllableImage
+		if img.StartColor.R != tc.want || img.EndColor != gradientColor(theme.colorsFor(tc.line).Inactive) {
+			t.Errorf("line duet=%v bg=%v start=%v end=%v", tc.line.IsDuet, tc.line.IsBackground, img.StartColor, img.EndColor)
+		}
+		if !tc.line.imageDirty {
+			t.Errorf("line duet=%v bg=%v was not marked dirty", tc.line.IsDuet, tc.line.IsBackground)
+		}
+	}
+}
+
+func TestThemeFromPaletteIsReadableOnDominant(t *testing.T) {
+	if ThemeFromPalette(palette.Palette{}) != DefaultTheme() {
+		t.Fatal("empty palette should fall back to the default theme")
+	}
+
+	swatch := func(c color.NRGBA, population, saturation float64) palette.Swatch {
+		return palette.Swatch{Color: c, Population: population, Saturation: saturation}
+	}
+	dominant := swatch(color.NRGBA{25, 20, 50, 255}, 0.6, 0.4)
+	vibrant := swatch(color.NRGBA{120, 30, 160, 255}, 0.2, 0.7)
+	other := swatch(color.NRGBA{40, 150, 60, 255}, 0.1, 0.6)
+	muted := swatch(color.NRGBA{90, 90, 100, 255}, 0.1, 0.05)
+	p := palette.Palette{
+		Dominant: dominant,
+		Vibrant:  vibrant,
+		Muted:    muted,
+		Swatches: []palette.Swatch{dominant, vibrant, other, muted},
+	}
+
+	theme := ThemeFromPalette(p)
+	opaque := func(c color.NRGBA) color.NRGBA {
+		c.A = 255
+		return c
+	}
+	if r := palette.ContrastRatio(opaque(theme.Main.Active), dominant.Color); r < autoThemeActiveContrast {
+		t.Fatalf("main active contrast = %.2f", r)
+	}
+	if r := palette.ContrastRatio(opaque(theme.Translation), dominant.Color); r < autoThemeInactiveContrast {
+		t.Fatalf("translation contrast = %.2f", r)
+	}
+	if theme.Main.Active.A != 255 || theme.Main.Inactive.A != DefaultTheme().Main.Inactive.A {
+		t.Fatalf("alpha levels changed: %+v", theme.Main)
+	}
+	if theme.Duet.Active == theme.Main.Active {
+		t.Fatal("duet should use a different hue than the main highlight")
+	}
+	// 中间调的主色（如灰色封面）上同样要达到对比度
+	mid := p
+	mid.Dominant = swatch(color.NRGBA{150, 150, 150, 255}, 0.6, 0)
+	theme = ThemeFromPalette(mid)
+	if r := palette.ContrastRatio(opaque(theme.Main.Active), mid.Dominant.Color); r < autoThemeActiveContrast {
+		t.Fatalf("main active contrast on mid-tone = %.2f", r)
+	}
+	if r := palette.ContrastRatio(opaque(theme.Translation), mid.Dominant.Color); r < autoThemeInactiveContrast {
+		t.Fatalf("translation contrast on mid-tone = %.2f", r)
+	}
+
+	// 指定背景时按背景而不是主色检查对比度
+	backdrop := color.NRGBA{40, 40, 40, 255}
+	theme = ThemeOnBackdrop(mid, backdrop)
+	if r := palette.ContrastRatio(opaque(theme.Main.Active), backdrop); r < autoThemeActiveContrast {
+		t.Fatalf("main active contrast on backdrop = %.2f", r)
+	}
+}
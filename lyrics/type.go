@@ -4,10 +4,12 @@ package lyrics
 // 主要职责：声明行、音节、元素、状态和整体歌词对象的字段布局。
 
 import (
+	"math"
 	"time"
 
 	"github.com/xiaowumin-mark/EbitenLyrics/anim"
 	ft "github.com/xiaowumin-mark/EbitenLyrics/font"
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -69,6 +71,8 @@ type LineSyllable struct {
 	Elements []*SyllableElement
 
 	Alpha float64
+	// RTL 为音节解析后的书写方向，由 Line.analyzeBidi 写入。
+	RTL bool
 }
 
 type SyllableElement struct {
@@ -104,6 +108,14 @@ type Line struct {
 
 	// RenderMode 由加载阶段统一判定后写入，布局和动画直接读取该值。
 	RenderMode LyricRenderMode
+	// Align 决定行内排版、行的水平位置和缩放原点，切换请用 SetAlign。
+	Align LyricAlign
+	// RTL 表示行的段落方向为右到左（阿拉伯文、希伯来文等），影响对齐与行内音节顺序。
+	RTL bool
+	// WritingMode 为书写方向，竖排时行宽由排版决定、行高固定，切换请用 Lyrics.SetWritingMode。
+	WritingMode WritingMode
+	// words 保留原始逐字数据，切换书写方向时据此重建音节。
+	words []ttml.LyricWord
 
 	lineHeight float64
 	Padding    float64
@@ -111,6 +123,9 @@ type Line struct {
 	IsBackground bool
 	IsDuet       bool
 
+	// theme 由 Lyrics.SetTheme 下发，创建音节与绘制翻译时读取。
+	theme Theme
+
 	Image                            *ebiten.Image
 	TranslateImage                   *ebiten.Image
 	TranslateImageW, TranslateImageH float64
@@ -127,6 +142,15 @@ type Line struct {
 	imageDirty          bool
 	StatusSettleAnimate *anim.Tween
 
+	// depthBlur 是景深模糊量，blurImage 是按 blurredFor 模糊后的行位图缓存，见 depth_blur.go。
+	depthBlur  float64
+	blurImage  *ebiten.Image
+	blurredFor float64
+
+	// 悬停提亮从 hoverFrom 到 hoverTo 的淡变，起点为 hoverAt，见 pointer.go。
+	hoverFrom, hoverTo float64
+	hoverAt            time.Time
+
 	ScrollAnimate        *anim.Tween
 	AlphaAnimate         *anim.KeyframeAnimation
 	GradientColorAnimate *anim.Tween
@@ -165,9 +189,50 @@ type Lyrics struct {
 	HighlightTime time.Duration
 	FD            float64
 
+	// Align 是整首歌词的水平对齐方式；width 为排版所用的区域宽度。
+	Align LyricAlign
+	width float64
+	// WritingMode 是整首歌词的书写方向，切换请用 SetWritingMode。
+	WritingMode WritingMode
+
+	// ViewportWidth / ViewportHeight 是歌词可见区域的尺寸（逻辑像素），区域左上角为绘制目标的原点，
+	// 用于定位、裁剪与绘制时的剔除，设置请用 SetViewport。
+	ViewportWidth  float64
+	ViewportHeight float64
+	// FocusPosition 是当前行在可见区域滚动方向上的位置：横排 0 为顶部、1 为底部，竖排 0 为右缘、1 为左缘。
+	FocusPosition float64
+
+	// 节拍触发的当前行缩放脉冲
+	kickStrength float64
+	kickAt       time.Time
+
+	// hoverLine 是指针悬停的主行，切换请用 SetHover。
+	hoverLine *Line
+	// scrollOffset 是页面叠加的手动滚动偏移，cullOffset 是上次裁剪所用的偏移，见 SetScrollOffset。
+	scrollOffset float64
+	cullOffset   float64
+
+	// DepthBlur / DepthBlurStrength 控制非当前行的景深模糊，修改请用 SetDepthBlur。
+	DepthBlur         bool
+	DepthBlurStrength float64
+
+	// Theme 为当前生效的配色，过渡期间是插值后的中间值；切换请用 SetTheme。
+	Theme        Theme
+	themeAnimate *anim.Tween
+
 	AnimateManager *anim.Manager
 }
 
+// DefaultFocusPosition 让当前行停在可见区域的 1/4 高度处。
+const DefaultFocusPosition = 0.25
+
+// SetViewport 设置可见区域的宽高与焦点位置，下一次滚动生效；focus 会被限制在 [0,1]。
+func (l *Lyrics) SetViewport(width, height, focus float64) {
+	l.ViewportWidth = width
+	l.ViewportHeight = height
+	l.FocusPosition = math.Max(0, math.Min(1, focus))
+}
+
 func (l *Lyrics) GetNowLyrics() []int {
 	return l.nowLyrics
 }
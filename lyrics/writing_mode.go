@@ -0,0 +1,146 @@
+package lyrics
+
+// 文件说明：歌词书写方向（横排 / 竖排）。
+// 主要职责：定义书写方向、滚动轴抽象，并在运行时切换方向时重建音节与行盒。
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// WritingMode 表示歌词的书写方向。
+type WritingMode int
+
+const (
+	// WritingHorizontal 横排：行自上而下滚动（默认）。
+	WritingHorizontal WritingMode = iota
+	// WritingVertical 竖排：字自上而下、列从右向左，行沿水平方向滚动。
+	WritingVertical
+)
+
+const (
+	// 竖排时行高占可见区域的比例，剩余部分上下均分留白。
+	verticalLineHeightRatio = 0.9
+	// 竖排列间距，相对字号。
+	verticalColumnGapRatio = 0.25
+)
+
+var writingModeNames = [...]string{"horizontal", "vertical"}
+
+func (m WritingMode) String() string {
+	if m < 0 || int(m) >= len(writingModeNames) {
+		return fmt.Sprintf("WritingMode(%d)", int(m))
+	}
+	return writingModeNames[m]
+}
+
+// WritingModes 返回全部书写方向，顺序与取值一致。
+func WritingModes() []WritingMode {
+	return []WritingMode{WritingHorizontal, WritingVertical}
+}
+
+// ParseWritingMode 解析 horizontal、vertical（不区分大小写）。
+func ParseWritingMode(s string) (WritingMode, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range writingModeNames {
+		if s == name {
+			return WritingMode(i), nil
+		}
+	}
+	return WritingHorizontal, fmt.Errorf("unknown writing mode %q", s)
+}
+
+// scrollAxis 把滚动统一为沿“行流动方向”的一维坐标：
+// 横排时就是 Y；竖排时行从右向左排列，坐标从可见区域右边缘向左计算。
+type scrollAxis struct {
+	vertical bool
+	viewport float64
+}
+
+func (l *Lyrics) scrollAxis() scrollAxis {
+	if l.WritingMode == WritingVertical {
+		return scrollAxis{vertical: true, viewport: l.ViewportWidth}
+	}
+	return scrollAxis{viewport: l.ViewportHeight}
+}
+
+// extent 返回行在流动方向上占用的长度。
+func (a scrollAxis) extent(p *Position) float64 {
+	if a.vertical {
+		return p.GetW()
+	}
+	return p.GetH()
+}
+
+// flow 返回行在流动方向上的起点。
+func (a scrollAxis) flow(p *Position) float64 {
+	if a.vertical {
+		return a.viewport - p.GetX() - p.GetW()
+	}
+	return p.GetY()
+}
+
+func (a scrollAxis) setFlow(p *Position, v float64) {
+	if a.vertical {
+		p.SetX(a.viewport - v - p.GetW())
+		return
+	}
+	p.SetY(v)
+}
+
+// applyWritingBox 按书写方向设置行盒：横排定宽，竖排定高，另一维由排版决定。
+func (l *Line) applyWritingBox(width, viewport float64) {
+	if l.WritingMode == WritingVertical {
+		l.Position.SetH(viewport * verticalLineHeightRatio)
+		l.Position.SetY(viewport * (1 - verticalLineHeightRatio) / 2)
+	} else {
+		l.Position.SetW(width * 0.9)
+		l.placeX(width)
+	}
+	for _, bgLine := range l.BackgroundLines {
+		bgLine.applyWritingBox(width, viewport)
+	}
+}
+
+// setVertical 切换音节内所有元素的渐变方向，扫光偏移回到起点。
+func (s *LineSyllable) setVertical(vertical bool) {
+	for _, element := range s.Elements {
+		if element == nil || element.SyllableImage == nil || element.SyllableImage.Vertical == vertical {
+			continue
+		}
+		element.SyllableImage.SetVertical(vertical)
+		element.NowOffset = element.SyllableImage.Offset
+	}
+}
+
+// rebuildForWritingMode 按新方向重建音节（竖排需要逐字拆分）并重新排版。
+func (l *Line) rebuildForWritingMode(mode WritingMode, width, viewport, fd float64) {
+	for _, bgLine := range l.BackgroundLines {
+		bgLine.rebuildForWritingMode(mode, width, viewport, fd)
+	}
+	l.WritingMode = mode
+	l.applyWritingBox(width, viewport)
+	if err := CreateSyllable(l.words, l, fd); err != nil {
+		log.Printf("rebuild line for writing mode %s: %v", mode, err)
+	}
+	lineLayoutLayer.GenerateLineTranslateImage(l)
+	lineLayoutLayer.LayoutLine(l)
+}
+
+// SetWritingMode 切换整首歌词的书写方向。所有行会被隐藏并重建，
+// 调用方随后需要 Update 与 Scroll 以恢复当前行动画和位置。
+func (l *Lyrics) SetWritingMode(mode WritingMode) {
+	if l == nil || l.WritingMode == mode {
+		return
+	}
+	lineAnimationLayer.DisposeLyricsAnimations(l)
+	l.WritingMode = mode
+	l.nowLyrics = nil
+	l.renderIndex = nil
+	viewport := l.ViewportHeight
+	for _, line := range l.Lines {
+		lineRendererLayer.DisposeLine(line)
+		line.rebuildForWritingMode(mode, l.width, viewport, l.FD)
+	}
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"log"
 	"os"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"github.com/xiaowumin-mark/EbitenLyrics/anim"
 	f "github.com/xiaowumin-mark/EbitenLyrics/font"
 	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+	"github.com/xiaowumin-mark/EbitenLyrics/mpris"
 	"github.com/xiaowumin-mark/EbitenLyrics/pages"
 	"github.com/xiaowumin-mark/EbitenLyrics/router"
 	"github.com/xiaowumin-mark/EbitenLyrics/ws"
@@ -101,6 +103,7 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 }
 
 func main() {
+	wsOpts := parseFlags()
 	initfont()
 	lp.RefreshSystemScale()
 	lp.SetUserScale(1.0)
@@ -137,7 +140,7 @@ func main() {
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 
 	ebiten.SetWindowDecorated(true)
-	go ws.Initws()
+	go ws.Initws(wsOpts)
 	if err := ebiten.RunGameWithOptions(&game, &ebiten.RunGameOptions{
 		X11ClassName:    "Ebiten Lyrics",
 		X11InstanceName: "Ebiten Lyrics",
@@ -147,6 +150,39 @@ func main() {
 	}
 }
 
+// parseFlags 解析命令行参数，涉及 WebSocket 会话的录制与回放、音频格式、封面处理以及额外的数据源。
+func parseFlags() ws.Options {
+	opts := ws.Options{}
+	flag.StringVar(&opts.Addr, "addr", ws.DefaultAddr, "WebSocket listen address")
+	flag.StringVar(&opts.RecordPath, "record", "", "record incoming WebSocket messages to `file`")
+	flag.StringVar(&opts.ReplayPath, "replay", "", "replay a recorded session from `file` instead of listening")
+	flag.Float64Var(&opts.ReplaySpeed, "replay-speed", 1, "replay speed multiplier (<= 0 sends everything at once)")
+	flag.BoolVar(&opts.ReplayLoop, "replay-loop", false, "restart the replay when it reaches the end")
+	flag.Func("audio-format", "sample format of OnAudioData PCM frames: i16, u16, f32 or i64 (empty guesses from the data)", func(value string) error {
+		format, err := ws.ParseSampleFormat(value)
+		opts.Audio.SampleFormat = format
+		return err
+	})
+	flag.IntVar(&opts.Audio.SampleRate, "audio-rate", ws.DefaultAudioSampleRate, "sample rate of OnAudioData PCM frames in Hz")
+	flag.IntVar(&opts.Audio.Channels, "audio-channels", 0, "channel count of OnAudioData PCM frames (0 guesses from the data)")
+	flag.IntVar(&opts.SpectrumBands, "spectrum-bands", ws.DefaultSpectrumBands, "number of log-spaced spectrum bands published on ws:spectrum")
+	flag.Func("cover-chain", "cover processing `steps` before ws:cover, like blur=40,brightness=-30 (none disables; default "+ws.DefaultCoverChain.String()+")", func(value string) error {
+		chain, err := ws.ParseCoverChain(value)
+		opts.CoverChain = chain
+		return err
+	})
+	flag.Int64Var(&opts.CoverLimits.MaxBytes, "cover-max-bytes", ws.DefaultCoverMaxBytes, "reject cover images larger than this many bytes")
+	flag.DurationVar(&opts.CoverLimits.DecodeTimeout, "cover-decode-timeout", ws.DefaultCoverDecodeTimeout, "give up decoding a cover image after this long")
+	flag.BoolVar(&opts.CoverFiles.Loopback, "cover-local-paths", false, "read setCover file paths sent by players on this machine")
+	flag.StringVar(&opts.CoverFiles.Dir, "cover-dir", "", "read setCover file paths inside this `directory` from any player")
+	useMPRIS := flag.Bool("mpris", false, "also follow MPRIS players on the D-Bus session bus")
+	flag.Parse()
+	if *useMPRIS {
+		opts.Sources = append(opts.Sources, &mpris.Source{})
+	}
+	return opts
+}
+
 func initfont() {
 	game.fontManager = f.DefaultManager()
 	req := f.DefaultRequest()
@@ -0,0 +1,429 @@
+package mpris
+
+// 文件说明：MPRIS（D-Bus 会话总线）数据源。
+// 主要职责：发现 org.mpris.MediaPlayer2.* 播放器，读取歌曲信息、封面、进度与播放状态，
+// 转换为与 WebSocket 播放器相同的 ws 消息，交给仲裁器与其它数据源一起处理。
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/xiaowumin-mark/EbitenLyrics/ws"
+)
+
+const (
+	// SourcePrefix 是 MPRIS 数据源 id 的前缀，后接总线名去掉 org.mpris.MediaPlayer2. 的部分。
+	SourcePrefix = "mpris:"
+	// DefaultPollInterval 是播放中轮询 Position 的默认间隔。
+	DefaultPollInterval = time.Second
+
+	busPrefix   = "org.mpris.MediaPlayer2."
+	objectPath  = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	playerIface = "org.mpris.MediaPlayer2.Player"
+	propsIface  = "org.freedesktop.DBus.Properties"
+	dbusIface   = "org.freedesktop.DBus"
+	noTrack     = "/org/mpris/MediaPlayer2/TrackList/NoTrack"
+)
+
+// Source 监听会话总线上的所有 MPRIS 播放器，每个播放器是一个独立的数据源。
+type Source struct {
+	// Conn 为 nil 时连接会话总线，并在 Run 返回时关闭。
+	Conn *dbus.Conn
+	// PollInterval <= 0 时使用 DefaultPollInterval。
+	PollInterval time.Duration
+
+	mu sync.Mutex
+	// seeks 只在 Run 运行期间非空，跳转请求经它交给 Run 的 goroutine 执行
+	seeks chan seekRequest
+}
+
+type seekRequest struct {
+	id  string
+	pos time.Duration
+}
+
+var (
+	_ ws.Source = (*Source)(nil)
+	_ ws.Seeker = (*Source)(nil)
+)
+
+// Seek 让 id 对应的播放器跳转到 pos，只在 Run 运行期间有效。
+func (s *Source) Seek(id string, pos time.Duration) error {
+	if !strings.HasPrefix(id, SourcePrefix) {
+		return ws.ErrUnknownSource
+	}
+	s.mu.Lock()
+	seeks := s.seeks
+	s.mu.Unlock()
+	if seeks == nil {
+		return errors.New("mpris: source is not running")
+	}
+	select {
+	case seeks <- seekRequest{id: id, pos: pos}:
+		return nil
+	default:
+		return errors.New("mpris: too many pending seeks")
+	}
+}
+
+// Run 阻塞直到 stop 被关闭或连接断开。
+func (s *Source) Run(msgChan ws.MessageChannel, stop <-chan struct{}) error {
+	conn := s.Conn
+	if conn == nil {
+		c, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return fmt.Errorf("mpris: connect session bus: %w", err)
+		}
+		defer c.Close()
+		conn = c
+	}
+
+	signals := make(chan *dbus.Signal, 64)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchObjectPath(objectPath), dbus.WithMatchInterface(propsIface), dbus.WithMatchMember("PropertiesChanged")},
+		{dbus.WithMatchObjectPath(objectPath), dbus.WithMatchInterface(playerIface), dbus.WithMatchMember("Seeked")},
+		{dbus.WithMatchSender(dbusIface), dbus.WithMatchMember("NameOwnerChanged"), dbus.WithMatchArg0Namespace(strings.TrimSuffix(busPrefix, "."))},
+	}
+	for _, m := range matches {
+		if err := conn.AddMatchSignal(m...); err != nil {
+			return fmt.Errorf("mpris: add match: %w", err)
+		}
+		defer conn.RemoveMatchSignal(m...)
+	}
+
+	w := &watcher{conn: conn, msgChan: msgChan, stop: stop, players: make(map[string]*player)}
+	var names []string
+	if err := conn.BusObject().Call(dbusIface+".ListNames", 0).Store(&names); err != nil {
+		return fmt.Errorf("mpris: list names: %w", err)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasPrefix(name, busPrefix) {
+			w.add(name, "")
+		}
+	}
+
+	seeks := make(chan seekRequest, 4)
+	s.mu.Lock()
+	s.seeks = seeks
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.seeks = nil
+		s.mu.Unlock()
+	}()
+
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case sig, ok := <-signals:
+			if !ok {
+				return errors.New("mpris: connection closed")
+			}
+			w.handleSignal(sig)
+		case <-ticker.C:
+			w.poll()
+		case req := <-seeks:
+			w.seek(req.id, req.pos)
+		}
+	}
+}
+
+// player 是一个已发现的 MPRIS 播放器。
+type player struct {
+	name   string // 众所周知名，如 org.mpris.MediaPlayer2.spotify
+	owner  string // 唯一名，信号的 Sender 是它
+	id     string // 消息来源 id
+	status string
+	track  trackInfo
+}
+
+// watcher 只在 Run 的 goroutine 中使用，无需加锁。
+type watcher struct {
+	conn    *dbus.Conn
+	msgChan ws.MessageChannel
+	stop    <-chan struct{}
+	// players 以唯一名为 key
+	players map[string]*player
+}
+
+func (w *watcher) send(src string, payload ws.ProtocolPayload) {
+	select {
+	case w.msgChan <- ws.Envelope{Source: src, Payload: payload}:
+	case <-w.stop:
+	}
+}
+
+// add 接入一个播放器并读取它的全部状态；owner 为空时向总线查询。
+func (w *watcher) add(name, owner string) {
+	if owner == "" {
+		if err := w.conn.BusObject().Call(dbusIface+".GetNameOwner", 0, name).Store(&owner); err != nil {
+			log.Printf("MPRIS: 查询 %s 的所有者失败: %v", name, err)
+			return
+		}
+	}
+	if old, ok := w.players[owner]; ok {
+		if old.name == name {
+			return
+		}
+		w.remove(owner)
+	}
+	p := &player{name: name, owner: owner, id: SourcePrefix + strings.TrimPrefix(name, busPrefix)}
+	w.players[owner] = p
+	log.Printf("MPRIS: 发现播放器 %s", name)
+	w.send(p.id, ws.SourceConnected{Protocol: ws.MPRIS})
+	w.refresh(p)
+}
+
+func (w *watcher) remove(owner string) {
+	p, ok := w.players[owner]
+	if !ok {
+		return
+	}
+	delete(w.players, owner)
+	log.Printf("MPRIS: 播放器退出 %s", p.name)
+	w.send(p.id, ws.SourceDisconnected{})
+}
+
+// refresh 用 GetAll 读取 Player 接口的全部属性。
+func (w *watcher) refresh(p *player) {
+	var props map[string]dbus.Variant
+	obj := w.conn.Object(p.owner, objectPath)
+	if err := obj.Call(propsIface+".GetAll", 0, playerIface).Store(&props); err != nil {
+		log.Printf("MPRIS: 读取 %s 属性失败: %v", p.name, err)
+		return
+	}
+	w.apply(p, props)
+}
+
+// apply 把属性变化转换为 ws 消息。Position 只出现在 GetAll 的结果里，
+// 规范不要求播放器为它发 PropertiesChanged。
+func (w *watcher) apply(p *player, props map[string]dbus.Variant) {
+	if v, ok := props["Metadata"]; ok {
+		if md, ok := v.Value().(map[string]dbus.Variant); ok {
+			w.applyTrack(p, parseMetadata(md))
+		}
+	}
+	if v, ok := props["PlaybackStatus"]; ok {
+		if status, ok := v.Value().(string); ok && status != p.status {
+			p.status = status
+			if status == "Playing" {
+				w.send(p.id, ws.ResumedUpdate{})
+			} else {
+				w.send(p.id, ws.PausedUpdate{})
+			}
+		}
+	}
+	if v, ok := props["Position"]; ok {
+		if pos, ok := toInt64(v.Value()); ok {
+			w.send(p.id, ws.ProgressUpdate{Progress: pos / 1000})
+		}
+	}
+}
+
+// applyTrack 在歌曲变化时发送歌曲信息，封面地址变化时发送封面。
+func (w *watcher) applyTrack(p *player, t trackInfo) {
+	prev := p.track
+	p.track = t
+	changed := t.key() != prev.key()
+	if changed {
+		w.send(p.id, ws.SetMusicUpdate{MusicInfo: t.musicInfo()})
+	}
+	if changed || t.ArtURL != prev.ArtURL {
+		if cover, ok := coverUpdate(t.ArtURL); ok {
+			w.send(p.id, cover)
+		}
+	}
+}
+
+// poll 轮询播放中播放器的 Position，供本地时钟校正。
+func (w *watcher) poll() {
+	for _, p := range w.players {
+		if p.status != "Playing" {
+			continue
+		}
+		v, err := w.conn.Object(p.owner, objectPath).GetProperty(playerIface + ".Position")
+		if err != nil {
+			continue
+		}
+		if pos, ok := toInt64(v.Value()); ok {
+			w.send(p.id, ws.ProgressUpdate{Progress: pos / 1000})
+		}
+	}
+}
+
+// seek 调用 SetPosition 跳转。规范要求带上当前曲目的 trackid，没有 trackid 时无法跳转。
+func (w *watcher) seek(id string, pos time.Duration) {
+	for _, p := range w.players {
+		if p.id != id {
+			continue
+		}
+		if p.track.TrackID == "" || p.track.TrackID == noTrack {
+			log.Printf("MPRIS: %s 没有 trackid，无法跳转", p.name)
+			return
+		}
+		call := w.conn.Object(p.owner, objectPath).Call(playerIface+".SetPosition", 0, dbus.ObjectPath(p.track.TrackID), pos.Microseconds())
+		if call.Err != nil {
+			log.Printf("MPRIS: %s 跳转失败: %v", p.name, call.Err)
+		}
+		return
+	}
+}
+
+func (w *watcher) handleSignal(sig *dbus.Signal) {
+	switch sig.Name {
+	case dbusIface + ".NameOwnerChanged":
+		var name, oldOwner, newOwner string
+		if err := dbus.Store(sig.Body, &name, &oldOwner, &newOwner); err != nil || !strings.HasPrefix(name, busPrefix) {
+			return
+		}
+		if oldOwner != "" {
+			w.remove(oldOwner)
+		}
+		if newOwner != "" {
+			w.add(name, newOwner)
+		}
+
+	case propsIface + ".PropertiesChanged":
+		p, ok := w.players[sig.Sender]
+		if !ok || sig.Path != objectPath {
+			return
+		}
+		var iface string
+		var changed map[string]dbus.Variant
+		var invalidated []string
+		if err := dbus.Store(sig.Body, &iface, &changed, &invalidated); err != nil || iface != playerIface {
+			return
+		}
+		w.apply(p, changed)
+		if len(invalidated) > 0 {
+			// 只给出了失效的属性名，需要重新读取
+			w.refresh(p)
+		}
+
+	case playerIface + ".Seeked":
+		p, ok := w.players[sig.Sender]
+		if !ok || len(sig.Body) == 0 {
+			return
+		}
+		if pos, ok := toInt64(sig.Body[0]); ok {
+			w.send(p.id, ws.ProgressUpdate{Progress: pos / 1000})
+		}
+	}
+}
+
+// trackInfo 是从 MPRIS Metadata 中取出的字段。
+type trackInfo struct {
+	TrackID string
+	Title   string
+	Album   string
+	Artists []string
+	URL     string
+	ArtURL  string
+	Length  time.Duration
+}
+
+// key 用于判断是否换了歌，播放器没有给出 trackid 时退回到 url 与标题。
+func (t trackInfo) key() string {
+	if t.TrackID != "" && t.TrackID != noTrack {
+		return t.TrackID
+	}
+	return t.URL + "\x00" + t.Title
+}
+
+func (t trackInfo) musicInfo() ws.MusicInfo {
+	info := ws.MusicInfo{
+		MusicId:   t.TrackID,
+		MusicName: t.Title,
+		AlbumName: t.Album,
+		Duration:  t.Length.Milliseconds(),
+	}
+	if info.MusicId == "" || info.MusicId == noTrack {
+		info.MusicId = t.URL
+	}
+	for _, name := range t.Artists {
+		info.Artists = append(info.Artists, ws.Artist{Name: name})
+	}
+	return info
+}
+
+// parseMetadata 读取 Metadata 字典，类型不符的字段按缺失处理。
+func parseMetadata(md map[string]dbus.Variant) trackInfo {
+	str := func(key string) string {
+		if v, ok := md[key]; ok {
+			switch s := v.Value().(type) {
+			case string:
+				return s
+			case dbus.ObjectPath:
+				return string(s)
+			}
+		}
+		return ""
+	}
+	t := trackInfo{
+		TrackID: str("mpris:trackid"),
+		Title:   str("xesam:title"),
+		Album:   str("xesam:album"),
+		URL:     str("xesam:url"),
+		ArtURL:  str("mpris:artUrl"),
+	}
+	if v, ok := md["xesam:artist"]; ok {
+		switch a := v.Value().(type) {
+		case []string:
+			t.Artists = a
+		case string:
+			// 部分播放器不按规范，直接给字符串
+			t.Artists = []string{a}
+		}
+	}
+	if v, ok := md["mpris:length"]; ok {
+		if us, ok := toInt64(v.Value()); ok && us > 0 {
+			t.Length = time.Duration(us) * time.Microsecond
+		}
+	}
+	return t
+}
+
+// toInt64 兼容规范的 int64 和部分播放器使用的其它整数类型。
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// coverUpdate 把 mpris:artUrl 转换为 setCover uri。file:// 与 http(s) 地址都交给封面 worker 读取，
+// MPRIS 属于进程内数据源，本地文件不受 -cover-local-paths 限制。
+func coverUpdate(artURL string) (ws.SetCoverUpdate, bool) {
+	lower := strings.ToLower(artURL)
+	if !strings.HasPrefix(lower, "file://") && !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return ws.SetCoverUpdate{}, false
+	}
+	return ws.SetCoverUpdate{Source: "uri", URL: artURL}, true
+}
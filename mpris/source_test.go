@@ -0,0 +1,253 @@
+package mpris
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/xiaowumin-mark/EbitenLyrics/ws"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:tmpdir=%DIR%</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startBus 启动一个私有的 dbus-daemon，返回它的地址。
+func startBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "session.conf")
+	if err := os.WriteFile(config, []byte(strings.ReplaceAll(busConfig, "%DIR%", dir)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read bus address: %v", err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+func connect(t *testing.T, addr string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatalf("connect %s: %v", addr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// fakePlayer 在总线上导出一个只有 Player 属性的 MPRIS 播放器。
+func fakePlayer(t *testing.T, conn *dbus.Conn, metadata map[string]dbus.Variant) *prop.Properties {
+	t.Helper()
+	props, err := prop.Export(conn, objectPath, prop.Map{
+		playerIface: {
+			"Metadata":       {Value: metadata, Emit: prop.EmitTrue},
+			"PlaybackStatus": {Value: "Playing", Emit: prop.EmitTrue},
+			"Position":       {Value: int64(12_500_000), Emit: prop.EmitFalse},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := conn.RequestName(busPrefix+"fake", dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("request name: %v %v", reply, err)
+	}
+	return props
+}
+
+// expect 读取消息直到遇到 T 类型的 payload，忽略其它消息。
+func expect[T any](t *testing.T, msgChan ws.MessageChannel) (T, string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-msgChan:
+			if p, ok := msg.Payload.(T); ok {
+				return p, msg.Source
+			}
+		case <-timeout:
+			var zero T
+			t.Fatalf("timed out waiting for %T", zero)
+			return zero, ""
+		}
+	}
+}
+
+func TestSourceFollowsFakePlayer(t *testing.T) {
+	addr := startBus(t)
+	playerConn := connect(t, addr)
+
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "song.flac")
+	cover := filepath.Join(dir, "cover.jpg")
+	props := fakePlayer(t, playerConn, map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath("/fake/track/1")),
+		"xesam:title":   dbus.MakeVariant("Song"),
+		"xesam:artist":  dbus.MakeVariant([]string{"A", "B"}),
+		"xesam:album":   dbus.MakeVariant("Album"),
+		"xesam:url":     dbus.MakeVariant("file://" + audio),
+		"mpris:artUrl":  dbus.MakeVariant("file://" + cover),
+		"mpris:length":  dbus.MakeVariant(int64(200_000_000)),
+	})
+
+	msgChan := make(ws.MessageChannel, 64)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	src := &Source{Conn: connect(t, addr), PollInterval: 50 * time.Millisecond}
+	go func() { done <- src.Run(msgChan, stop) }()
+	defer func() {
+		close(stop)
+		if err := <-done; err != nil {
+			t.Errorf("Run() = %v", err)
+		}
+	}()
+
+	connected, id := expect[ws.SourceConnected](t, msgChan)
+	if id != "mpris:fake" || connected.Protocol != ws.MPRIS {
+		t.Fatalf("connected = %q %+v", id, connected)
+	}
+	music, _ := expect[ws.SetMusicUpdate](t, msgChan)
+	if music.MusicName != "Song" || music.AlbumName != "Album" || len(music.Artists) != 2 || music.Duration != 200_000 || music.MusicId != "/fake/track/1" {
+		t.Fatalf("music = %+v", music)
+	}
+	// 本地封面不在这里读取，交给封面 worker
+	coverMsg, _ := expect[ws.SetCoverUpdate](t, msgChan)
+	if coverMsg.Source != "uri" || coverMsg.URL != "file://"+cover {
+		t.Fatalf("cover = %+v", coverMsg)
+	}
+	expect[ws.ResumedUpdate](t, msgChan)
+	if progress, _ := expect[ws.ProgressUpdate](t, msgChan); progress.Progress != 12_500 {
+		t.Fatalf("progress = %d, want 12500", progress.Progress)
+	}
+
+	// 属性变化通过 PropertiesChanged 送达
+	props.SetMust(playerIface, "PlaybackStatus", "Paused")
+	expect[ws.PausedUpdate](t, msgChan)
+
+	// Seeked 信号立即带来新进度
+	if err := playerConn.Emit(objectPath, playerIface+".Seeked", int64(30_000_000)); err != nil {
+		t.Fatal(err)
+	}
+	if progress, _ := expect[ws.ProgressUpdate](t, msgChan); progress.Progress != 30_000 {
+		t.Fatalf("seeked progress = %d, want 30000", progress.Progress)
+	}
+
+	if _, err := playerConn.ReleaseName(busPrefix + "fake"); err != nil {
+		t.Fatal(err)
+	}
+	if _, id := expect[ws.SourceDisconnected](t, msgChan); id != "mpris:fake" {
+		t.Fatalf("disconnected source = %q", id)
+	}
+}
+
+func TestSourceSeeksWithSetPosition(t *testing.T) {
+	addr := startBus(t)
+	playerConn := connect(t, addr)
+	fakePlayer(t, playerConn, map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath("/fake/track/7")),
+		"xesam:title":   dbus.MakeVariant("Song"),
+	})
+	type setPosition struct {
+		track dbus.ObjectPath
+		pos   int64
+	}
+	calls := make(chan setPosition, 1)
+	err := playerConn.ExportMethodTable(map[string]interface{}{
+		"SetPosition": func(track dbus.ObjectPath, pos int64) *dbus.Error {
+			calls <- setPosition{track, pos}
+			return nil
+		},
+	}, objectPath, playerIface)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &Source{Conn: connect(t, addr), PollInterval: time.Hour}
+	if err := src.Seek("mpris:fake", time.Second); err == nil {
+		t.Fatal("Seek before Run should fail")
+	}
+	if err := src.Seek("127.0.0.1:5000", time.Second); !errors.Is(err, ws.ErrUnknownSource) {
+		t.Fatalf("Seek(websocket source) = %v, want ErrUnknownSource", err)
+	}
+
+	msgChan := make(ws.MessageChannel, 64)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- src.Run(msgChan, stop) }()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	// 收到曲目信息后播放器已登记完毕
+	expect[ws.SetMusicUpdate](t, msgChan)
+
+	if err := src.Seek("mpris:fake", 42500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case call := <-calls:
+		if call.track != "/fake/track/7" || call.pos != 42_500_000 {
+			t.Fatalf("SetPosition(%s, %d)", call.track, call.pos)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SetPosition was not called")
+	}
+}
+
+func TestParseMetadataToleratesLooseTypes(t *testing.T) {
+	md := parseMetadata(map[string]dbus.Variant{
+		"xesam:title":  dbus.MakeVariant("Song"),
+		"xesam:artist": dbus.MakeVariant("Solo"),
+		"xesam:url":    dbus.MakeVariant("https://example.com/song"),
+		"mpris:length": dbus.MakeVariant(uint64(3_000_000)),
+		"xesam:album":  dbus.MakeVariant(42),
+	})
+	if md.Title != "Song" || len(md.Artists) != 1 || md.Artists[0] != "Solo" || md.Album != "" || md.Length != 3*time.Second {
+		t.Fatalf("parseMetadata = %+v", md)
+	}
+	// 没有 trackid 时用 url 作为歌曲 id
+	if info := md.musicInfo(); info.MusicId != "https://example.com/song" {
+		t.Fatalf("MusicId = %q", info.MusicId)
+	}
+	if cover, ok := coverUpdate("https://example.com/a.jpg"); !ok || cover.URL != "https://example.com/a.jpg" {
+		t.Fatalf("remote cover = %+v %v", cover, ok)
+	}
+	if cover, ok := coverUpdate("mpris-cover:42"); ok {
+		t.Fatalf("unknown scheme cover = %+v", cover)
+	}
+}
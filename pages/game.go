@@ -116,6 +116,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 func (g *Game) OnResize(w, he int, isFirst bool) {
 	log.Println("Game OnResize", w, he, isFirst)
-	if !isFirst {
+	if g.lyric != nil {
+		// 演示页直接画到整个画面上
+		g.lyric.SetViewport(lp.FromLP(float64(w)), lp.FromLP(float64(he)), lyrics.DefaultFocusPosition)
 	}
 }
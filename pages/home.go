@@ -1,7 +1,7 @@
 package pages
 
 // 文件说明：主页场景，负责歌词展示、背景联动、调试信息和运行时交互。
-// 主要职责：接收事件、更新渲染组件、处理滚轮与字体配置热切换。
+// 主要职责：接收事件、更新渲染组件、处理滚轮/拖动滚动与字体配置热切换。
 
 import (
 	"errors"
@@ -11,8 +11,8 @@ import (
 	"math"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/xiaowumin-mark/EbitenLyrics/anim"
@@ -23,6 +23,8 @@ import (
 	f "github.com/xiaowumin-mark/EbitenLyrics/font"
 	"github.com/xiaowumin-mark/EbitenLyrics/lp"
 	"github.com/xiaowumin-mark/EbitenLyrics/lyrics"
+	"github.com/xiaowumin-mark/EbitenLyrics/palette"
+	"github.com/xiaowumin-mark/EbitenLyrics/playback"
 	"github.com/xiaowumin-mark/EbitenLyrics/router"
 	"github.com/xiaowumin-mark/EbitenLyrics/ws"
 
@@ -32,6 +34,9 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
+// EBITENLYRICS_THEME 取该值时启用跟随封面配色，而不是读取主题文件。
+const themeAutoValue = "auto"
+
 var runtimeWeightSteps = []f.Weight{
 	f.WeightLight,
 	f.WeightRegular,
@@ -54,11 +59,19 @@ type Home struct {
 	CoverPosition lyrics.Position
 	MeshRenderer  *bgrender.MeshGradientRenderer
 	meshLastTick  time.Time
+	// screenW / screenH 是最近一次 OnResize 的画面尺寸，歌词组件铺满整个画面。
+	screenW, screenH int
 
 	FontSize           float64
 	FD                 float64
 	UserScale          float64
 	SmartTranslateWrap bool
+	ShowNowPlaying     bool
+	// BeatBackground / BeatLyricKick 是节拍驱动背景加速与当前行缩放的强度，0 表示关闭。
+	BeatBackground float64
+	BeatLyricKick  float64
+	// ScrollResumeDelay 是手动滚动停下后回到当前行前的等待时间。
+	ScrollResumeDelay time.Duration
 
 	eventsBound bool
 
@@ -68,31 +81,39 @@ type Home struct {
 	fontItalic    bool
 	currentFamily string
 	fontConfig    string
-
-	pendingMu             sync.Mutex
-	hasPendingLyrics      bool
-	pendingLyrics         []ttml.LyricLine
-	hasPendingProgress    bool
-	pendingProgress       time.Duration
-	hasPendingCover       bool
-	pendingCover          image.Image
-	hasPendingLowFreq     bool
-	pendingLowFreqVolume  float64
-	hasPendingFontConfig  bool
-	pendingFontConfig     map[string]any
-	hasLatestProgress     bool
-	latestProgress        time.Duration
+	themePath     string
+	// themeAuto 为真时歌词配色跟随封面调色板，coverTheme 是最近一张封面生成的主题。
+	themeAuto     bool
+	coverPalette  palette.Palette
+	coverTheme    lyrics.Theme
+	hasCoverTheme bool
+
+	events                *evbus.Queue
+	subscriptions         evbus.Group
+	lowFreqVolume         float64
+	spectrum              []float64
+	lastBeat              ws.Beat
+	nowPlaying            nowPlayingOverlay
+	clock                 *playback.PlaybackClock
+	clockRate             float64
+	clockToleranceMs      float64
 	isUserScrolling       bool
 	manualScrollOffset    float64
 	manualScrollTarget    float64
 	manualScrollResumeAt  time.Time
 	manualScrollReturnAni *anim.Tween
+	// scrollVelocity 是松手后的惯性速度（逻辑像素/秒），scrollResumeSeconds 供调试面板调节 ScrollResumeDelay
+	scrollVelocity      float64
+	scrollResumeSeconds float64
+	drag                scrollDrag
+	// hoverLine / pressedLine 是指针悬停与按下时所在的歌词行，-1 表示没有
+	hoverLine   int
+	pressedLine int
 
 	lastMemSampleAt   time.Time
 	memSampleInterval time.Duration
 	memPanel          string
 
-	lastProgress       time.Duration
 	DebugPanel         *debugpanel.Panel
 	debugInputCaptured bool
 }
@@ -208,6 +229,10 @@ func (h *Home) collectLyricsDebugStats() lyricsDebugStats {
 			stats.lineImages++
 			addImage(line.Image)
 		}
+		if blurred := line.GetDepthBlurImage(); blurred != nil {
+			stats.lineImages++
+			addImage(blurred)
+		}
 		if line.TranslateImage != nil {
 			stats.translateImages++
 			addImage(line.TranslateImage)
@@ -300,9 +325,7 @@ func (h *Home) updateMemoryPanel() {
 func (h *Home) setFontSize(size float64) {
 	h.FontSize = math.Max(8, size)
 	if h.LyricsControl != nil {
-		if h.hasLatestProgress {
-			h.LyricsControl.Update(h.latestProgress)
-		}
+		h.syncLyrics()
 		h.LyricsControl.SetFontSize(h.FontSize)
 	}
 }
@@ -325,11 +348,8 @@ func (h *Home) setUserScale(scale float64) {
 			line.Dispose()
 			line.Render()
 		}
-		w, he := ebiten.WindowSize()
-		h.LyricsControl.Resize(lp.FromLP(float64(w)), lp.FromLP(float64(he)))
-		if h.hasLatestProgress {
-			h.LyricsControl.Update(h.latestProgress)
-		}
+		h.layoutLyrics()
+		h.syncLyrics()
 	}
 }
 
@@ -449,8 +469,8 @@ func (h *Home) applyFontRequest(req f.FontRequest) {
 	h.setCurrentFamilyChoice(resolved.Primary.Family)
 	if h.LyricsControl != nil {
 		h.LyricsControl.SetFont(h.FontManager, req)
-		if h.hasLatestProgress {
-			h.LyricsControl.Update(h.latestProgress)
+		if h.clock.HasPosition() {
+			h.syncLyrics()
 		} else if h.LyricsControl.LyricsControl != nil {
 			h.LyricsControl.Update(h.LyricsControl.LyricsControl.Position)
 		}
@@ -564,18 +584,91 @@ func (h *Home) loadAndApplyFontConfig(path string) {
 	h.applyFontRequest(req)
 }
 
+// loadAndApplyTheme 从 JSON 文件加载歌词主题，文件不存在时保持当前主题。
+func (h *Home) loadAndApplyTheme(path string, duration time.Duration) {
+	if h.LyricsControl == nil {
+		return
+	}
+	theme, err := lyrics.LoadTheme(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("load lyric theme failed: %v", err)
+		}
+		return
+	}
+	h.LyricsControl.SetTheme(theme, duration)
+}
+
+// setThemeAuto 切换“跟随封面配色”；关闭时回到主题文件（不存在则为默认主题）。
+func (h *Home) setThemeAuto(enabled bool) {
+	h.themeAuto = enabled
+	if h.LyricsControl == nil {
+		return
+	}
+	if enabled {
+		if h.hasCoverTheme {
+			h.LyricsControl.SetTheme(h.coverTheme, LyricsComponent.ThemeSwitchDuration)
+		}
+		return
+	}
+	h.LyricsControl.SetTheme(lyrics.DefaultTheme(), LyricsComponent.ThemeSwitchDuration)
+	h.loadAndApplyTheme(h.themePath, LyricsComponent.ThemeSwitchDuration)
+}
+
+// updateCoverTheme 记录封面 worker 提取的调色板，按处理后封面的颜色生成主题，自动模式下渐变应用到歌词。
+func (h *Home) updateCoverTheme(colors ws.CoverPalette) {
+	h.coverPalette = colors.Palette
+	if h.coverPalette.Empty() {
+		h.hasCoverTheme = false
+		return
+	}
+	h.coverTheme = lyrics.ThemeOnBackdrop(h.coverPalette, colors.Backdrop)
+	h.hasCoverTheme = true
+	if h.themeAuto && h.LyricsControl != nil {
+		h.LyricsControl.SetTheme(h.coverTheme, LyricsComponent.ThemeSwitchDuration)
+	}
+}
+
+func (h *Home) paletteText() string {
+	if h.coverPalette.Empty() {
+		return "调色板: -"
+	}
+	swatch := func(s palette.Swatch) string {
+		c := s.Color
+		return fmt.Sprintf("#%02x%02x%02x %.0f%% 对比 %.1f", c.R, c.G, c.B, s.Population*100, s.Contrast)
+	}
+	return fmt.Sprintf("主色: %s\n鲜艳: %s\n柔和: %s",
+		swatch(h.coverPalette.Dominant),
+		swatch(h.coverPalette.Vibrant),
+		swatch(h.coverPalette.Muted),
+	)
+}
+
 func (h *Home) debugSummaryText() string {
 	lines := []string{
 		fmt.Sprintf("字体: %s", h.currentFamily),
 		fmt.Sprintf("字重: %d", h.fontWeight),
 		fmt.Sprintf("斜体: %v", h.fontItalic),
-		fmt.Sprintf("用户滚动: %v", h.isUserScrolling),
-		fmt.Sprintf("低频音量: %.2f", h.pendingLowFreqVolume),
+		fmt.Sprintf("用户滚动: %v (偏移 %.0f, 速度 %.0f)", h.isUserScrolling, h.manualScrollOffset, h.scrollVelocity),
+		fmt.Sprintf("低频音量: %.2f", h.lowFreqVolume),
+		fmt.Sprintf("频谱: %s", spectrumText(h.spectrum)),
 		"快捷键: Esc 显示/隐藏面板, F2 液态玻璃测试, F5/F6 切字体, F7/F8 切字重, F9 切斜体, F10 重载字体配置, F11 全屏",
 	}
 	return strings.Join(lines, "\n")
 }
 
+// spectrumText 把每个频段量化为 0-9 的数字，便于在调试面板中观察。
+func spectrumText(bands []float64) string {
+	if len(bands) == 0 {
+		return "-"
+	}
+	var b strings.Builder
+	for _, v := range bands {
+		b.WriteByte('0' + byte(math.Round(math.Max(0, math.Min(1, v))*9)))
+	}
+	return b.String()
+}
+
 func (h *Home) runtimeStatusText() string {
 	listening, connections := ws.StatusSnapshot()
 	wsStatus := "未启动"
@@ -586,14 +679,47 @@ func (h *Home) runtimeStatusText() string {
 		wsStatus = "监听中 端口:11445"
 	}
 
+	active := ws.DefaultArbiter.Active()
+	if active == "" {
+		active = "无"
+	}
+
 	return fmt.Sprintf(
-		"WebSocket: %s\nTPS: %.2f\nFPS: %.2f",
+		"WebSocket: %s\n活动源: %s\nTPS: %.2f\nFPS: %.2f",
 		wsStatus,
+		active,
 		ebiten.ActualTPS(),
 		ebiten.ActualFPS(),
 	)
 }
 
+func (h *Home) sourceChoiceLabels() []string {
+	sources := ws.DefaultArbiter.Sources()
+	labels := make([]string, len(sources))
+	for i, src := range sources {
+		labels[i] = src.ID
+	}
+	return labels
+}
+
+func (h *Home) currentSourceChoiceIndex() int {
+	active := ws.DefaultArbiter.Active()
+	for i, src := range ws.DefaultArbiter.Sources() {
+		if src.ID == active {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *Home) setSourceChoiceIndex(index int) {
+	sources := ws.DefaultArbiter.Sources()
+	if index < 0 || index >= len(sources) {
+		return
+	}
+	ws.DefaultArbiter.Select(sources[index].ID)
+}
+
 func (h *Home) setupDebugPanel() {
 	panel := debugpanel.New(
 		"首页调试",
@@ -606,6 +732,28 @@ func (h *Home) setupDebugPanel() {
 			return h.runtimeStatusText()
 		})
 
+	panel.Group("数据源", false).
+		Description("多个播放器同时连接时，只有活动源会驱动界面。").
+		Select("仲裁策略", func() []string {
+			policies := ws.ArbitrationPolicies()
+			labels := make([]string, len(policies))
+			for i, policy := range policies {
+				labels[i] = policy.String()
+			}
+			return labels
+		}, func() int {
+			return int(ws.DefaultArbiter.Policy())
+		}, func(index int) {
+			ws.DefaultArbiter.SetPolicy(ws.ArbitrationPolicy(index))
+		}).
+		Select("活动源", func() []string {
+			return h.sourceChoiceLabels()
+		}, func() int {
+			return h.currentSourceChoiceIndex()
+		}, func(index int) {
+			h.setSourceChoiceIndex(index)
+		})
+
 	panel.Group("歌词", true).
 		Description("运行时调整歌词布局与动画参数。").
 		Float("字体大小", &h.FontSize, 8, 120, 1, 0, func(value float64) {
@@ -616,6 +764,98 @@ func (h *Home) setupDebugPanel() {
 		}).
 		Bool("智能翻译换行", &h.SmartTranslateWrap, func(value bool) {
 			h.setSmartTranslateWrap(value)
+		}).
+		Select("对齐", func() []string {
+			aligns := lyrics.LyricAligns()
+			labels := make([]string, len(aligns))
+			for i, align := range aligns {
+				labels[i] = align.String()
+			}
+			return labels
+		}, func() int {
+			return int(h.LyricsControl.Align)
+		}, func(index int) {
+			h.LyricsControl.SetAlign(lyrics.LyricAlign(index))
+		}).
+		Select("排版方向", func() []string {
+			modes := lyrics.WritingModes()
+			labels := make([]string, len(modes))
+			for i, mode := range modes {
+				labels[i] = mode.String()
+			}
+			return labels
+		}, func() int {
+			return int(h.LyricsControl.WritingMode)
+		}, func(index int) {
+			h.LyricsControl.SetWritingMode(lyrics.WritingMode(index))
+		}).
+		Float("焦点位置", &h.LyricsControl.FocusPosition, 0, 1, 0.01, 2, func(value float64) {
+			h.LyricsControl.SetFocusPosition(value)
+		}).
+		Bool("景深模糊", &h.LyricsControl.DepthBlur, func(value bool) {
+			h.LyricsControl.SetDepthBlur(value, h.LyricsControl.DepthBlurStrength)
+		}).
+		Float("景深强度", &h.LyricsControl.DepthBlurStrength, 0, 3, 0.1, 1, func(value float64) {
+			h.LyricsControl.SetDepthBlur(h.LyricsControl.DepthBlur, value)
+		}).
+		Float("回到当前行延迟(秒)", &h.scrollResumeSeconds, 0, 10, 0.1, 1, func(value float64) {
+			h.ScrollResumeDelay = time.Duration(value * float64(time.Second))
+		}).
+		Bool("跟随封面配色", &h.themeAuto, func(value bool) {
+			h.setThemeAuto(value)
+		}).
+		Action("重载主题", func() {
+			h.themeAuto = false
+			h.loadAndApplyTheme(h.themePath, LyricsComponent.ThemeSwitchDuration)
+		}).
+		Action("默认主题", func() {
+			h.themeAuto = false
+			h.LyricsControl.SetTheme(lyrics.DefaultTheme(), LyricsComponent.ThemeSwitchDuration)
+		}).
+		Text("", func() string {
+			return h.paletteText()
+		})
+
+	panel.Group("播放时钟", false).
+		Description("在两次进度上报之间按本地时钟外推播放位置。").
+		Float("倍速", &h.clockRate, 0.25, 4, 0.05, 2, func(value float64) {
+			h.setClockRate(value)
+		}).
+		Float("修正容差(ms)", &h.clockToleranceMs, 0, 5000, 50, 0, func(value float64) {
+			h.setClockTolerance(value)
+		}).
+		Text("", func() string {
+			state := "播放中"
+			if !h.clock.Playing() {
+				state = "已暂停"
+			}
+			return fmt.Sprintf("位置: %s\n状态: %s", formatPlaybackTime(h.clock.Position()), state)
+		})
+
+	panel.Group("节拍", false).
+		Description("根据音频起音让背景流动加速、当前行轻微放大。").
+		Float("背景强度", &h.BeatBackground, 0, 2, 0.05, 2, func(value float64) {
+			h.setBeatBackground(value)
+		}).
+		Float("歌词强度", &h.BeatLyricKick, 0, 1, 0.05, 2, nil).
+		Text("", func() string {
+			if h.lastBeat.BPM <= 0 {
+				return "BPM: -"
+			}
+			return fmt.Sprintf("BPM: %.1f\n置信度: %.2f", h.lastBeat.BPM, h.lastBeat.Confidence)
+		})
+
+	panel.Group("正在播放", false).
+		Bool("显示浮层", &h.ShowNowPlaying, nil).
+		Action("再次显示", func() {
+			h.nowPlaying.show(time.Now())
+		}).
+		Text("", func() string {
+			if !h.nowPlaying.hasInfo {
+				return "暂无歌曲信息"
+			}
+			info := h.nowPlaying.info
+			return fmt.Sprintf("%s\n%s\n%s", info.Name, info.ArtistNames(), info.Album)
 		})
 
 	panel.Group("字体", true).
@@ -663,13 +903,6 @@ func (h *Home) setupDebugPanel() {
 	h.DebugPanel = panel
 }
 
-func (h *Home) queueLyrics(lines []ttml.LyricLine) {
-	h.pendingMu.Lock()
-	h.hasPendingLyrics = true
-	h.pendingLyrics = lines
-	h.pendingMu.Unlock()
-}
-
 func (h *Home) setSmartTranslateWrap(enabled bool) {
 	h.SmartTranslateWrap = enabled
 	if h.LyricsControl != nil {
@@ -677,255 +910,262 @@ func (h *Home) setSmartTranslateWrap(enabled bool) {
 	}
 }
 
-func (h *Home) queueProgress(progress time.Duration) {
-	h.pendingMu.Lock()
-	h.hasPendingProgress = true
-	h.pendingProgress = progress
-	h.latestProgress = progress
-	h.hasLatestProgress = true
-	h.pendingMu.Unlock()
+func (h *Home) applyLyrics(lines []ttml.LyricLine) {
+	if h.LyricsControl == nil {
+		return
+	}
+	h.LyricsControl.SetLyrics(lines)
+	// 新歌词没有悬停状态，下一帧按指针位置重新设置
+	h.hoverLine = -1
+	if !h.isUserScrolling {
+		h.syncLyrics()
+	}
 }
 
-func (h *Home) queueCover(img image.Image) {
-	h.pendingMu.Lock()
-	h.hasPendingCover = true
-	h.pendingCover = img
-	h.pendingMu.Unlock()
+// syncLyrics 把歌词推进到播放时钟的当前位置。
+func (h *Home) syncLyrics() {
+	if h.LyricsControl == nil || !h.clock.HasPosition() {
+		return
+	}
+	h.LyricsControl.Update(h.clock.Position())
 }
 
-func (h *Home) queueLowFreqVolume(volume float64) {
-	h.pendingMu.Lock()
-	h.hasPendingLowFreq = true
-	h.pendingLowFreqVolume = volume
-	h.pendingMu.Unlock()
+// applyProgress 把播放器上报的进度交给播放时钟，由时钟平滑吸收抖动与漂移。
+func (h *Home) applyProgress(newProgress time.Duration) {
+	h.clock.Report(newProgress)
 }
 
-func cloneMapStringAny(src map[string]any) map[string]any {
-	if src == nil {
-		return nil
-	}
-	out := make(map[string]any, len(src))
-	for k, v := range src {
-		out[k] = v
+func (h *Home) applyPlaying(playing bool) {
+	if playing {
+		h.clock.Resume()
+	} else {
+		h.clock.Pause()
 	}
-	return out
 }
 
-func (h *Home) queueFontConfig(cfg map[string]any) {
-	h.pendingMu.Lock()
-	h.hasPendingFontConfig = true
-	h.pendingFontConfig = cloneMapStringAny(cfg)
-	h.pendingMu.Unlock()
-}
-
-func (h *Home) applyPendingEvents() {
-	var (
-		hasLyrics      bool
-		lyricsLines    []ttml.LyricLine
-		hasProgress    bool
-		progress       time.Duration
-		hasCover       bool
-		coverImage     image.Image
-		hasLowFreq     bool
-		lowFreqVolume  float64
-		hasFontConfig  bool
-		fontConfigData map[string]any
-	)
+func (h *Home) setClockRate(rate float64) {
+	h.clockRate = math.Max(0.25, math.Min(4, rate))
+	h.clock.SetRate(h.clockRate)
+}
 
-	h.pendingMu.Lock()
-	if h.hasPendingLyrics {
-		hasLyrics = true
-		lyricsLines = h.pendingLyrics
-		h.hasPendingLyrics = false
-		h.pendingLyrics = nil
-	}
-	if h.hasPendingProgress {
-		hasProgress = true
-		progress = h.pendingProgress
-		h.hasPendingProgress = false
-	}
-	if h.hasPendingCover {
-		hasCover = true
-		coverImage = h.pendingCover
-		h.hasPendingCover = false
-		h.pendingCover = nil
-	}
-	if h.hasPendingLowFreq {
-		hasLowFreq = true
-		lowFreqVolume = h.pendingLowFreqVolume
-		h.hasPendingLowFreq = false
-	}
-	if h.hasPendingFontConfig {
-		hasFontConfig = true
-		fontConfigData = h.pendingFontConfig
-		h.hasPendingFontConfig = false
-		h.pendingFontConfig = nil
-	}
-	h.pendingMu.Unlock()
-
-	if hasLyrics && h.LyricsControl != nil {
-		h.LyricsControl.SetLyrics(lyricsLines)
-		if h.hasLatestProgress && !h.isUserScrolling {
-			h.LyricsControl.Update(h.latestProgress)
-		}
-	}
+func (h *Home) setClockTolerance(ms float64) {
+	h.clockToleranceMs = math.Max(0, ms)
+	h.clock.Tolerance = time.Duration(h.clockToleranceMs * float64(time.Millisecond))
+}
 
-	if hasCover && coverImage != nil {
-		if h.Cover != nil {
-			h.Cover.Dispose()
-			h.Cover = nil
-		}
-		h.Cover = ebiten.NewImageFromImage(coverImage)
-		h.CoverPosition.W = lp.FromLP(float64(h.Cover.Bounds().Dx()))
-		h.CoverPosition.H = lp.FromLP(float64(h.Cover.Bounds().Dy()))
-		h.CoverPosition.OriginX = h.CoverPosition.W / 2
-		h.CoverPosition.OriginY = h.CoverPosition.H / 2
-		w, he := ebiten.WindowSize()
-		h.updateCoverTransform(lp.FromLP(float64(w)), lp.FromLP(float64(he)))
-		if h.MeshRenderer != nil {
-			if err := h.MeshRenderer.SetAlbum(coverImage); err != nil {
-				log.Printf("mesh renderer set album failed: %v", err)
-			}
-			log.Printf("mesh renderer set album success")
-		}
+func (h *Home) applyBeat(beat ws.Beat) {
+	h.lastBeat = beat
+	// 不在节拍网格上的起音只给一半力度，避免杂乱的打击乐让画面乱跳
+	strength := beat.Strength * (0.5 + 0.5*beat.Confidence)
+	if h.MeshRenderer != nil {
+		h.MeshRenderer.Pulse(strength)
 	}
-
-	if hasFontConfig {
-		h.applyMapFontConfig(fontConfigData)
+	if h.LyricsControl != nil && h.BeatLyricKick > 0 && !h.isUserScrolling {
+		h.LyricsControl.Kick(strength * h.BeatLyricKick)
 	}
+}
 
-	if hasLowFreq && h.MeshRenderer != nil {
-		h.MeshRenderer.SetLowFreqVolume(lowFreqVolume)
+func (h *Home) setBeatBackground(intensity float64) {
+	h.BeatBackground = math.Max(0, math.Min(2, intensity))
+	if h.MeshRenderer != nil {
+		h.MeshRenderer.SetBeatIntensity(h.BeatBackground)
 	}
+}
 
-	if hasProgress && h.LyricsControl != nil && !h.isUserScrolling {
-		h.LyricsControl.Update(progress)
+func (h *Home) applyCover(coverImage image.Image) {
+	if coverImage == nil {
+		return
+	}
+	if h.Cover != nil {
+		h.Cover.Dispose()
+		h.Cover = nil
 	}
+	h.Cover = ebiten.NewImageFromImage(coverImage)
+	h.CoverPosition.W = lp.FromLP(float64(h.Cover.Bounds().Dx()))
+	h.CoverPosition.H = lp.FromLP(float64(h.Cover.Bounds().Dy()))
+	h.CoverPosition.OriginX = h.CoverPosition.W / 2
+	h.CoverPosition.OriginY = h.CoverPosition.H / 2
+	h.updateCoverTransform(lp.FromLP(float64(h.screenW)), lp.FromLP(float64(h.screenH)))
 }
 
-func (h *Home) beginUserScroll() {
+func (h *Home) beginUserScroll(now time.Time) {
 	h.isUserScrolling = true
-	h.manualScrollResumeAt = time.Now().Add(time.Second)
+	h.scrollVelocity = 0
+	h.manualScrollResumeAt = now.Add(h.ScrollResumeDelay)
 	if h.manualScrollReturnAni != nil {
 		h.manualScrollReturnAni.Cancel()
 		h.manualScrollReturnAni = nil
 	}
 }
 
-func (h *Home) clampScrollTarget() {
-	_, wh := ebiten.WindowSize()
-	maxAbs := math.Max(200, lp.FromLP(float64(wh))*2.5)
-	if h.manualScrollTarget > maxAbs {
-		h.manualScrollTarget = maxAbs
+// scrollViewport 返回歌词在滚动方向上的可见长度（逻辑像素）。
+func (h *Home) scrollViewport() float64 {
+	if h.LyricsControl == nil {
+		return 0
 	}
-	if h.manualScrollTarget < -maxAbs {
-		h.manualScrollTarget = -maxAbs
+	if h.LyricsControl.WritingMode == lyrics.WritingVertical {
+		// 竖排沿水平方向滚动
+		return h.LyricsControl.Width
 	}
+	return h.LyricsControl.Height
 }
 
-func (h *Home) handleWheelScroll() {
-	_, wy := ebiten.Wheel()
-	if wy != 0 {
-		h.beginUserScroll()
-		h.manualScrollTarget += -wy * math.Max(24, h.FontSize*0.85)
-		h.clampScrollTarget()
+// scrollBounds 返回手动滚动偏移的范围：正向最多让第一行停在焦点处，反向最多让最后一行停在焦点处。
+func (h *Home) scrollBounds() (lo, hi float64) {
+	if h.LyricsControl == nil {
+		return 0, 0
 	}
+	return h.LyricsControl.ScrollBounds()
+}
 
-	if h.isUserScrolling {
-		h.manualScrollOffset += (h.manualScrollTarget - h.manualScrollOffset) * 0.23
-		if math.Abs(h.manualScrollTarget-h.manualScrollOffset) < 0.2 {
-			h.manualScrollOffset = h.manualScrollTarget
+func (h *Home) clampScrollTarget() {
+	lo, hi := h.scrollBounds()
+	h.manualScrollTarget = math.Min(math.Max(h.manualScrollTarget, lo), hi)
+}
+
+// handleScroll 处理滚轮、拖动与惯性滚动；停下并等待 ScrollResumeDelay 后回到当前行。
+func (h *Home) handleScroll(now time.Time, dt time.Duration) {
+	if h.debugInputCaptured {
+		h.cancelDrag()
+	} else {
+		_, wy := ebiten.Wheel()
+		if wy != 0 && !h.drag.dragging {
+			h.beginUserScroll(now)
+			h.manualScrollTarget += -wy * math.Max(24, h.FontSize*0.85)
+			h.clampScrollTarget()
 		}
+		h.handleDrag(now)
+	}
 
-		if time.Now().After(h.manualScrollResumeAt) {
+	if h.isUserScrolling && !h.drag.dragging {
+		if h.stepScroll(dt.Seconds()) {
+			h.manualScrollResumeAt = now.Add(h.ScrollResumeDelay)
+		} else if now.After(h.manualScrollResumeAt) {
 			h.isUserScrolling = false
-			from := h.manualScrollOffset
-			if h.manualScrollReturnAni != nil {
-				h.manualScrollReturnAni.Cancel()
-				h.manualScrollReturnAni = nil
-			}
-			h.manualScrollReturnAni = anim.NewTween(
-				"home-manual-scroll-return",
-				380*time.Millisecond,
-				0,
-				1,
-				from,
-				0,
-				anim.EaseOut,
-				func(value float64) {
-					h.manualScrollOffset = value
-					h.manualScrollTarget = value
-				},
-				func() {
-					h.manualScrollOffset = 0
-					h.manualScrollTarget = 0
-					h.manualScrollReturnAni = nil
-				},
-			)
-			if h.AnimateManager != nil {
-				h.AnimateManager.Add(h.manualScrollReturnAni)
-			}
-			if h.hasLatestProgress && h.LyricsControl != nil {
-				h.LyricsControl.Update(h.latestProgress)
-			}
+			h.returnManualScroll()
+			h.syncLyrics()
 		}
 	}
+	if h.LyricsControl != nil {
+		h.LyricsControl.SetScrollOffset(h.manualScrollOffset)
+	}
 }
 
-func (h *Home) bindEvents() {
-	if h.eventsBound {
+// returnManualScroll 让手动滚动偏移以动画回到 0，歌词回到跟随播放的位置。
+func (h *Home) returnManualScroll() {
+	from := h.manualScrollOffset
+	if h.manualScrollReturnAni != nil {
+		h.manualScrollReturnAni.Cancel()
+		h.manualScrollReturnAni = nil
+	}
+	if from == 0 {
+		h.manualScrollTarget = 0
 		return
 	}
-	h.eventsBound = true
-
-	evbus.Bus.Subscribe("ws:setLyric", func(value []interface{}) {
-		d, err := ws.ParseLyricsFromMap(value)
-		if err != nil {
-			log.Printf("parse lyric failed: %v", err)
-			return
-		}
-		h.queueLyrics(d)
-	})
+	h.manualScrollReturnAni = anim.NewTween(
+		"home-manual-scroll-return",
+		380*time.Millisecond,
+		0,
+		1,
+		from,
+		0,
+		anim.EaseOut,
+		func(value float64) {
+			h.manualScrollOffset = value
+			h.manualScrollTarget = value
+		},
+		func() {
+			h.manualScrollOffset = 0
+			h.manualScrollTarget = 0
+			h.manualScrollReturnAni = nil
+		},
+	)
+	if h.AnimateManager != nil {
+		h.AnimateManager.Add(h.manualScrollReturnAni)
+	}
+}
 
-	evbus.Bus.Subscribe("ws:progress", func(value float64) {
-		newProgress := time.Duration(value) * time.Millisecond
+// layoutLyrics 让歌词组件铺满画面。
+func (h *Home) layoutLyrics() {
+	if h.LyricsControl == nil || h.screenW <= 0 || h.screenH <= 0 {
+		return
+	}
+	h.LyricsControl.Resize(lp.FromLP(float64(h.screenW)), lp.FromLP(float64(h.screenH)))
+}
 
-		// 1. 计算当前收到的进度与上次进度的差值
-		diff := newProgress - h.lastProgress
+// lineAt 返回屏幕坐标 (x, y) 处的歌词行下标，没有命中返回 -1。
+func (h *Home) lineAt(x, y float64) int {
+	if h.LyricsControl == nil {
+		return -1
+	}
+	return h.LyricsControl.LineAt(x, y, nil)
+}
 
-		// --- 处理向后跳动的情况 ---
-		if diff < 0 {
-			// 如果向后跳动的距离很小（比如在 500ms 以内），我们认为是抖动，直接忽略
-			// 注意：-500ms < diff < 0
-			if diff > -500*time.Millisecond {
-				return
-			}
-			// 如果向后跳动的距离很大（比如超过 500ms），我们认为是用户手动“回拖”了进度条
-			// 这时候我们需要强制更新，否则进度条会卡住
+// handlePointer 提亮鼠标指针下的歌词行；拖动期间不提亮。点击跳转见 handleDrag。
+func (h *Home) handlePointer() {
+	index := -1
+	if !h.debugInputCaptured && !h.drag.dragging {
+		cx, cy := ebiten.CursorPosition()
+		index = h.lineAt(float64(cx), float64(cy))
+	}
+	if index != h.hoverLine {
+		h.hoverLine = index
+		if h.LyricsControl != nil {
+			h.LyricsControl.SetHoverLine(index)
 		}
+		if index >= 0 {
+			ebiten.SetCursorShape(ebiten.CursorShapePointer)
+		} else {
+			ebiten.SetCursorShape(ebiten.CursorShapeDefault)
+		}
+	}
+}
 
-		// --- 处理向前跳动过大的情况 (可选) ---
-		// 如果你发现向前也会偶尔闪跳，也可以加一个上限判断，但通常向前跳动是正常的
-
-		// 更新状态并执行回调
-		h.lastProgress = newProgress
-		h.queueProgress(newProgress)
-	})
-
-	evbus.Bus.Subscribe("ws:fontConfig", func(value map[string]any) {
-		h.queueFontConfig(value)
-	})
+// seekTo 把本地时钟跳到 pos，结束手动滚动，并通过 ws.TopicSeekRequest 请求播放器跟随。
+func (h *Home) seekTo(pos time.Duration) {
+	h.clock.Seek(pos)
+	if h.isUserScrolling {
+		h.isUserScrolling = false
+		h.returnManualScroll()
+	}
+	h.syncLyrics()
+	ws.TopicSeekRequest.Publish(pos)
+}
 
-	evbus.Bus.Subscribe("ws:cover", func(img image.Image) {
-		if img == nil {
-			return
-		}
-		h.queueCover(img)
-	})
+func (h *Home) bindEvents() {
+	if h.eventsBound {
+		return
+	}
+	h.eventsBound = true
+	if h.events == nil {
+		h.events = evbus.NewQueue()
+	}
+
+	// 所有回调都在 Update 中由 h.events.Drain 执行，因此可以直接操作渲染对象。
+	h.subscriptions.Add(ws.TopicLyrics.SubscribeLatest(h.events, h.applyLyrics))
+	h.subscriptions.Add(ws.TopicProgress.SubscribeLatest(h.events, h.applyProgress))
+	h.subscriptions.Add(ws.TopicPlaying.SubscribeLatest(h.events, h.applyPlaying))
+	h.subscriptions.Add(ws.TopicFontConfig.SubscribeOn(h.events, h.applyMapFontConfig))
+	h.subscriptions.Add(ws.TopicCoverPalette.SubscribeLatest(h.events, h.updateCoverTheme))
+	h.subscriptions.Add(ws.TopicCover.SubscribeLatest(h.events, h.applyCover))
+	h.subscriptions.Add(ws.TopicNowPlaying.SubscribeLatest(h.events, func(info ws.NowPlaying) {
+		h.nowPlaying.set(info, time.Now())
+	}))
+	h.subscriptions.Add(ws.TopicLowFreqVolume.SubscribeLatest(h.events, func(value float64) {
+		h.lowFreqVolume = value
+	}))
+	h.subscriptions.Add(ws.TopicSpectrum.SubscribeLatest(h.events, func(bands []float64) {
+		h.spectrum = bands
+	}))
+	h.subscriptions.Add(ws.TopicBeat.SubscribeOn(h.events, h.applyBeat))
+	if h.MeshRenderer != nil {
+		h.subscriptions.Add(bgrender.Subscribe(h.MeshRenderer, h.events, ws.TopicCoverOriginal, ws.TopicLowFreqVolume))
+	}
+}
 
-	evbus.Bus.Subscribe("ws:lowFreqVolume", func(value float64) {
-		h.queueLowFreqVolume(value)
-	})
+func (h *Home) unbindEvents() {
+	h.subscriptions.Close()
+	h.eventsBound = false
 }
 
 func (h *Home) updateCoverTransform(w, he float64) {
@@ -954,11 +1194,22 @@ func (h *Home) OnCreate() {
 	if len(h.FontRequest.Families) == 0 {
 		h.FontRequest = f.DefaultRequest()
 	}
-	ww, hh := ebiten.WindowSize()
 	h.FontSize = 50
 	h.FD = 0.5
 	h.UserScale = lp.UserScale()
 	h.SmartTranslateWrap = true
+	h.ShowNowPlaying = true
+	h.BeatBackground = 0.6
+	h.BeatLyricKick = 0
+	h.ScrollResumeDelay = defaultScrollResumeDelay
+	if raw := strings.TrimSpace(os.Getenv("EBITENLYRICS_SCROLL_RESUME")); raw != "" {
+		if delay, err := time.ParseDuration(raw); err == nil && delay >= 0 {
+			h.ScrollResumeDelay = delay
+		} else {
+			log.Printf("ignore EBITENLYRICS_SCROLL_RESUME: %q", raw)
+		}
+	}
+	h.scrollResumeSeconds = h.ScrollResumeDelay.Seconds()
 	h.fontWeight = h.FontRequest.Weight
 	h.fontItalic = h.FontRequest.Italic
 	h.currentFamily = ""
@@ -977,21 +1228,68 @@ func (h *Home) OnCreate() {
 		h.AnimateManager,
 		h.FontManager,
 		h.FontRequest,
-		lp.FromLP(float64(ww)),
-		lp.FromLP(float64(hh)),
+		// 尺寸在首次 OnResize 时设置
+		0,
+		0,
 		h.FontSize,
 		h.FD,
 	)
 	h.LyricsControl.Init()
 	h.LyricsControl.SetSmartTranslateWrap(h.SmartTranslateWrap)
-	meshRenderer, err := bgrender.NewMeshGradientRenderer(ww, hh)
+	if raw := strings.TrimSpace(os.Getenv("EBITENLYRICS_ALIGN")); raw != "" {
+		if align, err := lyrics.ParseLyricAlign(raw); err == nil {
+			h.LyricsControl.SetAlign(align)
+		} else {
+			log.Printf("ignore EBITENLYRICS_ALIGN: %v", err)
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("EBITENLYRICS_WRITING_MODE")); raw != "" {
+		if mode, err := lyrics.ParseWritingMode(raw); err == nil {
+			h.LyricsControl.SetWritingMode(mode)
+		} else {
+			log.Printf("ignore EBITENLYRICS_WRITING_MODE: %v", err)
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("EBITENLYRICS_DEPTH_BLUR")); raw != "" {
+		// on / off 开关景深模糊，数字设置强度，0 为关闭
+		switch strings.ToLower(raw) {
+		case "on", "true":
+			h.LyricsControl.SetDepthBlur(true, h.LyricsControl.DepthBlurStrength)
+		case "off", "false":
+			h.LyricsControl.SetDepthBlur(false, h.LyricsControl.DepthBlurStrength)
+		default:
+			if strength, err := strconv.ParseFloat(raw, 64); err == nil && strength >= 0 {
+				h.LyricsControl.SetDepthBlur(strength > 0, strength)
+			} else {
+				log.Printf("ignore EBITENLYRICS_DEPTH_BLUR: %q", raw)
+			}
+		}
+	}
+	h.themePath = strings.TrimSpace(os.Getenv("EBITENLYRICS_THEME"))
+	if strings.EqualFold(h.themePath, themeAutoValue) {
+		h.themeAuto = true
+		h.themePath = lyrics.DefaultThemePath
+	}
+	if h.themePath == "" {
+		h.themePath = lyrics.DefaultThemePath
+	}
+	if !h.themeAuto {
+		h.loadAndApplyTheme(h.themePath, 0)
+	}
+	meshRenderer, err := bgrender.NewMeshGradientRenderer(h.screenW, h.screenH)
 	if err != nil {
 		log.Printf("create mesh renderer failed: %v", err)
 	} else {
 		h.MeshRenderer = meshRenderer
+		h.MeshRenderer.SetBeatIntensity(h.BeatBackground)
 	}
 	h.CoverPosition = lyrics.NewPosition(0, 0, 0, 0)
+	h.clock = playback.NewPlaybackClock(nil)
+	h.clockRate = h.clock.Rate()
+	h.clockToleranceMs = float64(h.clock.Tolerance / time.Millisecond)
 	h.memSampleInterval = 500 * time.Millisecond
+	h.hoverLine = -1
+	h.pressedLine = -1
 	h.updateMemoryPanel()
 	h.setupDebugPanel()
 	h.bindEvents()
@@ -1013,6 +1311,7 @@ func (h *Home) OnLeave() {
 
 func (h *Home) OnDestroy() {
 	log.Println("Home OnDestroy")
+	h.unbindEvents()
 	if h.LyricsControl != nil {
 		h.LyricsControl.Dispose()
 		h.LyricsControl = nil
@@ -1039,7 +1338,7 @@ func (h *Home) Update() error {
 		h.DebugPanel.Toggle()
 	}
 
-	h.applyPendingEvents()
+	h.events.Drain()
 	h.debugInputCaptured = false
 	if h.DebugPanel != nil {
 		captured, err := h.DebugPanel.Update()
@@ -1048,8 +1347,11 @@ func (h *Home) Update() error {
 		}
 		h.debugInputCaptured = captured
 	}
-	if !h.debugInputCaptured {
-		h.handleWheelScroll()
+	h.handleScroll(now, dt)
+	h.handlePointer()
+	// 每帧由播放时钟驱动歌词，不再依赖进度上报的频率
+	if !h.isUserScrolling {
+		h.syncLyrics()
 	}
 	if h.MeshRenderer != nil {
 		h.MeshRenderer.Update(dt)
@@ -1118,9 +1420,10 @@ func (h *Home) Draw(screen *ebiten.Image) {
 		screen.DrawImage(h.Cover, op)
 	}
 	if h.LyricsControl != nil {
-		pos := lyrics.NewPosition(0, 0, 0, 0)
-		pos.SetTranslateY(h.manualScrollOffset)
-		h.LyricsControl.Draw(screen, &pos)
+		h.LyricsControl.Draw(screen, nil)
+	}
+	if h.ShowNowPlaying {
+		h.nowPlaying.draw(screen, h.FontManager, h.FontRequest, h.clock.Position(), time.Now())
 	}
 	if h.DebugPanel != nil {
 		h.DebugPanel.Draw(screen)
@@ -1129,9 +1432,8 @@ func (h *Home) Draw(screen *ebiten.Image) {
 
 func (h *Home) OnResize(w, he int, isFirst bool) {
 	log.Println("Home OnResize", w, he, isFirst)
-	if !isFirst && h.LyricsControl != nil {
-		h.LyricsControl.Resize(lp.FromLP(float64(w)), lp.FromLP(float64(he)))
-	}
+	h.screenW, h.screenH = w, he
+	h.layoutLyrics()
 	if h.MeshRenderer != nil {
 		h.MeshRenderer.Resize(w, he)
 	}
@@ -0,0 +1,203 @@
+package pages
+
+// 文件说明：主页的“正在播放”浮层。
+// 主要职责：在切歌时淡入显示歌名、艺术家、专辑与播放进度，数秒后自动淡出。
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	f "github.com/xiaowumin-mark/EbitenLyrics/font"
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+	"github.com/xiaowumin-mark/EbitenLyrics/ws"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	nowPlayingFadeIn  = 400 * time.Millisecond
+	nowPlayingHold    = 4 * time.Second
+	nowPlayingFadeOut = 800 * time.Millisecond
+
+	nowPlayingMargin   = 24.0
+	nowPlayingPadding  = 16.0
+	nowPlayingMaxWidth = 420.0
+	nowPlayingTitle    = 22.0
+	nowPlayingSubtitle = 15.0
+	nowPlayingCaption  = 13.0
+	nowPlayingBar      = 4.0
+
+	// nowPlayingEllipsisCache 是截断结果缓存的上限，窗口拖动缩放时超出即清空。
+	nowPlayingEllipsisCache = 16
+)
+
+// ellipsisKey 标识一次截断：同一文本、字体与宽度的结果不变，无需每帧重新测量。
+type ellipsisKey struct {
+	text string
+	font string
+	size float64
+	maxW float64
+}
+
+type nowPlayingOverlay struct {
+	info    ws.NowPlaying
+	hasInfo bool
+	shownAt time.Time
+
+	ellipsized map[ellipsisKey]string
+}
+
+// set 更新歌曲信息；只有切歌时才重新开始淡入。
+func (o *nowPlayingOverlay) set(info ws.NowPlaying, now time.Time) {
+	changed := !o.hasInfo || !o.info.SameSong(info)
+	o.info = info
+	o.hasInfo = true
+	if changed {
+		o.shownAt = now
+	}
+}
+
+// show 手动再次显示浮层。
+func (o *nowPlayingOverlay) show(now time.Time) {
+	if o.hasInfo {
+		o.shownAt = now
+	}
+}
+
+// alpha 返回当前的不透明度：淡入、停留、淡出。
+func (o *nowPlayingOverlay) alpha(now time.Time) float64 {
+	if !o.hasInfo || o.shownAt.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(o.shownAt)
+	switch {
+	case elapsed < 0:
+		return 0
+	case elapsed < nowPlayingFadeIn:
+		return float64(elapsed) / float64(nowPlayingFadeIn)
+	case elapsed < nowPlayingFadeIn+nowPlayingHold:
+		return 1
+	case elapsed < nowPlayingFadeIn+nowPlayingHold+nowPlayingFadeOut:
+		return 1 - float64(elapsed-nowPlayingFadeIn-nowPlayingHold)/float64(nowPlayingFadeOut)
+	}
+	return 0
+}
+
+func (o *nowPlayingOverlay) draw(screen *ebiten.Image, fm *f.FontManager, req f.FontRequest, progress time.Duration, now time.Time) {
+	alpha := o.alpha(now)
+	if alpha <= 0 || fm == nil {
+		return
+	}
+
+	screenW := lp.FromLP(float64(screen.Bounds().Dx()))
+	screenH := lp.FromLP(float64(screen.Bounds().Dy()))
+	cardW := math.Min(nowPlayingMaxWidth, screenW-nowPlayingMargin*2)
+	if cardW <= nowPlayingPadding*2 {
+		return
+	}
+	textW := cardW - nowPlayingPadding*2
+
+	type row struct {
+		text  string
+		size  float64
+		alpha float64
+	}
+	rows := []row{{o.info.Name, nowPlayingTitle, 1}}
+	if artists := o.info.ArtistNames(); artists != "" {
+		rows = append(rows, row{artists, nowPlayingSubtitle, 0.85})
+	}
+	if o.info.Album != "" {
+		rows = append(rows, row{o.info.Album, nowPlayingCaption, 0.6})
+	}
+
+	cardH := nowPlayingPadding * 2
+	for _, r := range rows {
+		cardH += r.size * 1.4
+	}
+	hasBar := o.info.Duration > 0
+	if hasBar {
+		cardH += nowPlayingCaption*1.4 + nowPlayingBar + 8
+	}
+
+	x := nowPlayingMargin
+	y := screenH - nowPlayingMargin - cardH
+	vector.FillRect(screen,
+		float32(lp.LP(x)), float32(lp.LP(y)), float32(lp.LP(cardW)), float32(lp.LP(cardH)),
+		color.NRGBA{0, 0, 0, uint8(140 * alpha)}, true)
+
+	cy := y + nowPlayingPadding
+	fontKey := req.CacheKey()
+	for _, r := range rows {
+		face, err := fm.GetFaceForText(req, r.size, r.text)
+		if err == nil && face != nil {
+			op := &text.DrawOptions{}
+			op.GeoM.Translate(lp.LP(x+nowPlayingPadding), lp.LP(cy))
+			op.ColorScale.ScaleAlpha(float32(r.alpha * alpha))
+			label := o.ellipsize(ellipsisKey{text: r.text, font: fontKey, size: r.size, maxW: lp.LP(textW)}, face)
+			text.Draw(screen, label, face, op)
+		}
+		cy += r.size * 1.4
+	}
+	if !hasBar {
+		return
+	}
+
+	cy += 8
+	ratio := math.Max(0, math.Min(1, float64(progress)/float64(o.info.Duration)))
+	barX, barY := float32(lp.LP(x+nowPlayingPadding)), float32(lp.LP(cy))
+	barW, barH := float32(lp.LP(textW)), float32(lp.LP(nowPlayingBar))
+	vector.FillRect(screen, barX, barY, barW, barH, color.NRGBA{255, 255, 255, uint8(60 * alpha)}, true)
+	vector.FillRect(screen, barX, barY, barW*float32(ratio), barH, color.NRGBA{255, 255, 255, uint8(220 * alpha)}, true)
+
+	cy += nowPlayingBar + 4
+	label := fmt.Sprintf("%s / %s", formatPlaybackTime(progress), formatPlaybackTime(o.info.Duration))
+	if face, err := fm.GetFaceForText(req, nowPlayingCaption, label); err == nil && face != nil {
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(lp.LP(x+nowPlayingPadding), lp.LP(cy))
+		op.ColorScale.ScaleAlpha(float32(0.6 * alpha))
+		text.Draw(screen, label, face, op)
+	}
+}
+
+// ellipsize 返回缓存的截断结果，缓存未命中时测量一次。
+func (o *nowPlayingOverlay) ellipsize(key ellipsisKey, face text.Face) string {
+	if out, ok := o.ellipsized[key]; ok {
+		return out
+	}
+	if o.ellipsized == nil || len(o.ellipsized) >= nowPlayingEllipsisCache {
+		o.ellipsized = make(map[ellipsisKey]string, nowPlayingEllipsisCache)
+	}
+	out := ellipsize(key.text, face, key.maxW)
+	o.ellipsized[key] = out
+	return out
+}
+
+// ellipsize 在文本超出 maxW（物理像素）时截断并追加省略号，按字符数二分查找最长的前缀。
+func ellipsize(s string, face text.Face, maxW float64) string {
+	if w, _ := text.Measure(s, face, 0); w <= maxW {
+		return s
+	}
+	runes := []rune(s)
+	// 找到第一个放不下的长度，前一个即为最长可用前缀
+	n := sort.Search(len(runes), func(i int) bool {
+		w, _ := text.Measure(string(runes[:i])+"…", face, 0)
+		return w > maxW
+	})
+	if n == 0 {
+		return ""
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func formatPlaybackTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
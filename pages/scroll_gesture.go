@@ -0,0 +1,250 @@
+package pages
+
+// 文件说明：主页歌词的拖动与惯性滚动。
+// 主要职责：把鼠标拖动和触摸转换为手动滚动偏移，松手后按速度惯性滑动，越过歌词首尾时阻尼回弹；
+// 没有拖动的按下松开仍视为点击，跳转到对应歌词行。
+
+import (
+	"math"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/lp"
+	"github.com/xiaowumin-mark/EbitenLyrics/lyrics"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// defaultScrollResumeDelay 是手动滚动停下后回到当前行的默认等待时间。
+	defaultScrollResumeDelay = time.Second
+	// scrollDragSlop 是按下后移动超过多少逻辑像素才算拖动，以内松开视为点击。
+	scrollDragSlop = 8.0
+	// scrollVelocityWindow 是松手时估算速度所用的最近采样时长。
+	scrollVelocityWindow = 100 * time.Millisecond
+	// scrollMaxVelocity / scrollMinVelocity 是惯性速度的上限与停止阈值（逻辑像素/秒）。
+	scrollMaxVelocity = 6000.0
+	scrollMinVelocity = 10.0
+	// 惯性衰减、越界减速与回弹的时间常数（秒）。
+	scrollFlingFriction      = 0.325
+	scrollOverscrollFriction = 0.05
+	scrollSpringTime         = 0.08
+	// scrollRubberBand 是越界拖动的阻尼系数，越小越难拖出边界。
+	scrollRubberBand = 0.55
+)
+
+type scrollSample struct {
+	at     time.Time
+	offset float64
+}
+
+// scrollDrag 是一次鼠标或触摸从按下到松开的手势，坐标为物理像素，偏移为未加阻尼的原始偏移。
+type scrollDrag struct {
+	active   bool
+	touch    bool
+	touchID  ebiten.TouchID
+	dragging bool
+
+	startX, startY float64
+	x, y           float64
+	startOffset    float64
+
+	samples  []scrollSample
+	touchIDs []ebiten.TouchID
+}
+
+// reset 结束手势，保留采样与触摸缓冲以便复用。
+func (d *scrollDrag) reset() {
+	*d = scrollDrag{samples: d.samples[:0], touchIDs: d.touchIDs[:0]}
+}
+
+func (d *scrollDrag) addSample(now time.Time, offset float64) {
+	d.samples = append(d.samples, scrollSample{at: now, offset: offset})
+	cut := 0
+	for cut < len(d.samples)-1 && now.Sub(d.samples[cut].at) > scrollVelocityWindow {
+		cut++
+	}
+	d.samples = append(d.samples[:0], d.samples[cut:]...)
+}
+
+// velocity 按窗口内首尾采样估算松手速度；停住后再松手时采样不变，速度为 0。
+func (d *scrollDrag) velocity() float64 {
+	if len(d.samples) < 2 {
+		return 0
+	}
+	first, last := d.samples[0], d.samples[len(d.samples)-1]
+	dt := last.at.Sub(first.at).Seconds()
+	if dt <= 0 {
+		return 0
+	}
+	v := (last.offset - first.offset) / dt
+	return math.Max(-scrollMaxVelocity, math.Min(scrollMaxVelocity, v))
+}
+
+// rubberBandDistance 把越界距离 x 压缩为显示距离，dim 越大越容易拖远，但永远不超过 dim。
+func rubberBandDistance(x, dim float64) float64 {
+	return (1 - 1/(x*scrollRubberBand/dim+1)) * dim
+}
+
+// rubberBand 把越过 [lo, hi] 的部分按阻尼压缩，dim 为滚动方向的可见长度。
+func rubberBand(offset, lo, hi, dim float64) float64 {
+	if dim <= 0 {
+		return math.Min(math.Max(offset, lo), hi)
+	}
+	switch {
+	case offset > hi:
+		return hi + rubberBandDistance(offset-hi, dim)
+	case offset < lo:
+		return lo - rubberBandDistance(lo-offset, dim)
+	}
+	return offset
+}
+
+// unrubberBand 是 rubberBand 的逆变换，从已越界的位置接着拖动时不会跳动。
+func unrubberBand(offset, lo, hi, dim float64) float64 {
+	raw := func(shown float64) float64 {
+		shown = math.Min(shown, dim*0.99)
+		return dim / scrollRubberBand * shown / (dim - shown)
+	}
+	if dim <= 0 {
+		return offset
+	}
+	switch {
+	case offset > hi:
+		return hi + raw(offset-hi)
+	case offset < lo:
+		return lo - raw(lo-offset)
+	}
+	return offset
+}
+
+// flowDelta 把指针位移（逻辑像素）换算为滚动偏移：横排跟随纵向拖动；竖排后续行在左侧，向右拖动露出后续行。
+func (h *Home) flowDelta(dx, dy float64) float64 {
+	if h.LyricsControl != nil && h.LyricsControl.WritingMode == lyrics.WritingVertical {
+		return -dx
+	}
+	return dy
+}
+
+// beginPress 记录新的按下；触摸优先于鼠标。按下会截停正在进行的惯性滑动，此时不算点击。
+func (h *Home) beginPress() {
+	d := &h.drag
+	d.touchIDs = inpututil.AppendJustPressedTouchIDs(d.touchIDs[:0])
+	var x, y int
+	switch {
+	case len(d.touchIDs) > 0:
+		d.touch = true
+		d.touchID = d.touchIDs[0]
+		x, y = ebiten.TouchPosition(d.touchID)
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		x, y = ebiten.CursorPosition()
+	default:
+		return
+	}
+	d.active = true
+	d.startX, d.startY = float64(x), float64(y)
+	d.x, d.y = d.startX, d.startY
+	h.pressedLine = h.lineAt(d.x, d.y)
+	if h.scrollVelocity != 0 {
+		h.scrollVelocity = 0
+		h.pressedLine = -1
+	}
+}
+
+// handleDrag 跟踪当前手势：移动超过 scrollDragSlop 后开始拖动，松手时按速度惯性滑动；
+// 没有拖动时松手点在按下的同一行上则跳转到该行。
+func (h *Home) handleDrag(now time.Time) {
+	d := &h.drag
+	if !d.active {
+		h.beginPress()
+		if !d.active {
+			return
+		}
+	}
+
+	released := false
+	if d.touch {
+		// 抬起后 TouchPosition 不再可用，沿用最后的位置
+		if inpututil.IsTouchJustReleased(d.touchID) {
+			released = true
+		} else {
+			x, y := ebiten.TouchPosition(d.touchID)
+			d.x, d.y = float64(x), float64(y)
+		}
+	} else {
+		x, y := ebiten.CursorPosition()
+		d.x, d.y = float64(x), float64(y)
+		released = !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	}
+
+	lo, hi := h.scrollBounds()
+	dim := h.scrollViewport()
+	dx, dy := lp.FromLP(d.x-d.startX), lp.FromLP(d.y-d.startY)
+	delta := h.flowDelta(dx, dy)
+	if !d.dragging && math.Hypot(dx, dy) > scrollDragSlop {
+		d.dragging = true
+		h.pressedLine = -1
+		h.beginUserScroll(now)
+		d.startOffset = unrubberBand(h.manualScrollOffset, lo, hi, dim) - delta
+	}
+	if d.dragging {
+		raw := d.startOffset + delta
+		h.manualScrollOffset = rubberBand(raw, lo, hi, dim)
+		h.manualScrollTarget = h.manualScrollOffset
+		h.manualScrollResumeAt = now.Add(h.ScrollResumeDelay)
+		d.addSample(now, raw)
+	}
+
+	if !released {
+		return
+	}
+	if d.dragging {
+		h.scrollVelocity = d.velocity()
+	} else if h.pressedLine >= 0 && h.lineAt(d.x, d.y) == h.pressedLine {
+		if start, ok := h.LyricsControl.LineStartTime(h.pressedLine); ok {
+			h.seekTo(start)
+		}
+	}
+	h.pressedLine = -1
+	d.reset()
+}
+
+// cancelDrag 放弃当前手势且不触发点击，已越界的偏移交给 stepScroll 回弹。
+func (h *Home) cancelDrag() {
+	h.pressedLine = -1
+	h.drag.reset()
+}
+
+// stepScroll 推进一帧惯性滑动、越界回弹或滚轮缓动，返回偏移是否仍在变化。
+func (h *Home) stepScroll(dt float64) bool {
+	lo, hi := h.scrollBounds()
+	offset := h.manualScrollOffset
+	edge := math.Min(math.Max(offset, lo), hi)
+	switch {
+	case h.scrollVelocity != 0:
+		offset += h.scrollVelocity * dt
+		friction := scrollFlingFriction
+		if offset < lo || offset > hi {
+			friction = scrollOverscrollFriction
+		}
+		h.scrollVelocity *= math.Exp(-dt / friction)
+		if math.Abs(h.scrollVelocity) < scrollMinVelocity {
+			h.scrollVelocity = 0
+		}
+		h.manualScrollTarget = offset
+	case offset != edge:
+		offset += (edge - offset) * (1 - math.Exp(-dt/scrollSpringTime))
+		if math.Abs(edge-offset) < 0.5 {
+			offset = edge
+		}
+		h.manualScrollTarget = offset
+	default:
+		offset += (h.manualScrollTarget - offset) * 0.23
+		if math.Abs(h.manualScrollTarget-offset) < 0.2 {
+			offset = h.manualScrollTarget
+		}
+	}
+	moving := offset != h.manualScrollOffset || h.scrollVelocity != 0
+	h.manualScrollOffset = offset
+	return moving
+}
@@ -0,0 +1,312 @@
+package palette
+
+// 文件说明：封面调色板提取。
+// 主要职责：对缩小后的封面做中位切分，给出主色、鲜艳色、柔和色及其对比度，
+// 并提供让前景色在背景上保持可读的调整函数。
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// DefaultColors 是 Extract 默认切分出的颜色数。
+	DefaultColors = 8
+	// SampleSize 是提取前缩放到的最大边长，封面细节对调色板没有意义。
+	SampleSize = 48
+	// 占比低于该值的色块不参与鲜艳色、柔和色的挑选，避免噪点被选中。
+	minSwatchPopulation = 0.02
+	// 平均色的 RGB 曼哈顿距离不超过该值时视为同一种颜色。
+	mergeDistance = 12
+)
+
+// Swatch 是调色板中的一种颜色。
+type Swatch struct {
+	Color      color.NRGBA
+	Population float64 // 在封面中的占比，0-1
+	Luminance  float64 // WCAG 相对亮度，0-1
+	Saturation float64 // HSL 饱和度，0-1
+	Lightness  float64 // HSL 亮度，0-1
+	Contrast   float64 // 与 Dominant 的 WCAG 对比度，1-21
+}
+
+// Palette 是 Extract 的结果，Swatches 按占比从高到低排列。
+type Palette struct {
+	Dominant Swatch
+	Vibrant  Swatch
+	Muted    Swatch
+	Swatches []Swatch
+}
+
+// Empty 表示图片没有可用像素（全透明或为空）。
+func (p Palette) Empty() bool {
+	return len(p.Swatches) == 0
+}
+
+type rgb [3]uint8
+
+// Extract 把 img 缩小后用中位切分得到至多 n 种颜色，n <= 0 时使用 DefaultColors。
+func Extract(img image.Image, n int) Palette {
+	if img == nil {
+		return Palette{}
+	}
+	if n <= 0 {
+		n = DefaultColors
+	}
+	b := img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return Palette{}
+	}
+	var small *image.NRGBA
+	if b.Dx() > SampleSize || b.Dy() > SampleSize {
+		small = imaging.Fit(img, SampleSize, SampleSize, imaging.Box)
+	} else {
+		small = imaging.Clone(img)
+	}
+
+	pixels := make([]rgb, 0, len(small.Pix)/4)
+	for i := 0; i+3 < len(small.Pix); i += 4 {
+		// 半透明像素的颜色不可靠，直接忽略
+		if small.Pix[i+3] < 128 {
+			continue
+		}
+		pixels = append(pixels, rgb{small.Pix[i], small.Pix[i+1], small.Pix[i+2]})
+	}
+	if len(pixels) == 0 {
+		return Palette{}
+	}
+
+	boxes := medianCut(pixels, n)
+	swatches := make([]Swatch, 0, len(boxes))
+	for _, box := range mergeSimilar(boxes) {
+		swatches = append(swatches, newSwatch(average(box), float64(len(box))/float64(len(pixels))))
+	}
+	sort.SliceStable(swatches, func(i, j int) bool {
+		return swatches[i].Population > swatches[j].Population
+	})
+
+	p := Palette{Swatches: swatches, Dominant: swatches[0]}
+	for i := range p.Swatches {
+		p.Swatches[i].Contrast = ContrastRatio(p.Swatches[i].Color, p.Dominant.Color)
+	}
+	p.Dominant = p.Swatches[0]
+	p.Vibrant = pick(p.Swatches, vibrantScore)
+	p.Muted = pick(p.Swatches, mutedScore)
+	return p
+}
+
+// medianCut 反复把“跨度×像素数”最大的盒子沿最宽的通道从中位数处切开。
+func medianCut(pixels []rgb, n int) [][]rgb {
+	boxes := [][]rgb{pixels}
+	for len(boxes) < n {
+		best, bestScore, bestChannel := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, span := widestChannel(box)
+			if score := span * len(box); span > 0 && score > bestScore {
+				best, bestScore, bestChannel = i, score, channel
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		sort.Slice(box, func(i, j int) bool {
+			return box[i][bestChannel] < box[j][bestChannel]
+		})
+		mid := splitIndex(box, bestChannel)
+		boxes[best] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+	return boxes
+}
+
+// splitIndex 返回靠近中位数、且不把相同通道值拆开的切分位置；box 已按该通道排序且跨度大于 0。
+func splitIndex(box []rgb, channel int) int {
+	mid := len(box) / 2
+	v := box[mid][channel]
+	lo, hi := mid, mid
+	for lo > 0 && box[lo-1][channel] == v {
+		lo--
+	}
+	for hi < len(box) && box[hi][channel] == v {
+		hi++
+	}
+	if lo > 0 && (hi == len(box) || mid-lo <= hi-mid) {
+		return lo
+	}
+	return hi
+}
+
+// mergeSimilar 合并平均色几乎相同的盒子：中位切分可能把同一块纯色拆到两个盒子里。
+func mergeSimilar(boxes [][]rgb) [][]rgb {
+	merged := make([][]rgb, 0, len(boxes))
+	means := make([]color.NRGBA, 0, len(boxes))
+next:
+	for _, box := range boxes {
+		if len(box) == 0 {
+			continue
+		}
+		c := average(box)
+		for i, m := range means {
+			if colorDistance(c, m) <= mergeDistance {
+				merged[i] = append(merged[i], box...)
+				means[i] = average(merged[i])
+				continue next
+			}
+		}
+		merged = append(merged, box)
+		means = append(means, c)
+	}
+	return merged
+}
+
+func colorDistance(a, b color.NRGBA) int {
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	return abs(int(a.R)-int(b.R)) + abs(int(a.G)-int(b.G)) + abs(int(a.B)-int(b.B))
+}
+
+func widestChannel(box []rgb) (int, int) {
+	lo := rgb{255, 255, 255}
+	var hi rgb
+	for _, px := range box {
+		for c := 0; c < 3; c++ {
+			lo[c] = min(lo[c], px[c])
+			hi[c] = max(hi[c], px[c])
+		}
+	}
+	channel, span := 0, -1
+	for c := 0; c < 3; c++ {
+		if s := int(hi[c]) - int(lo[c]); s > span {
+			channel, span = c, s
+		}
+	}
+	return channel, span
+}
+
+func average(box []rgb) color.NRGBA {
+	var sum [3]int
+	for _, px := range box {
+		for c := 0; c < 3; c++ {
+			sum[c] += int(px[c])
+		}
+	}
+	n := len(box)
+	return color.NRGBA{
+		R: uint8((sum[0] + n/2) / n),
+		G: uint8((sum[1] + n/2) / n),
+		B: uint8((sum[2] + n/2) / n),
+		A: 255,
+	}
+}
+
+func newSwatch(c color.NRGBA, population float64) Swatch {
+	s, l := saturationLightness(c)
+	return Swatch{
+		Color:      c,
+		Population: population,
+		Luminance:  RelativeLuminance(c),
+		Saturation: s,
+		Lightness:  l,
+	}
+}
+
+// vibrantScore 偏好高饱和、亮度居中的颜色，占比只做轻微加权。
+func vibrantScore(s Swatch) float64 {
+	return s.Saturation * (1 - math.Abs(s.Lightness-0.5)) * math.Sqrt(s.Population)
+}
+
+// mutedScore 偏好低饱和、亮度居中的颜色。
+func mutedScore(s Swatch) float64 {
+	return (1 - s.Saturation) * (1 - math.Abs(s.Lightness-0.5)) * math.Sqrt(s.Population)
+}
+
+func pick(swatches []Swatch, score func(Swatch) float64) Swatch {
+	best, bestScore := swatches[0], -1.0
+	for _, s := range swatches {
+		if s.Population < minSwatchPopulation {
+			continue
+		}
+		if v := score(s); v > bestScore {
+			best, bestScore = s, v
+		}
+	}
+	return best
+}
+
+func saturationLightness(c color.NRGBA) (float64, float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi := math.Max(r, math.Max(g, b))
+	lo := math.Min(r, math.Min(g, b))
+	l := (hi + lo) / 2
+	if hi == lo {
+		return 0, l
+	}
+	d := hi - lo
+	if l > 0.5 {
+		return d / (2 - hi - lo), l
+	}
+	return d / (hi + lo), l
+}
+
+// RelativeLuminance 按 WCAG 2 定义计算 sRGB 颜色的相对亮度。
+func RelativeLuminance(c color.NRGBA) float64 {
+	lin := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(c.R) + 0.7152*lin(c.G) + 0.0722*lin(c.B)
+}
+
+// ContrastRatio 返回两种不透明颜色的 WCAG 对比度，范围 1-21。
+func ContrastRatio(a, b color.NRGBA) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// Readable 把 fg 逐步向白色或黑色中与 bg 对比度更高的一个混合，直到对比度达到 ratio。
+// 两者在 bg 相对亮度约 0.179 处对比度相同，中间调背景因此会选黑色。
+// 返回色保留 fg 的透明度；无法达到时返回纯白或纯黑。
+func Readable(fg, bg color.NRGBA, ratio float64) color.NRGBA {
+	target := color.NRGBA{255, 255, 255, 255}
+	if black := (color.NRGBA{0, 0, 0, 255}); ContrastRatio(black, bg) > ContrastRatio(target, bg) {
+		target = black
+	}
+	opaque := fg
+	opaque.A = 255
+	for step := 0; step <= 20; step++ {
+		c := Mix(opaque, target, float64(step)/20)
+		if ContrastRatio(c, bg) >= ratio {
+			c.A = fg.A
+			return c
+		}
+	}
+	target.A = fg.A
+	return target
+}
+
+// Mix 按 t 在 a、b 之间线性混合四个通道，t 会被限制在 [0,1]。
+func Mix(a, b color.NRGBA, t float64) color.NRGBA {
+	t = math.Max(0, math.Min(1, t))
+	ch := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return color.NRGBA{ch(a.R, b.R), ch(a.G, b.G), ch(a.B, b.B), ch(a.A, b.A)}
+}
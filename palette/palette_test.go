@@ -0,0 +1,108 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// stripes 按给定颜色和宽度画竖条，用来构造占比已知的图片。
+func stripes(h int, cols []color.NRGBA, widths []int) *image.NRGBA {
+	w := 0
+	for _, v := range widths {
+		w += v
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	x := 0
+	for i, c := range cols {
+		for dx := 0; dx < widths[i]; dx++ {
+			for y := 0; y < h; y++ {
+				img.SetNRGBA(x+dx, y, c)
+			}
+		}
+		x += widths[i]
+	}
+	return img
+}
+
+func TestExtractFindsDominantVibrantAndMuted(t *testing.T) {
+	navy := color.NRGBA{20, 24, 60, 255}
+	orange := color.NRGBA{240, 120, 20, 255}
+	gray := color.NRGBA{120, 118, 115, 255}
+	img := stripes(20, []color.NRGBA{navy, orange, gray}, []int{24, 8, 8})
+
+	p := Extract(img, 4)
+	if p.Empty() {
+		t.Fatal("palette is empty")
+	}
+	if p.Dominant.Color != navy || math.Abs(p.Dominant.Population-0.6) > 1e-9 {
+		t.Fatalf("dominant = %+v", p.Dominant)
+	}
+	if p.Vibrant.Color != orange {
+		t.Fatalf("vibrant = %+v", p.Vibrant)
+	}
+	if p.Muted.Color != gray {
+		t.Fatalf("muted = %+v", p.Muted)
+	}
+	if p.Dominant.Contrast != 1 || p.Vibrant.Contrast <= 3 {
+		t.Fatalf("contrast scores dominant=%v vibrant=%v", p.Dominant.Contrast, p.Vibrant.Contrast)
+	}
+	total := 0.0
+	for i, s := range p.Swatches {
+		total += s.Population
+		if i > 0 && s.Population > p.Swatches[i-1].Population {
+			t.Fatalf("swatches not sorted by population: %+v", p.Swatches)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("populations sum to %v", total)
+	}
+}
+
+func TestExtractSkipsTransparentAndDownscales(t *testing.T) {
+	if !Extract(image.NewNRGBA(image.Rect(0, 0, 4, 4)), 0).Empty() {
+		t.Fatal("fully transparent image produced a palette")
+	}
+	big := stripes(300, []color.NRGBA{{200, 0, 0, 255}, {0, 0, 200, 255}}, []int{300, 300})
+	p := Extract(big, 0)
+	if len(p.Swatches) < 2 || len(p.Swatches) > DefaultColors {
+		t.Fatalf("swatches = %d", len(p.Swatches))
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	black := color.NRGBA{0, 0, 0, 255}
+	if got := ContrastRatio(white, black); math.Abs(got-21) > 1e-9 {
+		t.Fatalf("white/black contrast = %v", got)
+	}
+	if ContrastRatio(black, white) != ContrastRatio(white, black) {
+		t.Fatal("contrast ratio is not symmetric")
+	}
+}
+
+func TestReadableReachesRatio(t *testing.T) {
+	dark := color.NRGBA{30, 20, 40, 255}
+	fg := color.NRGBA{90, 40, 120, 200}
+	got := Readable(fg, dark, 4.5)
+	if ContrastRatio(got, dark) < 4.5 || got.A != 200 {
+		t.Fatalf("Readable on dark = %v (contrast %.2f)", got, ContrastRatio(got, dark))
+	}
+	light := color.NRGBA{240, 240, 230, 255}
+	got = Readable(color.NRGBA{250, 200, 0, 255}, light, 4.5)
+	if ContrastRatio(got, light) < 4.5 {
+		t.Fatalf("Readable on light = %v", got)
+	}
+	// 中间调背景上白色达不到 4.5，应改为向黑色混合
+	for _, mid := range []color.NRGBA{{150, 150, 150, 255}, {180, 180, 180, 255}, {120, 140, 110, 255}} {
+		got = Readable(color.NRGBA{200, 220, 255, 255}, mid, 4.5)
+		if ContrastRatio(got, mid) < 4.5 {
+			t.Fatalf("Readable on mid-tone %v = %v (contrast %.2f)", mid, got, ContrastRatio(got, mid))
+		}
+	}
+	// 已经足够清晰的颜色保持不变
+	if got := Readable(color.NRGBA{250, 250, 250, 255}, dark, 4.5); got != (color.NRGBA{250, 250, 250, 255}) {
+		t.Fatalf("readable colour was changed to %v", got)
+	}
+}
@@ -0,0 +1,193 @@
+package playback
+
+// 文件说明：本地播放时钟。
+// 主要职责：基于单调时钟外推播放位置，处理暂停、恢复、跳转与倍速，
+// 并把播放器上报的进度平滑地吸收进来，避免歌词随上报频率跳动。
+
+import (
+	"time"
+)
+
+const (
+	// DefaultTolerance 是默认的平滑修正阈值，超过该误差直接跳转。
+	DefaultTolerance = 800 * time.Millisecond
+	// DefaultCorrectionTime 是默认的平滑修正时长。
+	DefaultCorrectionTime = 400 * time.Millisecond
+)
+
+// PlaybackClock 根据最近一次锚点外推当前播放位置。
+// 不是并发安全的，应只在同一个 goroutine（通常是 Update）中使用。
+type PlaybackClock struct {
+	// Tolerance 为上报进度与本地位置的最大平滑误差，超过时直接跳转；<=0 表示总是跳转。
+	Tolerance time.Duration
+	// CorrectionTime 为吸收误差所用的最短时长。
+	CorrectionTime time.Duration
+
+	now func() time.Time
+
+	hasPosition bool
+	playing     bool
+	rate        float64
+
+	// 锚点：anchorAt 时刻的位置为 anchorPos，之后按 rate 线性前进。
+	anchorPos time.Duration
+	anchorAt  time.Time
+
+	// 平滑修正：offset 在 correctionTime 内线性衰减为 0。
+	offset         time.Duration
+	correctionAt   time.Time
+	correctionTime time.Duration
+}
+
+// NewPlaybackClock 创建一个默认处于播放状态、倍速为 1 的时钟。
+// now 为 nil 时使用 time.Now。
+func NewPlaybackClock(now func() time.Time) *PlaybackClock {
+	if now == nil {
+		now = time.Now
+	}
+	return &PlaybackClock{
+		Tolerance:      DefaultTolerance,
+		CorrectionTime: DefaultCorrectionTime,
+		now:            now,
+		playing:        true,
+		rate:           1,
+	}
+}
+
+// HasPosition 报告是否已经收到过进度或跳转。
+func (c *PlaybackClock) HasPosition() bool {
+	return c.hasPosition
+}
+
+// Playing 报告时钟是否在走。
+func (c *PlaybackClock) Playing() bool {
+	return c.playing
+}
+
+// Rate 返回当前倍速。
+func (c *PlaybackClock) Rate() float64 {
+	return c.rate
+}
+
+// Position 返回当前外推的播放位置。
+func (c *PlaybackClock) Position() time.Duration {
+	return c.positionAt(c.now())
+}
+
+func (c *PlaybackClock) positionAt(now time.Time) time.Duration {
+	if !c.hasPosition {
+		return 0
+	}
+	pos := c.anchorPos
+	if c.playing {
+		pos += scale(now.Sub(c.anchorAt), c.rate)
+	}
+	pos += c.offsetAt(now)
+	if pos < 0 {
+		return 0
+	}
+	return pos
+}
+
+func (c *PlaybackClock) offsetAt(now time.Time) time.Duration {
+	if c.offset == 0 {
+		return 0
+	}
+	elapsed := now.Sub(c.correctionAt)
+	if elapsed >= c.correctionTime || c.correctionTime <= 0 {
+		c.offset = 0
+		return 0
+	}
+	remain := 1 - float64(elapsed)/float64(c.correctionTime)
+	return time.Duration(float64(c.offset) * remain)
+}
+
+// Report 吸收播放器上报的进度。
+// 误差在 Tolerance 内时平滑修正，显示位置不会倒退；否则视为跳转。
+// 暂停状态下直接跳转到上报位置。
+func (c *PlaybackClock) Report(pos time.Duration) {
+	now := c.now()
+	if !c.hasPosition || !c.playing {
+		c.seekAt(pos, now)
+		return
+	}
+	current := c.positionAt(now)
+	diff := current - pos
+	if diff < 0 {
+		diff = -diff
+	}
+	if c.Tolerance <= 0 || diff > c.Tolerance {
+		c.seekAt(pos, now)
+		return
+	}
+
+	c.anchorPos = pos
+	c.anchorAt = now
+	c.offset = current - pos
+	c.correctionAt = now
+	c.correctionTime = c.CorrectionTime
+	if c.offset > 0 && c.rate > 0 {
+		// 本地领先时需要“放慢”，修正速度不超过半倍速，保证显示位置单调前进。
+		if minTime := scale(c.offset, 2/c.rate); minTime > c.correctionTime {
+			c.correctionTime = minTime
+		}
+	}
+}
+
+// Seek 立即跳转到 pos，不做平滑。
+func (c *PlaybackClock) Seek(pos time.Duration) {
+	c.seekAt(pos, c.now())
+}
+
+func (c *PlaybackClock) seekAt(pos time.Duration, now time.Time) {
+	if pos < 0 {
+		pos = 0
+	}
+	c.hasPosition = true
+	c.anchorPos = pos
+	c.anchorAt = now
+	c.offset = 0
+}
+
+// Pause 在当前位置停住。
+func (c *PlaybackClock) Pause() {
+	if !c.playing {
+		return
+	}
+	now := c.now()
+	c.rebase(now)
+	c.playing = false
+}
+
+// Resume 从暂停位置继续走。
+func (c *PlaybackClock) Resume() {
+	if c.playing {
+		return
+	}
+	c.anchorAt = c.now()
+	c.playing = true
+}
+
+// SetRate 设置倍速，<=0 的值会被忽略。
+func (c *PlaybackClock) SetRate(rate float64) {
+	if rate <= 0 || rate == c.rate {
+		return
+	}
+	c.rebase(c.now())
+	c.rate = rate
+}
+
+// rebase 把当前位置（含未完成的修正）固化为新锚点。
+func (c *PlaybackClock) rebase(now time.Time) {
+	if !c.hasPosition {
+		c.anchorAt = now
+		return
+	}
+	c.anchorPos = c.positionAt(now)
+	c.anchorAt = now
+	c.offset = 0
+}
+
+func scale(d time.Duration, factor float64) time.Duration {
+	return time.Duration(float64(d) * factor)
+}
@@ -0,0 +1,108 @@
+package playback
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeNow struct {
+	t time.Time
+}
+
+func (f *fakeNow) now() time.Time { return f.t }
+
+func (f *fakeNow) advance(d time.Duration) { f.t = f.t.Add(d) }
+
+func newTestClock() (*PlaybackClock, *fakeNow) {
+	fn := &fakeNow{t: time.Unix(100, 0)}
+	return NewPlaybackClock(fn.now), fn
+}
+
+func TestClockExtrapolatesBetweenReports(t *testing.T) {
+	c, fn := newTestClock()
+	if c.HasPosition() || c.Position() != 0 {
+		t.Fatalf("fresh clock should report zero")
+	}
+	c.Report(10 * time.Second)
+	fn.advance(250 * time.Millisecond)
+	if got := c.Position(); got != 10250*time.Millisecond {
+		t.Fatalf("Position() = %v, want 10.25s", got)
+	}
+
+	c.SetRate(2)
+	fn.advance(500 * time.Millisecond)
+	if got := c.Position(); got != 11250*time.Millisecond {
+		t.Fatalf("Position() at 2x = %v, want 11.25s", got)
+	}
+}
+
+func TestClockPauseResumeAndSeek(t *testing.T) {
+	c, fn := newTestClock()
+	c.Report(time.Second)
+	fn.advance(time.Second)
+	c.Pause()
+	fn.advance(5 * time.Second)
+	if got := c.Position(); got != 2*time.Second {
+		t.Fatalf("paused Position() = %v, want 2s", got)
+	}
+
+	// 暂停时上报的进度直接生效
+	c.Report(3 * time.Second)
+	if got := c.Position(); got != 3*time.Second {
+		t.Fatalf("Position() after paused report = %v, want 3s", got)
+	}
+
+	c.Resume()
+	fn.advance(time.Second)
+	if got := c.Position(); got != 4*time.Second {
+		t.Fatalf("resumed Position() = %v, want 4s", got)
+	}
+
+	c.Seek(30 * time.Second)
+	if got := c.Position(); got != 30*time.Second {
+		t.Fatalf("Position() after seek = %v, want 30s", got)
+	}
+}
+
+func TestClockSmoothsSmallDriftWithoutGoingBackwards(t *testing.T) {
+	c, fn := newTestClock()
+	c.Report(10 * time.Second)
+	fn.advance(time.Second)
+
+	// 本地为 11s，播放器报告 10.7s：误差在容差内，应平滑修正而不是倒退
+	before := c.Position()
+	c.Report(10700 * time.Millisecond)
+	if got := c.Position(); got != before {
+		t.Fatalf("Position() jumped from %v to %v on small drift", before, got)
+	}
+
+	last := before
+	for i := 0; i < 100; i++ {
+		fn.advance(10 * time.Millisecond)
+		got := c.Position()
+		if got < last {
+			t.Fatalf("Position() went backwards: %v -> %v", last, got)
+		}
+		last = got
+	}
+	// 修正结束后与播放器时间线一致
+	if want := 10700*time.Millisecond + time.Second; last != want {
+		t.Fatalf("Position() after correction = %v, want %v", last, want)
+	}
+}
+
+func TestClockSeeksOnLargeDrift(t *testing.T) {
+	c, fn := newTestClock()
+	c.Tolerance = 500 * time.Millisecond
+	c.Report(10 * time.Second)
+	fn.advance(100 * time.Millisecond)
+
+	c.Report(5 * time.Second)
+	if got := c.Position(); got != 5*time.Second {
+		t.Fatalf("Position() = %v, want hard seek to 5s", got)
+	}
+	c.Report(5600 * time.Millisecond)
+	if got := c.Position(); got != 5600*time.Millisecond {
+		t.Fatalf("Position() = %v, want hard seek forward to 5.6s", got)
+	}
+}
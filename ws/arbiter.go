@@ -0,0 +1,312 @@
+package ws
+
+// 文件说明：多播放器数据源仲裁。
+// 主要职责：跟踪每个连接的活跃状态，按策略选出唯一的活动数据源，
+// 只有活动源的消息才会进入 ws:* 主题；切换时补发新源的最新状态。
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ArbitrationPolicy 决定多个播放器同时连接时由谁驱动界面。
+type ArbitrationPolicy int
+
+const (
+	// PolicyLastActive 最近发送过状态的播放器获胜。
+	PolicyLastActive ArbitrationPolicy = iota
+	// PolicyFirstConnected 最早连接且仍在线的播放器获胜。
+	PolicyFirstConnected
+	// PolicyManual 只在调试面板手动选择时切换。
+	PolicyManual
+)
+
+var arbitrationPolicyNames = []string{"last-active", "first-connected", "manual"}
+
+func (p ArbitrationPolicy) String() string {
+	if p < 0 || int(p) >= len(arbitrationPolicyNames) {
+		return "unknown"
+	}
+	return arbitrationPolicyNames[p]
+}
+
+// ArbitrationPolicies 返回全部策略，顺序与常量值一致，便于界面展示。
+func ArbitrationPolicies() []ArbitrationPolicy {
+	return []ArbitrationPolicy{PolicyLastActive, PolicyFirstConnected, PolicyManual}
+}
+
+// SourceInfo 描述一个已连接的数据源。
+type SourceInfo struct {
+	ID          string
+	ConnectedAt time.Time
+	LastActive  time.Time
+}
+
+type sourceState struct {
+	info SourceInfo
+	seq  uint64
+	// snapshot 按 key 保存该源最近一次的状态消息，切换为活动源时补发。
+	snapshot map[string]ProtocolPayload
+	order    []string
+}
+
+// Arbiter 在多个数据源之间选出活动源。可以被多个 goroutine 同时调用。
+type Arbiter struct {
+	mu      sync.Mutex
+	policy  ArbitrationPolicy
+	sources map[string]*sourceState
+	seq     uint64
+	active  string
+	changed chan struct{}
+}
+
+// DefaultArbiter 是 Initws 使用的仲裁器，调试面板通过它查看和切换数据源。
+var DefaultArbiter = NewArbiter(PolicyLastActive)
+
+func NewArbiter(policy ArbitrationPolicy) *Arbiter {
+	return &Arbiter{
+		policy:  policy,
+		sources: make(map[string]*sourceState),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Changed 在活动源被 Select / SetPolicy / Disconnect 改变时收到通知。
+// 收到通知后应调用 Snapshot 补发新活动源的状态。
+func (a *Arbiter) Changed() <-chan struct{} {
+	return a.changed
+}
+
+func (a *Arbiter) Policy() ArbitrationPolicy {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.policy
+}
+
+func (a *Arbiter) SetPolicy(policy ArbitrationPolicy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.policy == policy {
+		return
+	}
+	a.policy = policy
+	if policy == PolicyFirstConnected {
+		a.setActiveLocked(a.pickLocked(""), true)
+	}
+}
+
+// Active 返回当前活动源 id，没有活动源时为空字符串。
+func (a *Arbiter) Active() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.active
+}
+
+// Sources 按连接顺序返回全部在线数据源。
+func (a *Arbiter) Sources() []SourceInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	states := make([]*sourceState, 0, len(a.sources))
+	for _, st := range a.sources {
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].seq < states[j].seq })
+	out := make([]SourceInfo, len(states))
+	for i, st := range states {
+		out[i] = st.info
+	}
+	return out
+}
+
+// Select 手动指定活动源，同时把策略切换为 PolicyManual。
+func (a *Arbiter) Select(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.sources[id]; !ok {
+		return false
+	}
+	a.policy = PolicyManual
+	a.setActiveLocked(id, true)
+	return true
+}
+
+// Connect 登记一个新连接。
+func (a *Arbiter) Connect(id string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ensureLocked(id, now)
+}
+
+// Disconnect 移除连接；如果它是活动源，则按策略挑选下一个。
+func (a *Arbiter) Disconnect(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.sources[id]; !ok {
+		return
+	}
+	delete(a.sources, id)
+	if a.active == id {
+		a.setActiveLocked(a.pickLocked(id), true)
+	}
+}
+
+// Route 记录 src 发来的 payload，并返回需要分发到主题的消息：
+// 非活动源返回 nil；活动源返回 payload 本身；
+// 若该消息使 src 成为活动源，则先返回它之前缓存的状态再返回 payload；
+// 与 payload 同 key 的旧状态会被本条取代，不再补发。
+func (a *Arbiter) Route(src string, payload ProtocolPayload, now time.Time) []ProtocolPayload {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := a.ensureLocked(src, now)
+	active := isActivity(payload)
+	if active {
+		st.info.LastActive = now
+	}
+
+	switched := false
+	if a.active == "" {
+		// 任何策略下，没有活动源时都由第一个发消息的源接管
+		a.setActiveLocked(src, false)
+		switched = true
+	} else if a.active != src && a.policy == PolicyLastActive && active {
+		a.setActiveLocked(src, false)
+		switched = true
+	}
+
+	var out []ProtocolPayload
+	if a.active == src {
+		if switched {
+			out = st.snapshotPayloads(snapshotKey(payload))
+		}
+		out = append(out, payload)
+	}
+	st.remember(payload)
+	return out
+}
+
+// Snapshot 返回活动源缓存的最新状态，用于切换后重新同步界面。
+func (a *Arbiter) Snapshot() []ProtocolPayload {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st, ok := a.sources[a.active]
+	if !ok {
+		return nil
+	}
+	return st.snapshotPayloads("")
+}
+
+func (a *Arbiter) ensureLocked(id string, now time.Time) *sourceState {
+	if st, ok := a.sources[id]; ok {
+		return st
+	}
+	a.seq++
+	st := &sourceState{
+		info:     SourceInfo{ID: id, ConnectedAt: now},
+		seq:      a.seq,
+		snapshot: make(map[string]ProtocolPayload),
+	}
+	a.sources[id] = st
+	if a.active == "" && a.policy == PolicyFirstConnected {
+		a.setActiveLocked(id, false)
+	}
+	return st
+}
+
+// pickLocked 按策略挑选除 exclude 之外的下一个活动源。
+func (a *Arbiter) pickLocked(exclude string) string {
+	var best *sourceState
+	for id, st := range a.sources {
+		if id == exclude {
+			continue
+		}
+		if best == nil {
+			best = st
+			continue
+		}
+		if a.policy == PolicyLastActive {
+			if st.info.LastActive.After(best.info.LastActive) {
+				best = st
+			}
+		} else if st.seq < best.seq {
+			best = st
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.info.ID
+}
+
+func (a *Arbiter) setActiveLocked(id string, notify bool) {
+	if a.active == id {
+		return
+	}
+	a.active = id
+	if notify {
+		select {
+		case a.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// isActivity 判断 payload 是否代表用户在该播放器上的操作。
+// 心跳、音频帧和进度是持续发送的，不算活跃，否则两个同时播放的播放器会来回抢占。
+func isActivity(payload ProtocolPayload) bool {
+	switch p := payload.(type) {
+	case Command:
+		return true
+	case StateValue:
+		return p.UpdateName() != "progress"
+	case V2BinaryMessage:
+		return p.Type == "SetCoverData"
+	}
+	return false
+}
+
+// snapshotKeyProgress 是进度在快照中的 key。
+const snapshotKeyProgress = "state:progress"
+
+func snapshotKey(payload ProtocolPayload) string {
+	switch p := payload.(type) {
+	case StateValue:
+		switch p.(type) {
+		case PausedUpdate, ResumedUpdate:
+			// 暂停与继续互斥，共用一个 key 只保留最后一次
+			return "state:playback"
+		case SetLyricUpdate, SetLyricFromTTMLUpdate:
+			return "state:lyric"
+		}
+		return "state:" + p.UpdateName()
+	case V2BinaryMessage:
+		if p.Type == "SetCoverData" {
+			return "cover"
+		}
+	}
+	return ""
+}
+
+func (st *sourceState) remember(payload ProtocolPayload) {
+	key := snapshotKey(payload)
+	if key == "" {
+		return
+	}
+	if _, ok := st.snapshot[key]; !ok {
+		st.order = append(st.order, key)
+	}
+	st.snapshot[key] = payload
+}
+
+// snapshotPayloads 按首次出现的顺序返回缓存的状态，跳过 key 为 skip 的一项。
+// 进度可能已过去数秒，补发会让时钟倒退，因此不补发，等该源的下一条进度。
+func (st *sourceState) snapshotPayloads(skip string) []ProtocolPayload {
+	out := make([]ProtocolPayload, 0, len(st.order)+1)
+	for _, key := range st.order {
+		if key == skip || key == snapshotKeyProgress {
+			continue
+		}
+		out = append(out, st.snapshot[key])
+	}
+	return out
+}
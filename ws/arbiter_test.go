@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+)
+
+func lyricUpdate() SetLyricUpdate {
+	return SetLyricUpdate{}
+}
+
+func progressUpdate() ProgressUpdate {
+	return ProgressUpdate{Progress: 1000}
+}
+
+func TestArbiterLastActiveSwitchesAndReplaysSnapshot(t *testing.T) {
+	a := NewArbiter(PolicyLastActive)
+	now := time.Unix(0, 0)
+	a.Connect("a", now)
+	a.Connect("b", now)
+
+	if out := a.Route("a", lyricUpdate(), now); len(out) != 1 {
+		t.Fatalf("first source should become active, got %d payloads", len(out))
+	}
+	// b 的进度只是被缓存；b 的暂停与旧歌词是活跃消息，b 立即接管，随后 a 再夺回
+	now = now.Add(time.Second)
+	if out := a.Route("b", progressUpdate(), now); out != nil {
+		t.Fatalf("progress from inactive source leaked: %v", out)
+	}
+	if got := a.Active(); got != "a" {
+		t.Fatalf("Active() = %q, want a", got)
+	}
+	a.Route("b", PausedUpdate{}, now)
+	a.Route("b", SetLyricUpdate{Lines: make([]ttml.LyricLine, 1)}, now)
+	now = now.Add(time.Second)
+	a.Route("a", lyricUpdate(), now)
+	if got := a.Active(); got != "a" {
+		t.Fatalf("Active() = %q, want a", got)
+	}
+
+	now = now.Add(time.Second)
+	out := a.Route("b", lyricUpdate(), now)
+	if got := a.Active(); got != "b" {
+		t.Fatalf("Active() = %q, want b", got)
+	}
+	// 只补发 b 缓存的暂停状态：旧进度会让时钟倒退，旧歌词被本条取代
+	if len(out) != 2 {
+		t.Fatalf("switch should replay snapshot, got %d payloads: %#v", len(out), out)
+	}
+	if _, ok := out[0].(PausedUpdate); !ok {
+		t.Fatalf("out[0] = %#v, want cached paused state", out[0])
+	}
+	if lyric, ok := out[1].(SetLyricUpdate); !ok || len(lyric.Lines) != 0 {
+		t.Fatalf("out[1] = %#v, want the new lyric", out[1])
+	}
+}
+
+func TestArbiterFirstConnectedIgnoresLaterSources(t *testing.T) {
+	a := NewArbiter(PolicyFirstConnected)
+	now := time.Unix(0, 0)
+	a.Connect("a", now)
+	a.Connect("b", now)
+
+	if out := a.Route("b", lyricUpdate(), now); out != nil {
+		t.Fatalf("later source leaked: %v", out)
+	}
+	a.Disconnect("a")
+	select {
+	case <-a.Changed():
+	default:
+		t.Fatal("Disconnect of active source did not notify")
+	}
+	if got := a.Active(); got != "b" {
+		t.Fatalf("Active() = %q, want b", got)
+	}
+	if snap := a.Snapshot(); len(snap) != 1 {
+		t.Fatalf("Snapshot() = %d payloads, want 1", len(snap))
+	}
+}
+
+func TestArbiterManualSelect(t *testing.T) {
+	a := NewArbiter(PolicyLastActive)
+	now := time.Unix(0, 0)
+	a.Route("a", lyricUpdate(), now)
+	a.Route("b", lyricUpdate(), now)
+
+	if !a.Select("a") {
+		t.Fatal("Select(a) = false")
+	}
+	if a.Policy() != PolicyManual {
+		t.Fatalf("Policy() = %v, want manual", a.Policy())
+	}
+	if out := a.Route("b", lyricUpdate(), now.Add(time.Second)); out != nil {
+		t.Fatalf("manual policy switched on activity: %v", out)
+	}
+	if a.Select("missing") {
+		t.Fatal("Select(missing) = true")
+	}
+	if sources := a.Sources(); len(sources) != 2 || sources[0].ID != "a" || sources[1].ID != "b" {
+		t.Fatalf("Sources() = %+v", sources)
+	}
+}
@@ -0,0 +1,227 @@
+package ws
+
+// 文件说明：基于频谱通量的起音（onset）检测与速度估计。
+// 主要职责：逐帧比较 FFT 幅度谱得到频谱通量，用自适应阈值挑出起音，
+// 再从起音间隔估计 BPM，输出带置信度的节拍事件。
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+const (
+	// fluxHistory 是自适应阈值参考的时间窗口。
+	fluxHistory = 1500 * time.Millisecond
+	// onsetHistory 是估计速度时保留的起音时间窗口。
+	onsetHistory = 8 * time.Second
+	// minOnsetGap 是两次起音的最小间隔，避免同一次敲击被重复触发。
+	minOnsetGap = 100 * time.Millisecond
+
+	fluxThresholdK = 1.5
+	minTempoBPM    = 70.0
+	maxTempoBPM    = 180.0
+	minTempoOnsets = 4
+)
+
+// Beat 是一次检测到的起音。
+type Beat struct {
+	// Strength 为起音强度，0-1。
+	Strength float64
+	// BPM 为当前估计的速度，尚未估计出时为 0。
+	BPM float64
+	// Confidence 表示这次起音落在速度网格上的可信度，0-1；没有速度时为 0。
+	Confidence float64
+}
+
+type fluxSample struct {
+	at   time.Duration
+	flux float64
+}
+
+// beatDetector 只在 Initws 的消息循环中使用，无需加锁。
+type beatDetector struct {
+	// clock 是按已处理样本数推算的音频时间
+	clock    time.Duration
+	prevMag  []float64
+	prevFlux float64
+	history  []fluxSample
+
+	onsets    []time.Duration
+	lastOnset time.Duration
+	hasOnset  bool
+
+	bpm        float64
+	tempoScore float64
+	tempoBins  []float64
+}
+
+// reset 在格式变化或切换数据源时清空状态。
+func (b *beatDetector) reset() {
+	*b = beatDetector{tempoBins: b.tempoBins[:0]}
+}
+
+// process 处理一帧 FFT 结果（只含 0..nyquist 的 bin），dt 为这一帧覆盖的音频时长。
+func (b *beatDetector) process(spectrum []complex128, dt time.Duration) (Beat, bool) {
+	b.clock += dt
+	now := b.clock
+
+	// 1. 频谱通量：对数幅度的正向差分
+	if len(b.prevMag) != len(spectrum) {
+		b.prevMag = make([]float64, len(spectrum))
+		for i, c := range spectrum {
+			b.prevMag[i] = math.Log1p(cmplx.Abs(c))
+		}
+		return Beat{}, false
+	}
+	var flux float64
+	for i, c := range spectrum {
+		mag := math.Log1p(cmplx.Abs(c))
+		if d := mag - b.prevMag[i]; d > 0 {
+			flux += d
+		}
+		b.prevMag[i] = mag
+	}
+	flux /= float64(len(spectrum))
+
+	// 2. 自适应阈值：最近窗口内的均值 + k 倍标准差
+	mean, std := b.fluxStats(now)
+	b.history = append(b.history, fluxSample{at: now, flux: flux})
+	rising := flux > b.prevFlux
+	b.prevFlux = flux
+
+	threshold := mean + fluxThresholdK*std
+	if len(b.history) < 8 || !rising || flux <= threshold || flux < 1e-3 {
+		return Beat{}, false
+	}
+	if b.hasOnset && now-b.lastOnset < minOnsetGap {
+		return Beat{}, false
+	}
+
+	beat := Beat{Strength: math.Min(1, (flux-mean)/(3*std+1e-9))}
+	if b.hasOnset && b.bpm > 0 {
+		beat.Confidence = b.tempoScore * b.alignment(now-b.lastOnset)
+	}
+	b.lastOnset = now
+	b.hasOnset = true
+
+	// 3. 更新速度估计
+	b.onsets = append(b.onsets, now)
+	for len(b.onsets) > 0 && now-b.onsets[0] > onsetHistory {
+		b.onsets = b.onsets[1:]
+	}
+	b.estimateTempo()
+	beat.BPM = b.bpm
+	return beat, true
+}
+
+// fluxStats 返回窗口内通量的均值与标准差，并丢弃过期样本。
+func (b *beatDetector) fluxStats(now time.Duration) (float64, float64) {
+	drop := 0
+	for drop < len(b.history) && now-b.history[drop].at > fluxHistory {
+		drop++
+	}
+	if drop > 0 {
+		b.history = append(b.history[:0], b.history[drop:]...)
+	}
+	if len(b.history) == 0 {
+		return 0, 0
+	}
+	var sum, sq float64
+	for _, s := range b.history {
+		sum += s.flux
+		sq += s.flux * s.flux
+	}
+	n := float64(len(b.history))
+	mean := sum / n
+	return mean, math.Sqrt(math.Max(0, sq/n-mean*mean))
+}
+
+// alignment 返回间隔 gap 与当前节拍周期整数倍的吻合程度，0-1。
+func (b *beatDetector) alignment(gap time.Duration) float64 {
+	period := 60 / b.bpm
+	beats := gap.Seconds() / period
+	if beats < 0.5 {
+		return 0
+	}
+	errBeats := math.Abs(beats - math.Round(beats))
+	return math.Max(0, 1-4*errBeats)
+}
+
+// estimateTempo 统计起音间隔（含隔几拍的间隔），折叠到 70-180 BPM 后取最集中的一档。
+func (b *beatDetector) estimateTempo() {
+	if len(b.onsets) < minTempoOnsets {
+		return
+	}
+	bins := int(maxTempoBPM - minTempoBPM)
+	if cap(b.tempoBins) < bins {
+		b.tempoBins = make([]float64, bins)
+	}
+	b.tempoBins = b.tempoBins[:bins]
+	for i := range b.tempoBins {
+		b.tempoBins[i] = 0
+	}
+
+	var pairs float64
+	for i := range b.onsets {
+		for j := i + 1; j < len(b.onsets) && j <= i+4; j++ {
+			interval := (b.onsets[j] - b.onsets[i]).Seconds()
+			if interval < 0.25 || interval > 2 {
+				continue
+			}
+			bpm := 60 / interval
+			for bpm < minTempoBPM {
+				bpm *= 2
+			}
+			for bpm >= maxTempoBPM {
+				bpm /= 2
+			}
+			// 三角形权重，容忍 ±2 BPM 的抖动
+			center := bpm - minTempoBPM
+			for k := int(center) - 2; k <= int(center)+2; k++ {
+				if k < 0 || k >= bins {
+					continue
+				}
+				b.tempoBins[k] += math.Max(0, 1-math.Abs(float64(k)+0.5-center)/2.5)
+			}
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return
+	}
+
+	best := 0
+	for k, v := range b.tempoBins {
+		if v > b.tempoBins[best] {
+			best = k
+		}
+	}
+	// 用相邻两档加权求更精确的中心
+	var weight, sum float64
+	for k := best - 1; k <= best+1; k++ {
+		if k < 0 || k >= bins {
+			continue
+		}
+		weight += b.tempoBins[k]
+		sum += b.tempoBins[k] * (float64(k) + 0.5)
+	}
+	b.bpm = minTempoBPM + sum/weight
+
+	// 可信度：间隔落在节拍周期整数倍附近的比例
+	period := 60 / b.bpm
+	var support float64
+	for i := range b.onsets {
+		for j := i + 1; j < len(b.onsets) && j <= i+4; j++ {
+			interval := (b.onsets[j] - b.onsets[i]).Seconds()
+			if interval < 0.25 || interval > 2 {
+				continue
+			}
+			beats := interval / period
+			if math.Round(beats) >= 1 && math.Abs(beats-math.Round(beats)) < 0.1 {
+				support++
+			}
+		}
+	}
+	b.tempoScore = support / pairs
+}
@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// clickTrack 生成 bpm 速度的噪声敲击，底下垫一层很轻的正弦，按 frame 个采样切成 i16 单声道帧。
+func clickTrack(bpm float64, seconds float64, rate, frame int) [][]byte {
+	rng := rand.New(rand.NewSource(1))
+	total := int(seconds * float64(rate))
+	period := int(60 / bpm * float64(rate))
+	clickLen := rate / 50
+	samples := make([]float64, total)
+	for i := range samples {
+		samples[i] = 0.02 * math.Sin(2*math.Pi*220*float64(i)/float64(rate))
+		if pos := i % period; pos < clickLen {
+			decay := 1 - float64(pos)/float64(clickLen)
+			samples[i] += 0.8 * decay * (rng.Float64()*2 - 1)
+		}
+	}
+
+	var frames [][]byte
+	for start := 0; start+frame <= total; start += frame {
+		data := make([]byte, frame*2)
+		for i := 0; i < frame; i++ {
+			v := math.Max(-1, math.Min(1, samples[start+i]))
+			binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(v*32767)))
+		}
+		frames = append(frames, data)
+	}
+	return frames
+}
+
+func TestBeatDetectorFindsTempoOfClickTrack(t *testing.T) {
+	a := newSpectrumAnalyzer(AudioFormat{SampleFormat: SampleFormatI16, Channels: 1, SampleRate: 48000}, 0)
+	var beats []Beat
+	for _, frame := range clickTrack(120, 10, 48000, 1024) {
+		f, ok := a.Analyze(frame)
+		if ok && f.HasBeat {
+			beats = append(beats, f.Beat)
+		}
+	}
+	// 10 秒 120 BPM 共 20 次敲击，首次敲击可能因为没有历史而漏掉
+	if len(beats) < 17 || len(beats) > 21 {
+		t.Fatalf("detected %d beats, want about 20", len(beats))
+	}
+	last := beats[len(beats)-1]
+	if math.Abs(last.BPM-120) > 3 {
+		t.Fatalf("BPM = %.1f, want about 120", last.BPM)
+	}
+	if last.Confidence < 0.5 {
+		t.Fatalf("Confidence = %.2f, want a confident beat", last.Confidence)
+	}
+}
+
+func TestBeatDetectorIgnoresSteadyTone(t *testing.T) {
+	a := newSpectrumAnalyzer(AudioFormat{SampleFormat: SampleFormatI16, Channels: 2, SampleRate: 48000}, 0)
+	data := sinePCM(SampleFormatI16, 2, 440, 48000, 1024)
+	for i := 0; i < 200; i++ {
+		if f, ok := a.Analyze(data); ok && f.HasBeat {
+			t.Fatalf("steady tone produced a beat at frame %d: %+v", i, f.Beat)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package ws
+
+// 文件说明：封面图片的读取、解码与处理。
+// 主要职责：在独立的 worker 中读取（二进制、本地路径或 data URI）并解码封面，按可配置的处理链加工，
+// 新封面到达时取消尚未完成的旧任务；原图与处理结果分别发布，供不同的消费者使用。
+// 读取大小、像素数与解码耗时都有上限，避免异常图片拖住程序。
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaowumin-mark/EbitenLyrics/evbus"
+	"github.com/xiaowumin-mark/EbitenLyrics/palette"
+)
+
+// CoverOp 是处理链中的一步，Amount 的含义由 Name 决定。
+type CoverOp struct {
+	Name   string
+	Amount float64
+}
+
+func (op CoverOp) String() string {
+	if coverOps[op.Name].noAmount {
+		return op.Name
+	}
+	return op.Name + "=" + strconv.FormatFloat(op.Amount, 'g', -1, 64)
+}
+
+// CoverChain 按顺序执行的封面处理步骤。空链表示不做处理，处理结果即原图。
+type CoverChain []CoverOp
+
+// DefaultCoverChain 是默认的处理链：高斯模糊后压暗、降低对比度并略微提高饱和度。
+var DefaultCoverChain = CoverChain{
+	{Name: "blur", Amount: 40},
+	{Name: "brightness", Amount: -30},
+	{Name: "contrast", Amount: -30},
+	{Name: "saturation", Amount: 10},
+}
+
+type coverOpFunc struct {
+	apply    func(img image.Image, amount float64) image.Image
+	noAmount bool
+}
+
+// coverOps 是可用的处理步骤。fit 把长边缩小到 Amount 像素以内，放在 blur 之前可以显著减少耗时。
+var coverOps = map[string]coverOpFunc{
+	"fit": {apply: func(img image.Image, amount float64) image.Image {
+		size := int(amount)
+		b := img.Bounds()
+		if size <= 0 || (b.Dx() <= size && b.Dy() <= size) {
+			return img
+		}
+		return imaging.Fit(img, size, size, imaging.Linear)
+	}},
+	"blur":       {apply: func(img image.Image, amount float64) image.Image { return imaging.Blur(img, amount) }},
+	"sharpen":    {apply: func(img image.Image, amount float64) image.Image { return imaging.Sharpen(img, amount) }},
+	"brightness": {apply: func(img image.Image, amount float64) image.Image { return imaging.AdjustBrightness(img, amount) }},
+	"contrast":   {apply: func(img image.Image, amount float64) image.Image { return imaging.AdjustContrast(img, amount) }},
+	"saturation": {apply: func(img image.Image, amount float64) image.Image { return imaging.AdjustSaturation(img, amount) }},
+	"gamma":      {apply: func(img image.Image, amount float64) image.Image { return imaging.AdjustGamma(img, amount) }},
+	"grayscale":  {apply: func(img image.Image, _ float64) image.Image { return imaging.Grayscale(img) }, noAmount: true},
+}
+
+// ParseCoverChain 解析 "blur=40,brightness=-30" 形式的处理链，空字符串或 "none" 表示不处理。
+func ParseCoverChain(s string) (CoverChain, error) {
+	s = strings.TrimSpace(s)
+	chain := CoverChain{}
+	if s == "" || s == "none" {
+		return chain, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		op, ok := coverOps[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cover op %q", name)
+		}
+		step := CoverOp{Name: name}
+		switch {
+		case op.noAmount && hasValue:
+			return nil, fmt.Errorf("cover op %q takes no value", name)
+		case !op.noAmount && !hasValue:
+			return nil, fmt.Errorf("cover op %q needs a value, like %s=10", name, name)
+		case hasValue:
+			amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cover op %q: %w", name, err)
+			}
+			step.Amount = amount
+		}
+		chain = append(chain, step)
+	}
+	return chain, nil
+}
+
+func (c CoverChain) String() string {
+	if len(c) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(c))
+	for i, op := range c {
+		parts[i] = op.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Apply 依次执行处理步骤，每一步之前检查 ctx，被取消时返回 ctx.Err()。
+func (c CoverChain) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	for _, op := range c {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fn, ok := coverOps[op.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cover op %q", op.Name)
+		}
+		img = fn.apply(img, op.Amount)
+	}
+	return img, ctx.Err()
+}
+
+const (
+	// DefaultCoverMaxBytes 是封面文件或数据的默认大小上限，与 HTTP 接口一致。
+	DefaultCoverMaxBytes = maxHTTPCoverBody
+	// DefaultCoverMaxPixels 是解码前按图片头检查的像素数上限。
+	DefaultCoverMaxPixels = 64 << 20
+	// DefaultCoverDecodeTimeout 是单张封面解码的默认超时。
+	DefaultCoverDecodeTimeout = 5 * time.Second
+)
+
+var errCoverRemote = errors.New("remote cover URLs are not fetched")
+
+// CoverLimits 限制封面的读取与解码，零值字段使用对应的默认值。
+type CoverLimits struct {
+	MaxBytes      int64
+	MaxPixels     int64
+	DecodeTimeout time.Duration
+}
+
+func (l CoverLimits) withDefaults() CoverLimits {
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = DefaultCoverMaxBytes
+	}
+	if l.MaxPixels <= 0 {
+		l.MaxPixels = DefaultCoverMaxPixels
+	}
+	if l.DecodeTimeout <= 0 {
+		l.DecodeTimeout = DefaultCoverDecodeTimeout
+	}
+	return l
+}
+
+// CoverPalette 是封面原图的调色板，以及处理链加工后封面（歌词实际所在背景）的平均色。
+type CoverPalette struct {
+	Palette  palette.Palette
+	Backdrop color.NRGBA
+}
+
+// coverJob 携带已在内存中的数据，或需要由 worker 读取的本地路径及发来该路径的数据源。
+type coverJob struct {
+	ctx    context.Context
+	data   []byte
+	path   string
+	source string
+}
+
+// coverSource 把 setCover 转换为封面任务的数据来源：
+// "data" 与 data: URI 直接解码为字节，file:// 与本地路径交给 worker 读取。
+func coverSource(u SetCoverUpdate, maxBytes int64) (data []byte, path string, err error) {
+	switch u.Source {
+	case "data":
+		if u.Image == nil {
+			return nil, "", errors.New("setCover data without image")
+		}
+		data, err := decodeCoverBase64(u.Image.Data, maxBytes)
+		return data, "", err
+	case "uri":
+	default:
+		return nil, "", fmt.Errorf("unknown setCover source %q", u.Source)
+	}
+
+	ref := strings.TrimSpace(u.URL)
+	lower := strings.ToLower(ref)
+	switch {
+	case ref == "":
+		return nil, "", errors.New("empty setCover url")
+	case strings.HasPrefix(lower, "data:"):
+		data, err := parseDataURI(ref, maxBytes)
+		return data, "", err
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return nil, "", errCoverRemote
+	case strings.HasPrefix(lower, "file:"):
+		parsed, err := url.Parse(ref)
+		if err != nil || parsed.Path == "" {
+			return nil, "", fmt.Errorf("invalid file url %q", ref)
+		}
+		path := parsed.Path
+		// file:///C:/x 在 Windows 上对应 C:/x
+		if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		return nil, filepath.FromSlash(path), nil
+	}
+	return nil, ref, nil
+}
+
+// parseDataURI 解析 data:[<mediatype>][;base64],<data>。
+func parseDataURI(uri string, maxBytes int64) ([]byte, error) {
+	meta, payload, ok := strings.Cut(uri[len("data:"):], ",")
+	if !ok {
+		return nil, errors.New("data uri without ','")
+	}
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		return decodeCoverBase64(payload, maxBytes)
+	}
+	if int64(len(payload)) > maxBytes*3 {
+		// 百分号编码最多把长度放大 3 倍
+		return nil, fmt.Errorf("data uri is over the %d byte limit", maxBytes)
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("data uri: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("data uri is over the %d byte limit", maxBytes)
+	}
+	return []byte(data), nil
+}
+
+// decodeCoverBase64 在解码前按长度估算大小，超过上限时直接拒绝。
+func decodeCoverBase64(s string, maxBytes int64) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if int64(base64.StdEncoding.DecodedLen(len(s))) > maxBytes+2 {
+		return nil, fmt.Errorf("cover data is over the %d byte limit", maxBytes)
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		// 兼容去掉了填充的写法
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cover data: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("cover data is over the %d byte limit", maxBytes)
+	}
+	return data, nil
+}
+
+// readCoverFile 读取本地封面文件，超过 maxBytes 时报错。
+func readCoverFile(path string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > maxBytes {
+		return nil, fmt.Errorf("%s is %d bytes, over the %d byte limit", path, info.Size(), maxBytes)
+	}
+	// 文件可能在 Stat 之后变大，读取时再限制一次
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%s is over the %d byte limit", path, maxBytes)
+	}
+	return data, nil
+}
+
+// decodeCover 先按图片头检查尺寸，再在超时限制内解码。
+// 超时后解码 goroutine 会在完成时自行退出，结果被丢弃。
+func decodeCover(ctx context.Context, data []byte, limits CoverLimits) (image.Image, error) {
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, fmt.Errorf("cover is %d bytes, over the %d byte limit", len(data), limits.MaxBytes)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > limits.MaxPixels {
+		return nil, fmt.Errorf("%s cover is %dx%d, over the %d pixel limit", format, cfg.Width, cfg.Height, limits.MaxPixels)
+	}
+
+	type result struct {
+		img image.Image
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		img, err := imaging.Decode(bytes.NewReader(data))
+		done <- result{img, err}
+	}()
+	timer := time.NewTimer(limits.DecodeTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.img, r.err
+	case <-timer.C:
+		return nil, fmt.Errorf("decoding %dx%d %s cover took longer than %v", cfg.Width, cfg.Height, format, limits.DecodeTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// coverWorker 在单独的 goroutine 中处理封面，同一时间只处理最新的一张。
+// submit 只在 Initws 的消息循环中调用。
+type coverWorker struct {
+	chain     CoverChain
+	limits    CoverLimits
+	files     CoverFiles
+	original  *evbus.Topic[image.Image]
+	processed *evbus.Topic[image.Image]
+	palettes  *evbus.Topic[CoverPalette]
+
+	pending chan coverJob
+	cancel  context.CancelFunc
+}
+
+func newCoverWorker(chain CoverChain, limits CoverLimits, files CoverFiles, original, processed *evbus.Topic[image.Image], palettes *evbus.Topic[CoverPalette]) *coverWorker {
+	if chain == nil {
+		chain = DefaultCoverChain
+	}
+	w := &coverWorker{
+		chain:     chain,
+		limits:    limits.withDefaults(),
+		files:     files,
+		original:  original,
+		processed: processed,
+		palettes:  palettes,
+		pending:   make(chan coverJob, 1),
+	}
+	go w.run()
+	return w
+}
+
+// submit 提交已在内存中的封面数据，见 submitJob。
+func (w *coverWorker) submit(data []byte) {
+	w.submitJob(coverJob{data: data})
+}
+
+// submitUpdate 提交 source 发来的 setCover 封面。远程 URL 不在这里下载，只记录来源；
+// 本地路径按 CoverFiles 检查后才读取。
+func (w *coverWorker) submitUpdate(source string, u SetCoverUpdate) {
+	data, path, err := coverSource(u, w.limits.MaxBytes)
+	if err != nil {
+		if errors.Is(err, errCoverRemote) {
+			log.Printf("INFO: 封面地址 %s 不在本地，跳过解码", u.URL)
+		} else {
+			log.Printf("WARN: 无法使用 setCover: %v", err)
+		}
+		return
+	}
+	w.submitJob(coverJob{data: data, path: path, source: source})
+}
+
+// submitJob 取消正在处理的旧封面，并替换掉尚未开始的任务。
+func (w *coverWorker) submitJob(job coverJob) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.cancel = cancel
+
+	select {
+	case <-w.pending:
+	default:
+	}
+	job.ctx = ctx
+	w.pending <- job
+}
+
+func (w *coverWorker) run() {
+	for job := range w.pending {
+		w.process(job)
+	}
+}
+
+func (w *coverWorker) process(job coverJob) {
+	if job.ctx.Err() != nil {
+		return
+	}
+	data := job.data
+	if job.path != "" {
+		path, err := w.files.allow(job.source, job.path)
+		if err != nil {
+			log.Printf("WARN: 拒绝 %s 的本地封面路径: %v", job.source, err)
+			return
+		}
+		if data, err = readCoverFile(path, w.limits.MaxBytes); err != nil {
+			log.Printf("WARN: 读取封面失败: %v", err)
+			return
+		}
+	}
+	img, err := decodeCover(job.ctx, data, w.limits)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("WARN: 封面解码失败: %v", err)
+		}
+		return
+	}
+	if job.ctx.Err() != nil {
+		return
+	}
+	w.original.Publish(img)
+	// 调色板取自原图，不受处理链里模糊、调色的影响；Extract 会先缩小图片
+	colors := CoverPalette{Palette: palette.Extract(img, palette.DefaultColors)}
+
+	processed, err := w.chain.Apply(job.ctx, img)
+	if err != nil {
+		// 被更新的封面取消时不用提示
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("WARN: 封面处理失败: %v", err)
+		}
+		return
+	}
+	// 歌词画在处理后的封面上，对比度要按它的颜色检查
+	colors.Backdrop = palette.Extract(processed, 1).Dominant.Color
+	w.palettes.Publish(colors)
+	w.processed.Publish(processed)
+}
@@ -0,0 +1,64 @@
+package ws
+
+// 文件说明：setCover 本地路径的访问控制。
+// 主要职责：服务默认监听所有网卡，WebSocket 连接发来的 file:// 与裸路径只在显式开启后才读取：
+// 本机（回环地址）的连接可以使用任意路径，其它连接只能使用允许目录下的文件。
+// MPRIS 等进程内数据源的路径本来就来自本机，总是可以读取。
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+var errCoverFileDenied = errors.New("local cover paths are not allowed from this source")
+
+// CoverFiles 控制 WebSocket 连接发来的 setCover 本地路径（file:// 与裸路径），零值不读取任何路径。
+type CoverFiles struct {
+	// Loopback 为 true 时接受回环地址的连接发来的任意路径。
+	Loopback bool
+	// Dir 非空时，该目录下的文件对所有数据源开放。
+	Dir string
+}
+
+// peerIP 返回 WebSocket 连接来源的对端地址。连接以 "ip:port" 为来源 id，
+// "http"、"mpris:*" 等不是网络地址的 id 属于进程内数据源，返回 nil。
+func peerIP(source string) net.IP {
+	host, _, err := net.SplitHostPort(source)
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// allow 检查 source 能否让程序读取 path，返回实际要读取的路径。
+func (f CoverFiles) allow(source, path string) (string, error) {
+	ip := peerIP(source)
+	if ip == nil || (f.Loopback && ip.IsLoopback()) {
+		return path, nil
+	}
+	if f.Dir == "" {
+		return "", errCoverFileDenied
+	}
+	dir, err := filepath.EvalSymlinks(f.Dir)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	// 先解析符号链接，避免目录中的链接指向目录之外；不存在的文件同样拒绝，不区分原因
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", errCoverFileDenied
+	}
+	if real, err = filepath.Abs(real); err != nil {
+		return "", errCoverFileDenied
+	}
+	rel, err := filepath.Rel(dir, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", errCoverFileDenied
+	}
+	return real, nil
+}
@@ -0,0 +1,264 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/evbus"
+)
+
+func encodeTestPNG(t *testing.T, size int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseCoverChain(t *testing.T) {
+	chain, err := ParseCoverChain(" fit=512, blur=40 ,brightness=-30,grayscale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chain.String(); got != "fit=512,blur=40,brightness=-30,grayscale" {
+		t.Fatalf("String() = %q", got)
+	}
+	if chain, err := ParseCoverChain("none"); err != nil || chain == nil || len(chain) != 0 {
+		t.Fatalf("ParseCoverChain(none) = %v, %v", chain, err)
+	}
+	if again, err := ParseCoverChain(DefaultCoverChain.String()); err != nil || again.String() != DefaultCoverChain.String() {
+		t.Fatalf("default chain does not round-trip: %v, %v", again, err)
+	}
+	for _, bad := range []string{"wobble=1", "blur", "grayscale=1", "blur=x"} {
+		if _, err := ParseCoverChain(bad); err == nil {
+			t.Errorf("ParseCoverChain(%q) accepted", bad)
+		}
+	}
+}
+
+func TestCoverChainApplyStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	if _, err := DefaultCoverChain.Apply(ctx, img); err != context.Canceled {
+		t.Fatalf("Apply() error = %v, want context.Canceled", err)
+	}
+	if out, err := (CoverChain{}).Apply(context.Background(), img); err != nil || out != image.Image(img) {
+		t.Fatalf("empty chain should return the original image")
+	}
+}
+
+func TestCoverWorkerCancelsStaleCover(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	coverOps["testblock"] = coverOpFunc{apply: func(img image.Image, _ float64) image.Image {
+		started <- struct{}{}
+		<-release
+		return img
+	}, noAmount: true}
+	t.Cleanup(func() { delete(coverOps, "testblock") })
+
+	original := evbus.NewTopic[image.Image]("test:original")
+	processed := evbus.NewTopic[image.Image]("test:processed")
+	originals := make(chan image.Image, 4)
+	results := make(chan image.Image, 4)
+	original.Subscribe(func(img image.Image) { originals <- img })
+	processed.Subscribe(func(img image.Image) { results <- img })
+	palettes := evbus.NewTopic[CoverPalette]("test:palette")
+	colors := make(chan CoverPalette, 4)
+	palettes.Subscribe(func(p CoverPalette) { colors <- p })
+
+	w := newCoverWorker(CoverChain{{Name: "testblock"}, {Name: "grayscale"}}, CoverLimits{}, CoverFiles{}, original, processed, palettes)
+	w.submit(encodeTestPNG(t, 8, color.NRGBA{R: 255, A: 255}))
+	<-started
+	// 第一张还在处理链中，新封面到达后它不应再产出结果
+	w.submit(encodeTestPNG(t, 2, color.NRGBA{B: 255, A: 255}))
+	close(release)
+
+	select {
+	case img := <-results:
+		if img.Bounds().Dx() != 2 {
+			t.Fatalf("processed cover is %v, want the newer 2x2 cover", img.Bounds())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("newer cover was never processed")
+	}
+	select {
+	case img := <-results:
+		t.Fatalf("stale cover %v was published", img.Bounds())
+	default:
+	}
+	if len(originals) != 2 {
+		t.Fatalf("originals published = %d, want 2", len(originals))
+	}
+	// 调色板只随新封面发布，且取自未经灰度处理的原图
+	if len(colors) != 1 {
+		t.Fatalf("palettes published = %d, want 1", len(colors))
+	}
+	got := <-colors
+	if got.Palette.Dominant.Color != (color.NRGBA{B: 255, A: 255}) {
+		t.Fatalf("palette dominant = %v, want the newer cover's blue", got.Palette.Dominant.Color)
+	}
+	// 背景色取自处理后的封面，这里是灰度化后的蓝色
+	if b := got.Backdrop; b.R != b.G || b.G != b.B || b.B == 255 {
+		t.Fatalf("backdrop = %v, want the grayscale cover", b)
+	}
+}
+
+func TestCoverSourceFromSetCover(t *testing.T) {
+	pngData := encodeTestPNG(t, 2, color.NRGBA{G: 255, A: 255})
+	b64 := base64.StdEncoding.EncodeToString(pngData)
+	cases := []struct {
+		name     string
+		update   SetCoverUpdate
+		wantData bool
+		wantPath string
+		wantErr  bool
+	}{
+		{"inline", SetCoverUpdate{Source: "data", Image: &CoverImage{MimeType: "image/png", Data: b64}}, true, "", false},
+		{"data uri", SetCoverUpdate{Source: "uri", URL: "data:image/png;base64," + b64}, true, "", false},
+		{"file url", SetCoverUpdate{Source: "uri", URL: "file:///tmp/a%20b.png"}, false, filepath.FromSlash("/tmp/a b.png"), false},
+		{"local path", SetCoverUpdate{Source: "uri", URL: "covers/a.png"}, false, "covers/a.png", false},
+		{"remote", SetCoverUpdate{Source: "uri", URL: "https://example.com/a.png"}, false, "", true},
+		{"bad base64", SetCoverUpdate{Source: "data", Image: &CoverImage{Data: "!!"}}, false, "", true},
+		{"data uri without comma", SetCoverUpdate{Source: "uri", URL: "data:image/png;base64"}, false, "", true},
+	}
+	for _, tc := range cases {
+		data, path, err := coverSource(tc.update, DefaultCoverMaxBytes)
+		if (err != nil) != tc.wantErr || (data != nil) != tc.wantData || path != tc.wantPath {
+			t.Errorf("%s: coverSource = %d bytes, %q, %v", tc.name, len(data), path, err)
+		}
+		if tc.wantData && !bytes.Equal(data, pngData) {
+			t.Errorf("%s: decoded data differs", tc.name)
+		}
+	}
+
+	// 超过大小上限的数据在解码 base64 之前就被拒绝
+	if _, _, err := coverSource(SetCoverUpdate{Source: "data", Image: &CoverImage{Data: b64}}, 16); err == nil {
+		t.Error("oversized inline cover accepted")
+	}
+	if data, _, err := coverSource(SetCoverUpdate{Source: "uri", URL: "data:text/plain,hi%20there"}, 64); err != nil || string(data) != "hi there" {
+		t.Errorf("percent-encoded data uri = %q, %v", data, err)
+	}
+}
+
+func TestDecodeCoverLimits(t *testing.T) {
+	data := encodeTestPNG(t, 8, color.White)
+	limits := CoverLimits{}.withDefaults()
+	if img, err := decodeCover(context.Background(), data, limits); err != nil || img.Bounds().Dx() != 8 {
+		t.Fatalf("decodeCover = %v, %v", img, err)
+	}
+
+	small := limits
+	small.MaxPixels = 16
+	if _, err := decodeCover(context.Background(), data, small); err == nil || !strings.Contains(err.Error(), "pixel limit") {
+		t.Fatalf("pixel limit not enforced: %v", err)
+	}
+	small = limits
+	small.MaxBytes = int64(len(data) - 1)
+	if _, err := decodeCover(context.Background(), data, small); err == nil {
+		t.Fatal("byte limit not enforced")
+	}
+	slow := limits
+	slow.DecodeTimeout = time.Nanosecond
+	if _, err := decodeCover(context.Background(), encodeTestPNG(t, 512, color.White), slow); err == nil || !strings.Contains(err.Error(), "longer than") {
+		t.Fatalf("decode timeout not enforced: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cover.png")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCoverFile(path, int64(len(data)-1)); err == nil {
+		t.Fatal("readCoverFile ignored the size limit")
+	}
+}
+
+func TestCoverWorkerLoadsSetCoverPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cover.png")
+	if err := os.WriteFile(path, encodeTestPNG(t, 3, color.Black), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	original := evbus.NewTopic[image.Image]("test:original")
+	processed := evbus.NewTopic[image.Image]("test:processed")
+	results := make(chan image.Image, 1)
+	processed.Subscribe(func(img image.Image) { results <- img })
+
+	w := newCoverWorker(CoverChain{}, CoverLimits{}, CoverFiles{Loopback: true}, original, processed, evbus.NewTopic[CoverPalette]("test:palette"))
+	// 局域网中的其它主机不能让程序读取本地文件
+	w.submitUpdate("192.168.1.20:50000", SetCoverUpdate{Source: "uri", URL: "file://" + filepath.ToSlash(path)})
+	select {
+	case img := <-results:
+		t.Fatalf("remote peer loaded a local cover %v", img.Bounds())
+	case <-time.After(100 * time.Millisecond):
+	}
+	w.submitUpdate("127.0.0.1:50000", SetCoverUpdate{Source: "uri", URL: "file://" + filepath.ToSlash(path)})
+	select {
+	case img := <-results:
+		if img.Bounds().Dx() != 3 {
+			t.Fatalf("cover bounds = %v", img.Bounds())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cover from setCover path was never published")
+	}
+}
+
+func TestCoverFilesAllow(t *testing.T) {
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "covers")
+	if err := os.Mkdir(allowed, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	inside := filepath.Join(allowed, "a.png")
+	outside := filepath.Join(dir, "secret.png")
+	for _, path := range []string{inside, outside} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(allowed, "link.png")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		files  CoverFiles
+		source string
+		path   string
+		want   bool
+	}{
+		{"disabled", CoverFiles{}, "127.0.0.1:1", inside, false},
+		{"loopback v4", CoverFiles{Loopback: true}, "127.0.0.1:1", outside, true},
+		{"loopback v6", CoverFiles{Loopback: true}, "[::1]:1", outside, true},
+		{"in-process source", CoverFiles{}, "mpris:vlc", outside, true},
+		{"loopback disabled", CoverFiles{Dir: allowed}, "127.0.0.1:1", outside, false},
+		{"remote peer", CoverFiles{Loopback: true}, "192.168.1.20:1", outside, false},
+		{"remote peer in dir", CoverFiles{Dir: allowed}, "192.168.1.20:1", inside, true},
+		{"remote peer outside dir", CoverFiles{Dir: allowed}, "192.168.1.20:1", outside, false},
+		{"symlink out of dir", CoverFiles{Dir: allowed}, "192.168.1.20:1", link, false},
+		{"dot-dot out of dir", CoverFiles{Dir: allowed}, "192.168.1.20:1", filepath.Join(allowed, "..", "secret.png"), false},
+		{"missing file", CoverFiles{Dir: allowed}, "192.168.1.20:1", filepath.Join(allowed, "none.png"), false},
+	}
+	for _, tc := range cases {
+		if _, err := tc.files.allow(tc.source, tc.path); (err == nil) != tc.want {
+			t.Errorf("%s: allow = %v, want allowed=%v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package ws
+
+// 文件说明：与 WebSocket 共用端口的 HTTP 控制接口。
+// 主要职责：为脚本和集成测试提供 /status、/lyrics、/progress、/cover、/font，
+// 请求会被转换为与 V2 消息等价的 payload 投递到同一个消息通道，分发时不参与数据源仲裁。
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+)
+
+// HTTPSourceID 是 HTTP 控制接口投递消息时使用的来源 id，这类消息不经过仲裁器。
+const HTTPSourceID = "http"
+
+const (
+	maxHTTPTextBody  = 8 << 20
+	maxHTTPCoverBody = 32 << 20
+)
+
+type httpAPI struct {
+	msgChan MessageChannel
+	status  *statusTracker
+	arbiter *Arbiter
+}
+
+// registerHTTPHandlers 在 mux 上注册控制接口。WebSocket 仍然使用 "/"。
+func registerHTTPHandlers(mux *http.ServeMux, msgChan MessageChannel, status *statusTracker, arbiter *Arbiter) {
+	api := &httpAPI{msgChan: msgChan, status: status, arbiter: arbiter}
+	mux.HandleFunc("GET /status", api.handleStatus)
+	mux.HandleFunc("POST /lyrics", api.handleLyrics)
+	mux.HandleFunc("POST /progress", api.handleProgress)
+	mux.HandleFunc("POST /cover", api.handleCover)
+	mux.HandleFunc("POST /font", api.handleFont)
+}
+
+type statusResponse struct {
+	Listening    bool                `json:"listening"`
+	Connections  int                 `json:"connections"`
+	ActiveSource string              `json:"activeSource"`
+	Sources      []string            `json:"sources"`
+	Music        *MusicInfo          `json:"music,omitempty"`
+	Metadata     []ttml.TTMLMetadata `json:"metadata,omitempty"`
+	ProgressMs   int64               `json:"progressMs"`
+	ActiveLines  []ActiveLine        `json:"activeLines"`
+}
+
+func (a *httpAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
+	listening, connections := StatusSnapshot()
+	resp := statusResponse{
+		Listening:   listening,
+		Connections: connections,
+		Sources:     []string{},
+	}
+	if a.arbiter != nil {
+		resp.ActiveSource = a.arbiter.Active()
+		for _, src := range a.arbiter.Sources() {
+			resp.Sources = append(resp.Sources, src.ID)
+		}
+	}
+	if a.status != nil {
+		music, progress, lines := a.status.snapshot()
+		resp.Music = music
+		resp.ProgressMs = progress.Milliseconds()
+		resp.ActiveLines = lines
+		resp.Metadata = a.status.lyricMetadata()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleLyrics 接受 TTML 文档，或与 setLyric 相同的 JSON（行数组或 {"lines": [...]}）。
+func (a *httpAPI) handleLyrics(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r, maxHTTPTextBody)
+	if !ok {
+		return
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("empty body"))
+		return
+	}
+
+	var lines []ttml.LyricLine
+	switch {
+	case trimmed[0] == '<' || strings.Contains(r.Header.Get("Content-Type"), "xml"):
+		parsed, err := ttml.ParseTTML(string(trimmed))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parse ttml: %w", err))
+			return
+		}
+		// 与 setLyricFromTTML 一致，保留元数据
+		a.post(w, r, SetLyricFromTTMLUpdate{LyricContent: LyricContent{Ttml: string(trimmed)}, Lyric: parsed})
+		return
+	case trimmed[0] == '[':
+		parsed, err := decodeLyricLines("setLyric", trimmed)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		lines = parsed
+	case trimmed[0] == '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &obj); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		value, err := decodeStateValue("setLyric", obj)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		lines = value.(SetLyricUpdate).Lines
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("expected TTML or JSON lyric lines"))
+		return
+	}
+
+	a.post(w, r, SetLyricUpdate{Lines: lines})
+}
+
+// handleProgress 接受 {"progress": 毫秒} 或纯文本毫秒数。
+func (a *httpAPI) handleProgress(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r, maxHTTPTextBody)
+	if !ok {
+		return
+	}
+	trimmed := bytes.TrimSpace(body)
+	var progress float64
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var req struct {
+			Progress *float64 `json:"progress"`
+		}
+		if err := json.Unmarshal(trimmed, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Progress == nil {
+			writeError(w, http.StatusBadRequest, errors.New("missing field progress"))
+			return
+		}
+		progress = *req.Progress
+	} else {
+		v, err := strconv.ParseFloat(string(trimmed), 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("progress: %w", err))
+			return
+		}
+		progress = v
+	}
+	if progress < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("progress must not be negative"))
+		return
+	}
+	a.post(w, r, ProgressUpdate{Progress: int64(roundMs(progress))})
+}
+
+// handleCover 接受图片二进制，等价于 V2 的 SetCoverData。
+func (a *httpAPI) handleCover(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r, maxHTTPCoverBody)
+	if !ok {
+		return
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(body)); err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("decode image: %w", err))
+		return
+	}
+	a.post(w, r, V2BinaryMessage{Type: "SetCoverData", Data: body})
+}
+
+// handleFont 接受与 setFontConfig 相同的字典。
+func (a *httpAPI) handleFont(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r, maxHTTPTextBody)
+	if !ok {
+		return
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if cfg == nil {
+		writeError(w, http.StatusBadRequest, errors.New("expected a JSON object"))
+		return
+	}
+	cfg["update"] = "setFontConfig"
+	a.post(w, r, StateUpdate{Update: "setFontConfig", Data: cfg})
+}
+
+func (a *httpAPI) post(w http.ResponseWriter, r *http.Request, payload ProtocolPayload) {
+	select {
+	case a.msgChan <- Envelope{Source: HTTPSourceID, Payload: payload}:
+		w.WriteHeader(http.StatusAccepted)
+	case <-r.Context().Done():
+		writeError(w, http.StatusServiceUnavailable, r.Context().Err())
+	}
+}
+
+// routeEnvelope 返回 msg 中需要分发到主题的消息。HTTP 控制是显式操作，绕过仲裁直接分发：
+// 既不会被活动播放器挡掉（接口已回复 202），也不会让从不断开的 "http" 抢占活动源。
+func routeEnvelope(arbiter *Arbiter, msg Envelope, now time.Time) []ProtocolPayload {
+	if msg.Source == HTTPSourceID {
+		return []ProtocolPayload{msg.Payload}
+	}
+	return arbiter.Route(msg.Source, msg.Payload, now)
+}
+
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+		} else {
+			writeError(w, http.StatusBadRequest, err)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,165 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+)
+
+const testTTML = `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:amll="http://www.example.com/ns/amll">
+<head><metadata><amll:meta key="musicName" value="Test Song"/></metadata></head><body><div>
+<p begin="00:01.000" end="00:03.000"><span begin="00:01.000" end="00:02.000">Hello </span><span begin="00:02.000" end="00:03.000">world</span></p>
+</div></body></tt>`
+
+func newTestAPI(t *testing.T) (*httptest.Server, MessageChannel, *statusTracker) {
+	t.Helper()
+	ch := make(MessageChannel, 4)
+	status := newStatusTracker()
+	mux := http.NewServeMux()
+	registerHTTPHandlers(mux, ch, status, NewArbiter(PolicyLastActive))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, ch, status
+}
+
+func postBody(t *testing.T, url, contentType string, body []byte) int {
+	t.Helper()
+	resp, err := http.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHTTPProgressPublishesStateUpdate(t *testing.T) {
+	srv, ch, _ := newTestAPI(t)
+	if code := postBody(t, srv.URL+"/progress", "application/json", []byte(`{"progress": 1500}`)); code != http.StatusAccepted {
+		t.Fatalf("status = %d", code)
+	}
+	msg := <-ch
+	u, ok := msg.Payload.(ProgressUpdate)
+	if msg.Source != HTTPSourceID || !ok || u.Progress != 1500 {
+		t.Fatalf("unexpected message %#v", msg)
+	}
+
+	// 小数毫秒四舍五入，与 WebSocket 的 progress 一致
+	if code := postBody(t, srv.URL+"/progress", "text/plain", []byte("1499.6")); code != http.StatusAccepted {
+		t.Fatalf("status = %d", code)
+	}
+	if u, ok := (<-ch).Payload.(ProgressUpdate); !ok || u.Progress != 1500 {
+		t.Fatalf("fractional progress = %#v", u)
+	}
+
+	if code := postBody(t, srv.URL+"/progress", "text/plain", []byte("abc")); code != http.StatusBadRequest {
+		t.Fatalf("bad progress status = %d", code)
+	}
+}
+
+func TestHTTPLyricsAcceptsTTML(t *testing.T) {
+	srv, ch, _ := newTestAPI(t)
+	if code := postBody(t, srv.URL+"/lyrics", "application/ttml+xml", []byte(testTTML)); code != http.StatusAccepted {
+		t.Fatalf("status = %d", code)
+	}
+	u := (<-ch).Payload.(SetLyricFromTTMLUpdate)
+	if len(u.Lyric.Metadata) != 1 || u.Lyric.Metadata[0].Key != "musicName" {
+		t.Fatalf("metadata not kept: %+v", u.Lyric.Metadata)
+	}
+	lines := u.Lyric.LyricLines
+	if len(lines) != 1 || len(lines[0].Words) != 2 || lines[0].StartTime != 1000 {
+		t.Fatalf("unexpected lines %+v", lines)
+	}
+
+	if code := postBody(t, srv.URL+"/lyrics", "text/plain", []byte("not lyrics")); code != http.StatusUnsupportedMediaType {
+		t.Fatalf("bad lyrics status = %d", code)
+	}
+	if code := postBody(t, srv.URL+"/lyrics", "application/json", []byte(`[{"startTime": "soon"}]`)); code != http.StatusBadRequest {
+		t.Fatalf("malformed lines status = %d", code)
+	}
+}
+
+func TestHTTPCoverRejectsNonImage(t *testing.T) {
+	srv, ch, _ := newTestAPI(t)
+	if code := postBody(t, srv.URL+"/cover", "image/png", []byte("nope")); code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d", code)
+	}
+
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.White)
+	png.Encode(&buf, img)
+	if code := postBody(t, srv.URL+"/cover", "image/png", buf.Bytes()); code != http.StatusAccepted {
+		t.Fatalf("status = %d", code)
+	}
+	if m, ok := (<-ch).Payload.(V2BinaryMessage); !ok || m.Type != "SetCoverData" {
+		t.Fatalf("unexpected payload %#v", m)
+	}
+}
+
+func TestHTTPStatusReportsActiveLine(t *testing.T) {
+	srv, _, status := newTestAPI(t)
+	status.lines = []ttml.LyricLine{
+		{StartTime: 0, EndTime: 1000, Words: []ttml.LyricWord{{Word: "first"}}},
+		{StartTime: 1000, EndTime: 2000, Words: []ttml.LyricWord{{Word: "second"}}},
+	}
+	status.progress = 1500 * time.Millisecond
+
+	resp, err := http.Get(srv.URL + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got statusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ProgressMs != 1500 || len(got.ActiveLines) != 1 || got.ActiveLines[0].Text != "second" {
+		t.Fatalf("unexpected status %+v", got)
+	}
+}
+
+func TestHTTPControlBypassesArbitration(t *testing.T) {
+	srv, ch, _ := newTestAPI(t)
+	arbiter := NewArbiter(PolicyLastActive)
+	const player = "127.0.0.1:5000"
+	arbiter.Connect(player, time.Now())
+	if out := routeEnvelope(arbiter, Envelope{Source: player, Payload: ProgressUpdate{Progress: 100}}, time.Now()); len(out) != 1 {
+		t.Fatalf("player progress routed %d payloads", len(out))
+	}
+
+	// 播放器活动时 HTTP 进度仍然生效
+	if code := postBody(t, srv.URL+"/progress", "text/plain", []byte("2500")); code != http.StatusAccepted {
+		t.Fatalf("progress status = %d", code)
+	}
+	out := routeEnvelope(arbiter, <-ch, time.Now())
+	if len(out) != 1 || out[0].(ProgressUpdate).Progress != 2500 {
+		t.Fatalf("http progress routed %#v", out)
+	}
+
+	// HTTP 推送歌词不会抢占活动源，播放器的进度继续生效
+	if code := postBody(t, srv.URL+"/lyrics", "application/xml", []byte(testTTML)); code != http.StatusAccepted {
+		t.Fatalf("lyrics status = %d", code)
+	}
+	if out := routeEnvelope(arbiter, <-ch, time.Now()); len(out) != 1 {
+		t.Fatalf("http lyrics routed %d payloads", len(out))
+	}
+	if got := arbiter.Active(); got != player {
+		t.Fatalf("active source = %q, want %q", got, player)
+	}
+	if out := routeEnvelope(arbiter, Envelope{Source: player, Payload: ProgressUpdate{Progress: 200}}, time.Now()); len(out) != 1 {
+		t.Fatalf("player progress after http lyrics routed %d payloads", len(out))
+	}
+	for _, src := range arbiter.Sources() {
+		if src.ID == HTTPSourceID {
+			t.Fatal("http should not be registered as a source")
+		}
+	}
+}
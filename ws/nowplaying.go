@@ -0,0 +1,88 @@
+package ws
+
+// 文件说明：当前播放歌曲的数据模型。
+// 主要职责：把 setMusic、setCover 与二进制封面合并为 NowPlaying，并在变化时发布。
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CoverRef 描述当前封面的来源，不持有图片数据本身。
+type CoverRef struct {
+	// Source 为 "uri"（URL 有效）、"data"（内联 base64）或 "binary"（SetCoverData 二进制帧）。
+	Source   string
+	URL      string
+	MimeType string
+}
+
+// NowPlaying 是当前播放歌曲的完整信息。
+type NowPlaying struct {
+	ID       string
+	Name     string
+	Artists  []Artist
+	AlbumID  string
+	Album    string
+	Duration time.Duration
+	Cover    CoverRef
+}
+
+// ArtistNames 以 " / " 连接全部艺术家名。
+func (n NowPlaying) ArtistNames() string {
+	names := make([]string, 0, len(n.Artists))
+	for _, artist := range n.Artists {
+		if name := strings.TrimSpace(artist.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " / ")
+}
+
+// SameSong 判断两次信息是否属于同一首歌。播放器没有给出 id 时退回比较歌名。
+func (n NowPlaying) SameSong(other NowPlaying) bool {
+	if n.ID != "" || other.ID != "" {
+		return n.ID == other.ID
+	}
+	return n.Name == other.Name && n.Album == other.Album
+}
+
+func nowPlayingFromMusic(m MusicInfo) NowPlaying {
+	return NowPlaying{
+		ID:       m.MusicId,
+		Name:     m.MusicName,
+		Artists:  append([]Artist(nil), m.Artists...),
+		AlbumID:  m.AlbumId,
+		Album:    m.AlbumName,
+		Duration: time.Duration(m.Duration) * time.Millisecond,
+	}
+}
+
+// nowPlayingState 只在 Initws 的消息循环中使用，无需加锁。
+type nowPlayingState struct {
+	current NowPlaying
+}
+
+func (s *nowPlayingState) setMusic(m MusicInfo) NowPlaying {
+	next := nowPlayingFromMusic(m)
+	if next.SameSong(s.current) {
+		// 同一首歌重复上报时保留已知封面
+		next.Cover = s.current.Cover
+	}
+	s.current = next
+	return s.current
+}
+
+func (s *nowPlayingState) setCover(u SetCoverUpdate) NowPlaying {
+	ref := CoverRef{Source: u.Source, URL: u.URL}
+	if u.Image != nil {
+		ref.MimeType = u.Image.MimeType
+	}
+	s.current.Cover = ref
+	return s.current
+}
+
+func (s *nowPlayingState) setCoverData(data []byte) NowPlaying {
+	s.current.Cover = CoverRef{Source: "binary", MimeType: http.DetectContentType(data)}
+	return s.current
+}
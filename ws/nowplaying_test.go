@@ -0,0 +1,38 @@
+package ws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNowPlayingStateMergesMusicAndCover(t *testing.T) {
+	var s nowPlayingState
+	info := s.setMusic(MusicInfo{
+		MusicId:   "1",
+		MusicName: "Song",
+		AlbumName: "Album",
+		Artists:   []Artist{{Name: "A"}, {Name: " "}, {Name: "B"}},
+		Duration:  185000,
+	})
+	if info.Duration != 185*time.Second || info.ArtistNames() != "A / B" {
+		t.Fatalf("unexpected now playing %+v", info)
+	}
+
+	info = s.setCover(SetCoverUpdate{Source: "uri", URL: "https://example.com/a.jpg"})
+	if info.Cover.Source != "uri" || info.Cover.URL != "https://example.com/a.jpg" {
+		t.Fatalf("cover not recorded: %+v", info.Cover)
+	}
+
+	// 同一首歌重复上报保留封面，切歌后清空
+	if info = s.setMusic(MusicInfo{MusicId: "1", MusicName: "Song"}); info.Cover.URL == "" {
+		t.Fatal("cover dropped for the same song")
+	}
+	if info = s.setMusic(MusicInfo{MusicId: "2", MusicName: "Next"}); info.Cover != (CoverRef{}) {
+		t.Fatalf("cover kept across songs: %+v", info.Cover)
+	}
+
+	info = s.setCoverData([]byte("\x89PNG\r\n\x1a\n"))
+	if info.Cover.Source != "binary" || info.Cover.MimeType != "image/png" {
+		t.Fatalf("unexpected binary cover %+v", info.Cover)
+	}
+}
@@ -1,24 +1,17 @@
 package ws
 
-// 文件说明：把 WebSocket 收到的通用数据结构转换为歌词业务结构。
-// 主要职责：校验字段并组装 `ttml.LyricLine` 结果。
+// 文件说明：把 WebSocket 收到的歌词行整理为业务结构。
+// 主要职责：把协议中平铺的背景行并入前一主行的 `BGs`。
 
 import (
 	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
-
-	"github.com/go-viper/mapstructure/v2"
 )
 
-func ParseLyricsFromMap(lyrics []interface{}) ([]ttml.LyricLine, error) {
-	// 预估容量，避免多次内存分配
-	lyricLines := make([]ttml.LyricLine, 0, len(lyrics))
-	err := mapstructure.Decode(lyrics, &lyricLines)
-	if err != nil {
-		return nil, err
-	}
-
+// MergeBGLines 把紧跟在主行后的背景行并入主行的 BGs，孤立的背景行会被丢弃。
+// 主行自带的 BGs 会保留，因此对已合并的歌词再次调用是安全的。
+func MergeBGLines(lyricLines []ttml.LyricLine) []ttml.LyricLine {
 	// 预分配结果切片容量
-	merged := make([]ttml.LyricLine, 0, len(lyricLines)/2)
+	merged := make([]ttml.LyricLine, 0, len(lyricLines))
 
 	for i := 0; i < len(lyricLines); {
 		line := &lyricLines[i] // 使用指针避免拷贝
@@ -30,29 +23,21 @@ func ParseLyricsFromMap(lyrics []interface{}) ([]ttml.LyricLine, error) {
 		}
 
 		// 收集后面连续的 BG 行
-		var bgs []ttml.LyricLine
 		j := i + 1
-
-		// 计算连续BG行数量并预分配容量
-		bgCount := 0
-		for k := j; k < len(lyricLines) && lyricLines[k].IsBG; k++ {
-			bgCount++
-		}
-
-		if bgCount > 0 {
-			bgs = make([]ttml.LyricLine, 0, bgCount)
-			for j < len(lyricLines) && lyricLines[j].IsBG {
-				bgs = append(bgs, lyricLines[j])
-				j++
-			}
+		for j < len(lyricLines) && lyricLines[j].IsBG {
+			j++
 		}
 
 		// 创建新行并设置BGs
 		newLine := *line // 拷贝主行
-		newLine.BGs = bgs
+		if j > i+1 {
+			bgs := make([]ttml.LyricLine, 0, len(newLine.BGs)+j-i-1)
+			bgs = append(bgs, newLine.BGs...)
+			newLine.BGs = append(bgs, lyricLines[i+1:j]...)
+		}
 		merged = append(merged, newLine)
 		i = j
 	}
 
-	return merged, nil
+	return merged
 }
@@ -0,0 +1,289 @@
+package ws
+
+// 文件说明：OnAudioData 的 PCM 格式声明与解码。
+// 主要职责：按播放器在 initialize 中声明或配置给出的格式解码音频帧；
+// 没有声明时才根据数据推测格式，并在推测稳定后锁定，避免逐帧来回切换。
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SampleFormat 是 PCM 采样的编码方式，均为小端。
+type SampleFormat string
+
+const (
+	// SampleFormatUnknown 表示未声明，由解码器按数据推测。
+	SampleFormatUnknown SampleFormat = ""
+	SampleFormatI16     SampleFormat = "i16"
+	SampleFormatU16     SampleFormat = "u16"
+	SampleFormatF32     SampleFormat = "f32"
+	SampleFormatI64     SampleFormat = "i64"
+)
+
+// ParseSampleFormat 解析采样格式名称，兼容常见的别名（如 s16le、float32）。
+func ParseSampleFormat(name string) (SampleFormat, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	n = strings.TrimSuffix(n, "le")
+	switch n {
+	case "":
+		return SampleFormatUnknown, nil
+	case "i16", "s16", "int16":
+		return SampleFormatI16, nil
+	case "u16", "uint16":
+		return SampleFormatU16, nil
+	case "f32", "float32", "float":
+		return SampleFormatF32, nil
+	case "i64", "s64", "int64":
+		return SampleFormatI64, nil
+	}
+	return SampleFormatUnknown, fmt.Errorf("unknown sample format %q", name)
+}
+
+// Size 返回单个采样的字节数，未知格式返回 0。
+func (f SampleFormat) Size() int {
+	switch f {
+	case SampleFormatI16, SampleFormatU16:
+		return 2
+	case SampleFormatF32:
+		return 4
+	case SampleFormatI64:
+		return 8
+	}
+	return 0
+}
+
+// AudioFormat 描述 OnAudioData 的 PCM 格式，零值字段表示未知。
+// 播放器可以在 initialize 消息的 value.audioFormat 中声明它。
+type AudioFormat struct {
+	SampleFormat SampleFormat `json:"sampleFormat,omitempty"`
+	// Channels 为交错的声道数。
+	Channels   int `json:"channels,omitempty"`
+	SampleRate int `json:"sampleRate,omitempty"`
+}
+
+// Merge 用 over 中非零的字段覆盖 f，用于让播放器的声明优先于配置。
+func (f AudioFormat) Merge(over AudioFormat) AudioFormat {
+	if over.SampleFormat != SampleFormatUnknown {
+		f.SampleFormat = over.SampleFormat
+	}
+	if over.Channels > 0 {
+		f.Channels = over.Channels
+	}
+	if over.SampleRate > 0 {
+		f.SampleRate = over.SampleRate
+	}
+	return f
+}
+
+func (f AudioFormat) String() string {
+	sample := string(f.SampleFormat)
+	if sample == "" {
+		sample = "auto"
+	}
+	return fmt.Sprintf("%s/%dch/%dHz", sample, f.Channels, f.SampleRate)
+}
+
+// parseInitializeAudioFormat 读取 initialize 消息 value 中可选的 audioFormat 声明。
+func parseInitializeAudioFormat(value []byte) (AudioFormat, error) {
+	var init struct {
+		AudioFormat *struct {
+			SampleFormat string `json:"sampleFormat"`
+			Channels     int    `json:"channels"`
+			SampleRate   int    `json:"sampleRate"`
+		} `json:"audioFormat"`
+	}
+	if len(value) == 0 || string(value) == "null" {
+		return AudioFormat{}, nil
+	}
+	if err := json.Unmarshal(value, &init); err != nil || init.AudioFormat == nil {
+		// initialize 的其余内容不归这里管，解析不了就当没有声明
+		return AudioFormat{}, nil
+	}
+	sample, err := ParseSampleFormat(init.AudioFormat.SampleFormat)
+	if err != nil {
+		return AudioFormat{}, &FieldError{Update: "initialize", Field: "audioFormat.sampleFormat", Err: err}
+	}
+	if init.AudioFormat.Channels < 0 || init.AudioFormat.SampleRate < 0 {
+		return AudioFormat{}, &FieldError{Update: "initialize", Field: "audioFormat", Err: fmt.Errorf("channels and sampleRate must not be negative")}
+	}
+	return AudioFormat{
+		SampleFormat: sample,
+		Channels:     init.AudioFormat.Channels,
+		SampleRate:   init.AudioFormat.SampleRate,
+	}, nil
+}
+
+const (
+	minPCMFrames = 64
+	// guessLockStreak 是推测格式连续一致多少帧后锁定。
+	guessLockStreak = 4
+)
+
+// pcmDecoder 把 PCM 帧混合为单声道。只在 Initws 的消息循环中使用。
+type pcmDecoder struct {
+	declared AudioFormat
+
+	// 没有声明采样格式时的推测状态
+	candidate AudioFormat
+	streak    int
+	locked    bool
+}
+
+// setFormat 更换声明的格式并清空推测状态。
+func (d *pcmDecoder) setFormat(format AudioFormat) {
+	*d = pcmDecoder{declared: format}
+}
+
+// decode 解码 data 并追加到 dst，返回实际使用的格式。
+func (d *pcmDecoder) decode(data []byte, dst []float64) ([]float64, AudioFormat, bool) {
+	format := d.declared
+	if format.SampleFormat == SampleFormatUnknown {
+		if d.locked {
+			format = d.candidate
+		} else {
+			guess, ok := guessPCMFormat(data, d.declared.Channels)
+			if !ok {
+				return dst, AudioFormat{}, false
+			}
+			format = d.declared.Merge(guess)
+			switch {
+			case isSilentPCM(data):
+				// 全零数据在任何格式下都一样，不参与锁定
+			case format == d.candidate:
+				d.streak++
+			default:
+				d.candidate, d.streak = format, 1
+			}
+			d.locked = d.streak >= guessLockStreak
+		}
+	} else if format.Channels <= 0 {
+		// 声明了采样格式但没有声道数时按立体声处理
+		format.Channels = 2
+	}
+
+	out, ok := decodePCMSamples(data, format.SampleFormat, format.Channels, dst)
+	if !ok && d.locked {
+		// 锁定的格式解不了这一帧（比如长度不再对齐），重新推测
+		d.locked, d.streak = false, 0
+	}
+	return out, format, ok
+}
+
+// decodePCMSamples 按明确的格式解码交错 PCM，把各声道平均后追加到 dst。
+func decodePCMSamples(data []byte, sample SampleFormat, channels int, dst []float64) ([]float64, bool) {
+	size := sample.Size()
+	if size == 0 || channels <= 0 {
+		return dst, false
+	}
+	frameSize := size * channels
+	if len(data)%frameSize != 0 || len(data)/frameSize < minPCMFrames {
+		return dst, false
+	}
+	inv := 1.0 / float64(channels)
+	for base := 0; base < len(data); base += frameSize {
+		var sum float64
+		for c := 0; c < channels; c++ {
+			sum += decodePCMSample(data[base+c*size:], sample)
+		}
+		dst = append(dst, sum*inv)
+	}
+	return dst, true
+}
+
+// decodePCMSample 把一个采样归一化到约 -1..1。
+func decodePCMSample(b []byte, sample SampleFormat) float64 {
+	switch sample {
+	case SampleFormatI16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+	case SampleFormatU16:
+		return (float64(binary.LittleEndian.Uint16(b)) - 32768.0) / 32768.0
+	case SampleFormatF32:
+		v := float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0
+		}
+		return v
+	case SampleFormatI64:
+		return float64(int64(binary.LittleEndian.Uint64(b))) / 9223372036854775807.0
+	}
+	return 0
+}
+
+// guessPCMFormat 是没有格式声明时的兜底推测，channels 为 0 时一并推测声道数。
+// 先判断是否像 float32，再按 16 位整数的幅度分布区分有符号与无符号。
+func guessPCMFormat(data []byte, channels int) (AudioFormat, bool) {
+	if channels <= 0 {
+		switch {
+		case len(data)%4 == 0 && len(data) >= 4*minPCMFrames:
+			channels = 2
+		case len(data)%2 == 0 && len(data) >= 2*minPCMFrames:
+			channels = 1
+		default:
+			return AudioFormat{}, false
+		}
+	}
+	if len(data)%(4*channels) == 0 && likelyFloat32PCM(data) {
+		return AudioFormat{SampleFormat: SampleFormatF32, Channels: channels}, true
+	}
+	if len(data)%(2*channels) != 0 {
+		return AudioFormat{}, false
+	}
+	if likelySignedPCM16(data) {
+		return AudioFormat{SampleFormat: SampleFormatI16, Channels: channels}, true
+	}
+	return AudioFormat{SampleFormat: SampleFormatU16, Channels: channels}, true
+}
+
+// likelyFloat32PCM 要求所有值有限、大致落在 -1..1，并且不是全部接近 0。
+// 16 位整数数据按 float32 读取时通常会出现 NaN、极大值或非规格化的极小值。
+func likelyFloat32PCM(data []byte) bool {
+	if len(data) < 4*minPCMFrames {
+		return false
+	}
+	var peak float64
+	for i := 0; i+4 <= len(data); i += 4 {
+		v := float64(math.Float32frombits(binary.LittleEndian.Uint32(data[i:])))
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+		a := math.Abs(v)
+		if a > 1.5 {
+			return false
+		}
+		if a != 0 && a < 1e-30 {
+			// 非规格化数基本只会来自被误读的整数
+			return false
+		}
+		peak = math.Max(peak, a)
+	}
+	return peak >= 1e-4
+}
+
+// likelySignedPCM16 比较两种解释下的平均幅度：有符号数据在 0 附近，
+// 无符号数据在 32768 附近，取偏离更小的一种。静音按有符号处理。
+func likelySignedPCM16(data []byte) bool {
+	sampleCount := len(data) / 2
+	if sampleCount == 0 {
+		return true
+	}
+	var signedSum, unsignedSum float64
+	for i := 0; i < sampleCount; i++ {
+		v := binary.LittleEndian.Uint16(data[i*2:])
+		signedSum += math.Abs(float64(int16(v)))
+		unsignedSum += math.Abs(float64(v) - 32768.0)
+	}
+	return signedSum <= unsignedSum
+}
+
+func isSilentPCM(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
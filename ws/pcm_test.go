@@ -0,0 +1,114 @@
+package ws
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+const sineAmplitude = 0.6
+
+// sinePCM 按指定格式生成交错的正弦波，每个声道内容相同。
+func sinePCM(format SampleFormat, channels int, freq float64, rate, frames int) []byte {
+	size := format.Size()
+	data := make([]byte, frames*channels*size)
+	for i := 0; i < frames; i++ {
+		v := sineAmplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+		for c := 0; c < channels; c++ {
+			b := data[(i*channels+c)*size:]
+			switch format {
+			case SampleFormatI16:
+				binary.LittleEndian.PutUint16(b, uint16(int16(v*32767)))
+			case SampleFormatU16:
+				binary.LittleEndian.PutUint16(b, uint16(v*32767+32768))
+			case SampleFormatF32:
+				binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+			case SampleFormatI64:
+				binary.LittleEndian.PutUint64(b, uint64(int64(v*math.MaxInt64)))
+			}
+		}
+	}
+	return data
+}
+
+var pcmTestFormats = []SampleFormat{SampleFormatI16, SampleFormatU16, SampleFormatF32, SampleFormatI64}
+
+func TestDecodePCMSamplesSine(t *testing.T) {
+	for _, format := range pcmTestFormats {
+		for _, channels := range []int{1, 2} {
+			data := sinePCM(format, channels, 440, 48000, 512)
+			got, ok := decodePCMSamples(data, format, channels, nil)
+			if !ok || len(got) != 512 {
+				t.Fatalf("%s/%dch: ok=%v len=%d", format, channels, ok, len(got))
+			}
+			for i, v := range got {
+				want := sineAmplitude * math.Sin(2*math.Pi*440*float64(i)/48000)
+				if math.Abs(v-want) > 1e-3 {
+					t.Fatalf("%s/%dch sample %d = %.5f, want %.5f", format, channels, i, v, want)
+				}
+			}
+		}
+	}
+}
+
+func TestGuessPCMFormatSine(t *testing.T) {
+	for _, format := range []SampleFormat{SampleFormatI16, SampleFormatU16, SampleFormatF32} {
+		for _, freq := range []float64{60, 440, 5000} {
+			got, ok := guessPCMFormat(sinePCM(format, 2, freq, 48000, 1024), 2)
+			if !ok || got.SampleFormat != format || got.Channels != 2 {
+				t.Fatalf("guess(%s, %.0fHz) = %v, %v", format, freq, got, ok)
+			}
+		}
+	}
+}
+
+func TestPCMDecoderPrefersDeclaredFormat(t *testing.T) {
+	var d pcmDecoder
+	d.setFormat(AudioFormat{SampleFormat: SampleFormatI64, Channels: 1})
+	data := sinePCM(SampleFormatI64, 1, 440, 48000, 256)
+	got, format, ok := d.decode(data, nil)
+	if !ok || format.SampleFormat != SampleFormatI64 || len(got) != 256 {
+		t.Fatalf("decode = %d samples, %v, %v", len(got), format, ok)
+	}
+}
+
+func TestPCMDecoderLocksGuessedFormat(t *testing.T) {
+	var d pcmDecoder
+	silence := make([]byte, 4*256)
+	for i := 0; i < guessLockStreak*2; i++ {
+		d.decode(silence, nil)
+	}
+	if d.locked {
+		t.Fatal("silence should not lock a format")
+	}
+
+	f32 := sinePCM(SampleFormatF32, 2, 440, 48000, 256)
+	for i := 0; i < guessLockStreak; i++ {
+		d.decode(f32, nil)
+	}
+	if !d.locked || d.candidate.SampleFormat != SampleFormatF32 {
+		t.Fatalf("decoder not locked to f32: %+v", d)
+	}
+	// 锁定后不再逐帧推测，即使这一帧单独看更像整数
+	_, format, ok := d.decode(sinePCM(SampleFormatI16, 2, 440, 48000, 256), nil)
+	if !ok || format.SampleFormat != SampleFormatF32 {
+		t.Fatalf("locked decoder switched to %v", format)
+	}
+}
+
+func TestParseInitializeAudioFormat(t *testing.T) {
+	got, err := parseInitializeAudioFormat(json.RawMessage(`{"audioFormat":{"sampleFormat":"f32le","channels":2,"sampleRate":44100}}`))
+	if err != nil || got != (AudioFormat{SampleFormat: SampleFormatF32, Channels: 2, SampleRate: 44100}) {
+		t.Fatalf("parse = %v, %v", got, err)
+	}
+	if got, err := parseInitializeAudioFormat(nil); err != nil || got != (AudioFormat{}) {
+		t.Fatalf("parse(nil) = %v, %v", got, err)
+	}
+	_, err = parseInitializeAudioFormat(json.RawMessage(`{"audioFormat":{"sampleFormat":"mp3"}}`))
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "audioFormat.sampleFormat" {
+		t.Fatalf("err = %v, want sampleFormat field error", err)
+	}
+}
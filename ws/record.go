@@ -0,0 +1,428 @@
+package ws
+
+// 文件说明：WebSocket 会话的录制与回放。
+// 主要职责：把解码后的 ProtocolPayload 连同单调时间戳写入紧凑文件，
+// 并能按原速或加速把文件重新送回消息通道，方便离线复现渲染问题。
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 文件格式：
+//
+//	header  = "ELWS" version(1 byte)
+//	record  = delta(uvarint, 纳秒, 相对上一条记录) kind(1 byte) source(string) body
+//	string  = len(uvarint) bytes
+//	body    = 按 kind 不同由若干 string / uvarint 组成
+const (
+	recordMagic   = "ELWS"
+	recordVersion = 1
+)
+
+type recordKind byte
+
+const (
+	recordSignal recordKind = iota + 1
+	recordCommand
+	recordState
+	recordBinary
+	recordV1
+	recordConnected
+	recordDisconnected
+)
+
+// maxRecordField 限制单个字段长度，避免损坏的文件导致超大分配。
+const maxRecordField = 64 << 20
+
+var ErrBadRecording = errors.New("ws: invalid session recording")
+
+// Recorder 把消息写入录制文件。零值不可用，请使用 NewRecorder 或 CreateRecorder。
+type Recorder struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	start  time.Time
+	last   time.Duration
+	buf    []byte
+	err    error
+}
+
+// CreateRecorder 在 path 创建（或覆盖）录制文件。
+func CreateRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewRecorder(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewRecorder 把录制内容写入 w，并立即写出文件头。
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{
+		w:     bufio.NewWriterSize(w, 64<<10),
+		start: time.Now(),
+	}
+	r.w.WriteString(recordMagic)
+	r.w.WriteByte(recordVersion)
+	if err := r.w.Flush(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record 追加一条消息。未知类型的消息会被忽略并返回 nil。
+func (r *Recorder) Record(msg Envelope) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+
+	body := appendRecordString(r.buf[:0], []byte(msg.Source))
+	var kind recordKind
+	switch p := msg.Payload.(type) {
+	case V2PayloadType:
+		kind = recordSignal
+		body = appendRecordString(body, []byte(p))
+	case Command:
+		data, err := json.Marshal(p.Data)
+		if err != nil {
+			return err
+		}
+		kind = recordCommand
+		body = appendRecordString(body, []byte(p.Command))
+		body = appendRecordString(body, data)
+	case StateValue:
+		data, err := encodeStateValue(p)
+		if err != nil {
+			return err
+		}
+		kind = recordState
+		body = appendRecordString(body, []byte(p.UpdateName()))
+		body = appendRecordString(body, data)
+	case V2BinaryMessage:
+		kind = recordBinary
+		body = appendRecordString(body, []byte(p.Type))
+		body = appendRecordString(body, p.Data)
+	case V1Body:
+		kind = recordV1
+		body = binary.AppendUvarint(body, uint64(p.ID))
+		body = appendRecordString(body, p.Raw)
+	case SourceConnected:
+		kind = recordConnected
+		body = binary.AppendUvarint(body, uint64(p.Protocol))
+		body = appendRecordString(body, []byte(p.Audio.SampleFormat))
+		body = binary.AppendUvarint(body, uint64(p.Audio.Channels))
+		body = binary.AppendUvarint(body, uint64(p.Audio.SampleRate))
+	case SourceDisconnected:
+		kind = recordDisconnected
+	default:
+		return nil
+	}
+	r.buf = body
+
+	now := time.Since(r.start)
+	delta := now - r.last
+	if delta < 0 {
+		delta = 0
+	}
+	r.last = now
+
+	var head [binary.MaxVarintLen64 + 1]byte
+	n := binary.PutUvarint(head[:], uint64(delta))
+	head[n] = byte(kind)
+	if _, err := r.w.Write(head[:n+1]); err != nil {
+		r.err = err
+		return err
+	}
+	if _, err := r.w.Write(body); err != nil {
+		r.err = err
+		return err
+	}
+	// 每条记录都落盘，进程崩溃时录制文件依然可用。
+	if err := r.w.Flush(); err != nil {
+		r.err = err
+		return err
+	}
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.w.Flush()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+		r.closer = nil
+	}
+	if r.err == nil {
+		r.err = errors.New("ws: recorder closed")
+	}
+	return err
+}
+
+// encodeStateValue 把状态更新编码为与协议字段一致的 JSON，读取时复用 decodeStateValue。
+func encodeStateValue(v StateValue) ([]byte, error) {
+	if u, ok := v.(StateUpdate); ok {
+		return json.Marshal(u.Data)
+	}
+	return json.Marshal(v)
+}
+
+func appendRecordString(dst, s []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// RecordedPayload 是录制文件中的一条消息。
+type RecordedPayload struct {
+	// At 是相对会话开始的时间。
+	At time.Duration
+	Envelope
+}
+
+// RecordingReader 顺序读取录制文件。
+type RecordingReader struct {
+	r  *bufio.Reader
+	at time.Duration
+}
+
+func NewRecordingReader(r io.Reader) (*RecordingReader, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	header := make([]byte, len(recordMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	if string(header[:len(recordMagic)]) != recordMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrBadRecording)
+	}
+	if version := header[len(recordMagic)]; version != recordVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrBadRecording, version)
+	}
+	return &RecordingReader{r: br}, nil
+}
+
+// Next 返回下一条消息；文件结束时返回 io.EOF。
+func (rr *RecordingReader) Next() (RecordedPayload, error) {
+	delta, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return RecordedPayload{}, io.EOF
+		}
+		return RecordedPayload{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	kindByte, err := rr.r.ReadByte()
+	if err != nil {
+		return RecordedPayload{}, fmt.Errorf("%w: truncated record", ErrBadRecording)
+	}
+	rr.at += time.Duration(delta)
+
+	source, err := rr.readString()
+	if err != nil {
+		return RecordedPayload{}, err
+	}
+
+	var payload ProtocolPayload
+	switch recordKind(kindByte) {
+	case recordSignal:
+		name, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		payload = V2PayloadType(name)
+	case recordCommand:
+		name, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		raw, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return RecordedPayload{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+		}
+		payload = Command{Command: string(name), Data: data}
+	case recordState:
+		name, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		raw, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return RecordedPayload{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+		}
+		value, err := decodeStateValue(string(name), obj)
+		if err != nil {
+			return RecordedPayload{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+		}
+		payload = value
+	case recordBinary:
+		name, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		data, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		payload = V2BinaryMessage{Type: string(name), Data: data}
+	case recordV1:
+		id, err := binary.ReadUvarint(rr.r)
+		if err != nil {
+			return RecordedPayload{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+		}
+		raw, err := rr.readString()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		payload = V1Body{ID: uint32(id), Raw: raw}
+	case recordConnected:
+		protocol, err := binary.ReadUvarint(rr.r)
+		if err != nil {
+			return RecordedPayload{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+		}
+		audio, err := rr.readAudioFormat()
+		if err != nil {
+			return RecordedPayload{}, err
+		}
+		payload = SourceConnected{Protocol: ProtocolType(protocol), Audio: audio}
+	case recordDisconnected:
+		payload = SourceDisconnected{}
+	default:
+		return RecordedPayload{}, fmt.Errorf("%w: unknown record kind %d", ErrBadRecording, kindByte)
+	}
+	return RecordedPayload{At: rr.at, Envelope: Envelope{Source: string(source), Payload: payload}}, nil
+}
+
+func (rr *RecordingReader) readAudioFormat() (AudioFormat, error) {
+	sample, err := rr.readString()
+	if err != nil {
+		return AudioFormat{}, err
+	}
+	channels, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return AudioFormat{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	rate, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return AudioFormat{}, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	return AudioFormat{SampleFormat: SampleFormat(sample), Channels: int(channels), SampleRate: int(rate)}, nil
+}
+
+func (rr *RecordingReader) readString() ([]byte, error) {
+	n, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	if n > maxRecordField {
+		return nil, fmt.Errorf("%w: field too large (%d bytes)", ErrBadRecording, n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(rr.r, buf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	return buf, nil
+}
+
+// ReplaySource 把录制文件按时间轴送回消息通道。
+type ReplaySource struct {
+	Path string
+	// Speed 是回放倍速，1 为原速；<= 0 表示不等待，尽快送出全部消息。
+	Speed float64
+	// Loop 为 true 时在文件结束后从头开始。
+	Loop bool
+}
+
+// Run 阻塞直到回放结束或 stop 被关闭。
+func (s ReplaySource) Run(msgChan MessageChannel, stop <-chan struct{}) error {
+	for {
+		if err := s.playOnce(msgChan, stop); err != nil {
+			return err
+		}
+		if !s.Loop {
+			return nil
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+}
+
+func (s ReplaySource) playOnce(msgChan MessageChannel, stop <-chan struct{}) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, err := NewRecordingReader(f)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if s.Speed > 0 {
+			due := time.Duration(float64(rec.At) / s.Speed)
+			if wait := due - time.Since(start); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-timer.C:
+				case <-stop:
+					return nil
+				}
+			}
+		}
+
+		select {
+		case msgChan <- rec.Envelope:
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+var _ Source = ReplaySource{}
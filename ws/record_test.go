@@ -0,0 +1,108 @@
+package ws
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordingRoundTrip(t *testing.T) {
+	payloads := []Envelope{
+		{Source: "a", Payload: SourceConnected{Protocol: HybridV2, Audio: AudioFormat{SampleFormat: SampleFormatF32, Channels: 2, SampleRate: 44100}}},
+		{Source: "a", Payload: V2PayloadType("ping")},
+		{Source: "a", Payload: Command{Command: "pause", Data: map[string]interface{}{}}},
+		{Source: "b", Payload: ProgressUpdate{Progress: 1234}},
+		{Source: "b", Payload: SetMusicUpdate{MusicInfo{MusicId: "1", MusicName: "song", Artists: []Artist{{ID: "2", Name: "artist"}}}}},
+		{Source: "b", Payload: StateUpdate{Update: "setFontConfig", Data: map[string]interface{}{"update": "setFontConfig", "size": float64(32)}}},
+		{Source: "b", Payload: V2BinaryMessage{Type: "OnAudioData", Data: []byte{1, 2, 3, 4}}},
+		{Source: "c", Payload: V1Body{ID: 7, Raw: []byte("raw")}},
+		{Source: "a", Payload: SourceDisconnected{}},
+	}
+
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range payloads {
+		if err := rec.Record(p); err != nil {
+			t.Fatalf("Record(%T): %v", p.Payload, err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewRecordingReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last time.Duration
+	for i, want := range payloads {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Next() #%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got.Envelope, want) {
+			t.Fatalf("payload #%d = %#v, want %#v", i, got.Envelope, want)
+		}
+		if got.At < last {
+			t.Fatalf("timestamp #%d went backwards: %v < %v", i, got.At, last)
+		}
+		last = got.At
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("Next() after last record = %v, want io.EOF", err)
+	}
+}
+
+func TestRecordingReaderRejectsBadHeader(t *testing.T) {
+	_, err := NewRecordingReader(bytes.NewReader([]byte("nope!")))
+	if !errors.Is(err, ErrBadRecording) {
+		t.Fatalf("err = %v, want ErrBadRecording", err)
+	}
+}
+
+func TestReplaySourceFeedsChannel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.elws")
+	rec, err := CreateRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Record(Envelope{Source: "player", Payload: V2PayloadType("initialize")})
+	rec.Record(Envelope{Source: "player", Payload: ResumedUpdate{}})
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(MessageChannel, 4)
+	if err := (ReplaySource{Path: path, Speed: 0}).Run(ch, nil); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	var got []ProtocolPayload
+	for msg := range ch {
+		if msg.Source != "player" {
+			t.Fatalf("source = %q, want %q", msg.Source, "player")
+		}
+		got = append(got, msg.Payload)
+	}
+	if len(got) != 2 {
+		t.Fatalf("replayed %d payloads, want 2", len(got))
+	}
+	if got[0] != V2PayloadType("initialize") {
+		t.Fatalf("first payload = %#v", got[0])
+	}
+	if _, ok := got[1].(ResumedUpdate); !ok {
+		t.Fatalf("second payload = %#v", got[1])
+	}
+}
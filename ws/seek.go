@@ -0,0 +1,82 @@
+package ws
+
+// 文件说明：把界面发起的跳转请求转发给播放器。
+// 主要职责：定义可接收跳转指令的数据源接口，并按 AMLL 协议向 WebSocket 播放器发送 seekPlayProgress 指令。
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// CommandSeekPlayProgress 是 AMLL 协议中让播放器跳转进度的指令名，progress 为毫秒。
+const CommandSeekPlayProgress = "seekPlayProgress"
+
+// ErrUnknownSource 表示 Seeker 不负责该数据源。
+var ErrUnknownSource = errors.New("ws: unknown source")
+
+// Seeker 是能让播放器跳转进度的数据源，如 WebSocket 服务器和 MPRIS。
+// source 不属于自己时返回 ErrUnknownSource，调用方会继续尝试下一个 Seeker。
+type Seeker interface {
+	Seek(source string, pos time.Duration) error
+}
+
+var _ Seeker = (*AMLLWebSocketServer)(nil)
+
+// seekCommand 是发给 HybridV2 播放器的跳转指令。
+type seekCommand struct {
+	Command  string `json:"command"`
+	Progress int64  `json:"progress"`
+}
+
+// Seek 向 source 对应的 HybridV2 连接发送 seekPlayProgress 指令。
+func (s *AMLLWebSocketServer) Seek(source string, pos time.Duration) error {
+	return s.sendV2(source, TypeCommand, seekCommand{Command: CommandSeekPlayProgress, Progress: pos.Milliseconds()})
+}
+
+// sendV2 以 {"type":...,"value":...} 的形式向 source 发送一条文本消息。
+func (s *AMLLWebSocketServer) sendV2(source string, payloadType V2PayloadType, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(GenericV2Payload{Type: payloadType, Value: raw})
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	info, ok := s.connections[source]
+	s.mu.RUnlock()
+	if !ok {
+		return ErrUnknownSource
+	}
+	if info.Protocol != HybridV2 {
+		return fmt.Errorf("ws: %s does not accept %s messages", source, payloadType)
+	}
+	info.writeMu.Lock()
+	defer info.writeMu.Unlock()
+	return info.Conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// forwardSeek 把跳转请求交给负责 source 的 Seeker；没有活动数据源或无人负责时只记录日志。
+func forwardSeek(source string, pos time.Duration, seekers []Seeker) {
+	if source == "" {
+		return
+	}
+	for _, seeker := range seekers {
+		err := seeker.Seek(source, pos)
+		if errors.Is(err, ErrUnknownSource) {
+			continue
+		}
+		if err != nil {
+			log.Printf("WARN: 向 %s 转发跳转失败: %v", source, err)
+		}
+		return
+	}
+	log.Printf("INFO: 数据源 %s 不支持跳转", source)
+}
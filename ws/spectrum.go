@@ -0,0 +1,249 @@
+package ws
+
+// 文件说明：OnAudioData 的频谱分析。
+// 主要职责：把 PCM 帧做 FFT，按对数间隔划分为多个频段并分别平滑，
+// 同时保留原有 20-300Hz 低频音量的计算并做节拍检测；FFT 缓冲区在多次调用间复用，每帧的 Bands 则是新分配的。
+
+import (
+	"log"
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+const (
+	// DefaultSpectrumBands 是默认的频段数量。
+	DefaultSpectrumBands = 16
+	// DefaultAudioSampleRate 在播放器和配置都没有给出采样率时使用。
+	DefaultAudioSampleRate = 48000
+
+	spectrumMinFreq = 20.0
+	spectrumMaxFreq = 16000.0
+	lowFreqMin      = 20.0
+	lowFreqMax      = 300.0
+	maxFFTSize      = 2048
+	minFFTSize      = 256
+
+	// 平滑系数：上升快、回落慢
+	spectrumAttack = 0.5
+	spectrumDecay  = 0.2
+)
+
+// SpectrumFrame 是一次分析的结果，所有值都在 0-1 之间。
+type SpectrumFrame struct {
+	// Bands 从低频到高频按对数间隔排列，每帧都是新分配的切片，订阅者可以直接保留。
+	Bands []float64
+	// LowFreq 是 20-300Hz 的能量，与 TopicLowFreqVolume 含义一致。
+	LowFreq float64
+	// HasBeat 为 true 时 Beat 是这一帧检测到的起音。
+	HasBeat bool
+	Beat    Beat
+}
+
+// bandLevel 对单个频段做动态峰值归一化与 attack/decay 平滑。
+type bandLevel struct {
+	value float64
+	peak  float64
+}
+
+func (b *bandLevel) update(level float64) float64 {
+	// 历史峰值缓慢下降，以适应不同音量的歌曲
+	b.peak = math.Max(b.peak*0.999, level)
+	if b.peak < 0.01 {
+		b.peak = 0.01
+	}
+	norm := math.Min(1, math.Log10(1+9*level/b.peak))
+
+	k := spectrumDecay
+	if norm > b.value {
+		k = spectrumAttack
+	}
+	b.value = b.value*(1-k) + norm*k
+	b.value = math.Max(0, math.Min(1, b.value))
+	return b.value
+}
+
+// spectrumAnalyzer 只在 Initws 的消息循环中使用，无需加锁。
+type spectrumAnalyzer struct {
+	format     AudioFormat
+	decoder    pcmDecoder
+	lastFormat AudioFormat
+
+	samples []float64
+	window  []float64
+	fftBuf  []complex128
+
+	// edges[i]..edges[i+1]-1 是第 i 个频段的 FFT bin 范围，随 nfft 变化重算
+	edges     []int
+	edgesNFFT int
+	lowBins   [2]int
+
+	bands []bandLevel
+	low   bandLevel
+	beats beatDetector
+}
+
+func newSpectrumAnalyzer(format AudioFormat, bands int) *spectrumAnalyzer {
+	if bands <= 0 {
+		bands = DefaultSpectrumBands
+	}
+	a := &spectrumAnalyzer{
+		bands: make([]bandLevel, bands),
+		low:   bandLevel{peak: 0.2},
+	}
+	for i := range a.bands {
+		a.bands[i].peak = 0.2
+	}
+	a.setFormat(format)
+	return a
+}
+
+// setFormat 更换 PCM 格式。采样率变化时频段边界会在下一帧重算。
+func (a *spectrumAnalyzer) setFormat(format AudioFormat) {
+	if format.SampleRate <= 0 {
+		format.SampleRate = DefaultAudioSampleRate
+	}
+	if format == a.format {
+		return
+	}
+	if format.SampleRate != a.format.SampleRate {
+		a.edgesNFFT = 0
+	}
+	a.format = format
+	a.decoder.setFormat(format)
+	a.beats.reset()
+	a.lastFormat = AudioFormat{}
+}
+
+// Analyze 分析一帧 PCM 数据。返回的 Bands 是新分配的切片，可以安全地跨 goroutine 发布。
+func (a *spectrumAnalyzer) Analyze(data []byte) (SpectrumFrame, bool) {
+	if a == nil {
+		return SpectrumFrame{}, false
+	}
+	samples, format, ok := a.decoder.decode(data, a.samples[:0])
+	if !ok || len(samples) < 128 {
+		return SpectrumFrame{}, false
+	}
+	a.samples = samples
+	if format != a.lastFormat {
+		a.lastFormat = format
+		log.Printf("audio analyzer format=%s samples=%d", format, len(samples))
+	}
+
+	nfft := 1
+	for nfft*2 <= len(samples) && nfft < maxFFTSize {
+		nfft *= 2
+	}
+	if nfft < minFFTSize {
+		return SpectrumFrame{}, false
+	}
+	a.prepare(nfft)
+
+	// 去直流分量 + 汉宁窗
+	var mean float64
+	for i := 0; i < nfft; i++ {
+		mean += samples[i]
+	}
+	mean /= float64(nfft)
+	for i := 0; i < nfft; i++ {
+		a.fftBuf[i] = complex((samples[i]-mean)*a.window[i], 0)
+	}
+	fftInPlace(a.fftBuf)
+
+	frame := SpectrumFrame{Bands: make([]float64, len(a.bands))}
+	for i := range a.bands {
+		frame.Bands[i] = a.bands[i].update(a.rms(a.edges[i], a.edges[i+1]))
+	}
+	frame.LowFreq = a.low.update(a.rms(a.lowBins[0], a.lowBins[1]))
+
+	dt := time.Duration(float64(len(samples)) / float64(a.format.SampleRate) * float64(time.Second))
+	frame.Beat, frame.HasBeat = a.beats.process(a.fftBuf[:nfft/2+1], dt)
+	return frame, true
+}
+
+// prepare 在 FFT 长度变化时重建窗函数与频段边界。
+func (a *spectrumAnalyzer) prepare(nfft int) {
+	if a.edgesNFFT == nfft {
+		return
+	}
+	a.edgesNFFT = nfft
+	a.fftBuf = make([]complex128, nfft)
+	a.window = make([]float64, nfft)
+	denom := float64(nfft - 1)
+	for i := range a.window {
+		a.window[i] = 0.5 * (1.0 - math.Cos(2.0*math.Pi*float64(i)/denom))
+	}
+
+	nyquist := nfft / 2
+	freqRes := float64(a.format.SampleRate) / float64(nfft)
+	bin := func(freq float64) int {
+		k := int(math.Round(freq / freqRes))
+		return max(1, min(nyquist, k))
+	}
+
+	maxFreq := math.Min(spectrumMaxFreq, float64(a.format.SampleRate)/2)
+	ratio := maxFreq / spectrumMinFreq
+	n := len(a.bands)
+	a.edges = make([]int, n+1)
+	a.edges[0] = bin(spectrumMinFreq)
+	for i := 1; i <= n; i++ {
+		edge := bin(spectrumMinFreq * math.Pow(ratio, float64(i)/float64(n)))
+		// 低频段在小 FFT 下可能挤在同一个 bin，至少保证每段一个 bin
+		if edge <= a.edges[i-1] {
+			edge = a.edges[i-1] + 1
+		}
+		a.edges[i] = min(edge, nyquist+1)
+	}
+
+	a.lowBins = [2]int{
+		max(1, int(math.Ceil(lowFreqMin/freqRes))),
+		min(nyquist, int(math.Floor(lowFreqMax/freqRes))) + 1,
+	}
+}
+
+// rms 返回 [from, to) 范围内 FFT 幅度的均方根。
+func (a *spectrumAnalyzer) rms(from, to int) float64 {
+	if to <= from {
+		return 0
+	}
+	var energy float64
+	for k := from; k < to; k++ {
+		mag := cmplx.Abs(a.fftBuf[k])
+		energy += mag * mag
+	}
+	return math.Sqrt(energy / float64(to-from))
+}
+
+func fftInPlace(a []complex128) {
+	n := len(a)
+	if n <= 1 {
+		return
+	}
+	j := 0
+	for i := 1; i < n; i++ {
+		bit := n >> 1
+		for ; (j & bit) != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+
+	for step := 2; step <= n; step <<= 1 {
+		half := step >> 1
+		ang := -2.0 * math.Pi / float64(step)
+		wStep := cmplx.Exp(complex(0, ang))
+		for i := 0; i < n; i += step {
+			w := complex(1.0, 0)
+			for k := 0; k < half; k++ {
+				u := a[i+k]
+				v := a[i+k+half] * w
+				a[i+k] = u + v
+				a[i+k+half] = u - v
+				w *= wStep
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ws
+
+import (
+	"testing"
+)
+
+func loudestBand(bands []float64) int {
+	best := 0
+	for i, v := range bands {
+		if v > bands[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func TestSpectrumAnalyzerLocatesSineBand(t *testing.T) {
+	lowAnalyzer := newSpectrumAnalyzer(AudioFormat{SampleRate: 44100, Channels: 2}, 8)
+	highAnalyzer := newSpectrumAnalyzer(AudioFormat{SampleRate: 44100, Channels: 2}, 8)
+
+	var low, high SpectrumFrame
+	for i := 0; i < 10; i++ {
+		var ok bool
+		if low, ok = lowAnalyzer.Analyze(sinePCM(SampleFormatI16, 2, 100, 44100, 2048)); !ok {
+			t.Fatal("Analyze(100Hz) failed")
+		}
+		if high, ok = highAnalyzer.Analyze(sinePCM(SampleFormatI16, 2, 5000, 44100, 2048)); !ok {
+			t.Fatal("Analyze(5kHz) failed")
+		}
+	}
+	if len(low.Bands) != 8 {
+		t.Fatalf("len(Bands) = %d, want 8", len(low.Bands))
+	}
+	if lb, hb := loudestBand(low.Bands), loudestBand(high.Bands); lb >= hb {
+		t.Fatalf("100Hz peaked in band %d, 5kHz in band %d", lb, hb)
+	}
+	if low.LowFreq <= high.LowFreq {
+		t.Fatalf("LowFreq 100Hz = %.3f, 5kHz = %.3f", low.LowFreq, high.LowFreq)
+	}
+}
+
+func TestSpectrumAnalyzerReusesBuffers(t *testing.T) {
+	a := newSpectrumAnalyzer(AudioFormat{Channels: 2}, 0)
+	data := sinePCM(SampleFormatI16, 2, 440, DefaultAudioSampleRate, 1024)
+	first, _ := a.Analyze(data)
+	allocs := testing.AllocsPerRun(20, func() {
+		a.Analyze(data)
+	})
+	// 只有返回的 Bands 切片需要分配
+	if allocs > 1 {
+		t.Fatalf("Analyze allocates %.0f times per call", allocs)
+	}
+	second, _ := a.Analyze(data)
+	if &first.Bands[0] == &second.Bands[0] {
+		t.Fatal("Bands slice is shared between frames")
+	}
+	if len(first.Bands) != DefaultSpectrumBands {
+		t.Fatalf("len(Bands) = %d, want %d", len(first.Bands), DefaultSpectrumBands)
+	}
+}
@@ -0,0 +1,330 @@
+package ws
+
+// 文件说明：V2 状态更新的类型化解码。
+// 主要职责：在 processV2Message 中一次性把 state 消息解码为具体结构体，
+// 字段缺失或类型错误时返回带字段路径的错误。
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+)
+
+// StateValue 是一条已解码的 V2 状态更新。
+type StateValue interface {
+	UpdateName() string
+}
+
+// Artist 是 setMusic 中的艺术家信息。
+type Artist struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SetMusicUpdate 对应 setMusic，Duration 单位为毫秒。
+type SetMusicUpdate struct {
+	MusicInfo
+}
+
+// SetLyricUpdate 对应 setLyric，Lines 已合并背景行。
+type SetLyricUpdate struct {
+	Lines []ttml.LyricLine `json:"lines"`
+}
+
+// SetLyricFromTTMLUpdate 对应 setLyricFromTTML，携带原始 TTML 文本。
+// Lyric 是解码时用 ttml.ParseTTML 解析出的结果，不参与序列化。
+type SetLyricFromTTMLUpdate struct {
+	LyricContent
+	Lyric ttml.TTMLLyric `json:"-"`
+}
+
+// ProgressUpdate 对应 progress，单位为毫秒。
+type ProgressUpdate struct {
+	Progress int64 `json:"progress"`
+}
+
+// VolumeUpdate 对应 volume，范围 0-1。
+type VolumeUpdate struct {
+	Volume float64 `json:"volume"`
+}
+
+// CoverImage 是 setCover 内联图片数据，Data 为 base64。
+type CoverImage struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// SetCoverUpdate 对应 setCover。Source 为 "uri" 时使用 URL，为 "data" 时使用 Image。
+type SetCoverUpdate struct {
+	Source string      `json:"source"`
+	URL    string      `json:"url,omitempty"`
+	Image  *CoverImage `json:"image,omitempty"`
+}
+
+type PausedUpdate struct{}
+
+type ResumedUpdate struct{}
+
+func (SetMusicUpdate) UpdateName() string         { return "setMusic" }
+func (SetLyricUpdate) UpdateName() string         { return "setLyric" }
+func (SetLyricFromTTMLUpdate) UpdateName() string { return "setLyricFromTTML" }
+func (ProgressUpdate) UpdateName() string         { return "progress" }
+func (VolumeUpdate) UpdateName() string           { return "volume" }
+func (SetCoverUpdate) UpdateName() string         { return "setCover" }
+func (PausedUpdate) UpdateName() string           { return "paused" }
+func (ResumedUpdate) UpdateName() string          { return "resumed" }
+func (u StateUpdate) UpdateName() string          { return u.Update }
+
+// FieldError 描述状态更新中某个字段的错误。
+type FieldError struct {
+	Update string
+	Field  string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %v", e.Update, e.Err)
+	}
+	return fmt.Sprintf("%s.%s: %v", e.Update, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+var errMissingField = errors.New("missing required field")
+
+// DecodeStateUpdate 解码 state 消息的 value 部分。
+// 未知的 update 名称不算错误，会以 StateUpdate 的形式原样返回字段字典。
+func DecodeStateUpdate(raw json.RawMessage) (StateValue, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, &FieldError{Update: "state", Err: err}
+	}
+	var name string
+	if err := decodeField("state", obj, "update", &name, true); err != nil {
+		return nil, err
+	}
+	return decodeStateValue(name, obj)
+}
+
+func decodeStateValue(name string, obj map[string]json.RawMessage) (StateValue, error) {
+	switch name {
+	case "setMusic":
+		var u SetMusicUpdate
+		var duration float64
+		if err := firstError(
+			decodeField(name, obj, "musicId", &u.MusicId, true),
+			decodeField(name, obj, "musicName", &u.MusicName, true),
+			decodeField(name, obj, "albumId", &u.AlbumId, false),
+			decodeField(name, obj, "albumName", &u.AlbumName, false),
+			decodeField(name, obj, "artists", &u.Artists, false),
+			decodeField(name, obj, "duration", &duration, false),
+		); err != nil {
+			return nil, err
+		}
+		if duration < 0 {
+			return nil, &FieldError{Update: name, Field: "duration", Err: errors.New("must not be negative")}
+		}
+		u.Duration = int64(duration)
+		return u, nil
+
+	case "setLyric":
+		raw, ok := obj["lines"]
+		if !ok {
+			return nil, &FieldError{Update: name, Field: "lines", Err: errMissingField}
+		}
+		lines, err := decodeLyricLines(name, raw)
+		if err != nil {
+			return nil, err
+		}
+		return SetLyricUpdate{Lines: lines}, nil
+
+	case "setLyricFromTTML":
+		var u SetLyricFromTTMLUpdate
+		// 兼容旧版协议把 TTML 放在 data 字段
+		field := "ttml"
+		if _, ok := obj[field]; !ok {
+			if _, ok := obj["data"]; ok {
+				field = "data"
+			}
+		}
+		if err := decodeField(name, obj, field, &u.Ttml, true); err != nil {
+			return nil, err
+		}
+		lyric, err := ttml.ParseTTML(u.Ttml)
+		if err != nil {
+			return nil, &FieldError{Update: name, Field: field, Err: err}
+		}
+		u.Lyric = lyric
+		return u, nil
+
+	case "progress":
+		var progress float64
+		if err := decodeField(name, obj, "progress", &progress, true); err != nil {
+			return nil, err
+		}
+		if progress < 0 {
+			return nil, &FieldError{Update: name, Field: "progress", Err: errors.New("must not be negative")}
+		}
+		return ProgressUpdate{Progress: int64(progress)}, nil
+
+	case "volume":
+		var u VolumeUpdate
+		if err := decodeField(name, obj, "volume", &u.Volume, true); err != nil {
+			return nil, err
+		}
+		return u, nil
+
+	case "setCover":
+		var u SetCoverUpdate
+		if err := decodeField(name, obj, "source", &u.Source, true); err != nil {
+			return nil, err
+		}
+		switch u.Source {
+		case "uri":
+			if err := decodeField(name, obj, "url", &u.URL, true); err != nil {
+				return nil, err
+			}
+		case "data":
+			if err := decodeField(name, obj, "image", &u.Image, true); err != nil {
+				return nil, err
+			}
+			if u.Image == nil || u.Image.Data == "" {
+				return nil, &FieldError{Update: name, Field: "image.data", Err: errMissingField}
+			}
+		default:
+			return nil, &FieldError{Update: name, Field: "source", Err: fmt.Errorf("unknown source %q", u.Source)}
+		}
+		return u, nil
+
+	case "paused":
+		return PausedUpdate{}, nil
+
+	case "resumed":
+		return ResumedUpdate{}, nil
+	}
+
+	data := make(map[string]interface{}, len(obj))
+	for key, raw := range obj {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, &FieldError{Update: name, Field: key, Err: err}
+		}
+		data[key] = v
+	}
+	return StateUpdate{Update: name, Data: data}, nil
+}
+
+// decodeField 把 obj[key] 解码到 dst；required 为 true 时字段缺失或为 null 会报错。
+func decodeField(update string, obj map[string]json.RawMessage, key string, dst interface{}, required bool) error {
+	raw, ok := obj[key]
+	if !ok || string(raw) == "null" {
+		if required {
+			return &FieldError{Update: update, Field: key, Err: errMissingField}
+		}
+		return nil
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return &FieldError{Update: update, Field: fieldPath(key, err), Err: jsonCause(err)}
+	}
+	return nil
+}
+
+// decodeLyricLines 逐行解码歌词，错误信息带行号，最后合并背景行。
+func decodeLyricLines(update string, raw json.RawMessage) ([]ttml.LyricLine, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, &FieldError{Update: update, Field: "lines", Err: jsonCause(err)}
+	}
+	lines := make([]ttml.LyricLine, len(items))
+	for i, item := range items {
+		var line wireLyricLine
+		if err := json.Unmarshal(item, &line); err != nil {
+			return nil, &FieldError{Update: update, Field: fieldPath(fmt.Sprintf("lines[%d]", i), err), Err: jsonCause(err)}
+		}
+		lines[i] = line.lyricLine()
+	}
+	return MergeBGLines(lines), nil
+}
+
+// wireLyricWord / wireLyricLine 是 setLyric 中歌词行的线上格式。
+// 部分播放器发送小数毫秒（如 1234.5），按浮点解码后四舍五入。
+type wireLyricWord struct {
+	StartTime float64  `json:"startTime"`
+	EndTime   float64  `json:"endTime"`
+	Word      string   `json:"word"`
+	EmptyBeat *float64 `json:"emptyBeat,omitempty"`
+}
+
+type wireLyricLine struct {
+	Words           []wireLyricWord `json:"words"`
+	TranslatedLyric string          `json:"translatedLyric"`
+	RomanLyric      string          `json:"romanLyric"`
+	IsBG            bool            `json:"isBG"`
+	IsDuet          bool            `json:"isDuet"`
+	StartTime       float64         `json:"startTime"`
+	EndTime         float64         `json:"endTime"`
+	BGs             []wireLyricLine `json:"bgs"`
+}
+
+func roundMs(v float64) int {
+	return int(math.Round(v))
+}
+
+func (w wireLyricLine) lyricLine() ttml.LyricLine {
+	line := ttml.LyricLine{
+		TranslatedLyric: w.TranslatedLyric,
+		RomanLyric:      w.RomanLyric,
+		IsBG:            w.IsBG,
+		IsDuet:          w.IsDuet,
+		StartTime:       roundMs(w.StartTime),
+		EndTime:         roundMs(w.EndTime),
+	}
+	if w.Words != nil {
+		line.Words = make([]ttml.LyricWord, len(w.Words))
+	}
+	for i, word := range w.Words {
+		line.Words[i] = ttml.LyricWord{
+			StartTime: roundMs(word.StartTime),
+			EndTime:   roundMs(word.EndTime),
+			Word:      word.Word,
+		}
+		if word.EmptyBeat != nil {
+			beat := roundMs(*word.EmptyBeat)
+			line.Words[i].EmptyBeat = &beat
+		}
+	}
+	for _, bg := range w.BGs {
+		line.BGs = append(line.BGs, bg.lyricLine())
+	}
+	return line
+}
+
+func fieldPath(prefix string, err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return prefix + "." + typeErr.Field
+	}
+	return prefix
+}
+
+// jsonCause 把 encoding/json 的类型错误改写为不重复字段路径的简短描述。
+func jsonCause(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+	return err
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
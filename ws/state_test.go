@@ -0,0 +1,115 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodeStateUpdateKnownTypes(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want StateValue
+	}{
+		{`{"update":"progress","progress":1234.0}`, ProgressUpdate{Progress: 1234}},
+		{`{"update":"volume","volume":0.5}`, VolumeUpdate{Volume: 0.5}},
+		{`{"update":"paused"}`, PausedUpdate{}},
+		{`{"update":"resumed"}`, ResumedUpdate{}},
+		{`{"update":"setCover","source":"uri","url":"file:///a.png"}`, SetCoverUpdate{Source: "uri", URL: "file:///a.png"}},
+	}
+	for _, tc := range cases {
+		got, err := DecodeStateUpdate(json.RawMessage(tc.raw))
+		if err != nil {
+			t.Fatalf("DecodeStateUpdate(%s): %v", tc.raw, err)
+		}
+		if got != tc.want {
+			t.Fatalf("DecodeStateUpdate(%s) = %#v, want %#v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeStateUpdateSetLyricFromTTML(t *testing.T) {
+	raw, _ := json.Marshal(map[string]string{"update": "setLyricFromTTML", "ttml": testTTML})
+	got, err := DecodeStateUpdate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := got.(SetLyricFromTTMLUpdate)
+	if len(u.Lyric.LyricLines) != 1 || u.Lyric.LyricLines[0].StartTime != 1000 {
+		t.Fatalf("unexpected lyric %+v", u.Lyric)
+	}
+
+	_, err = DecodeStateUpdate(json.RawMessage(`{"update":"setLyricFromTTML","ttml":"<html/>"}`))
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "ttml" {
+		t.Fatalf("err = %v, want ttml field error", err)
+	}
+}
+
+func TestDecodeStateUpdateSetLyricMergesBG(t *testing.T) {
+	raw := `{"update":"setLyric","lines":[
+		{"startTime":0,"endTime":1000,"words":[{"word":"main","startTime":0,"endTime":1000}]},
+		{"startTime":0,"endTime":1000,"isBG":true,"words":[{"word":"bg","startTime":0,"endTime":1000}]}
+	]}`
+	got, err := DecodeStateUpdate(json.RawMessage(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := got.(SetLyricUpdate).Lines
+	if len(lines) != 1 || len(lines[0].BGs) != 1 || lines[0].BGs[0].Words[0].Word != "bg" {
+		t.Fatalf("unexpected lines %+v", lines)
+	}
+}
+
+func TestDecodeStateUpdateSetLyricAcceptsFractionalTimes(t *testing.T) {
+	raw := `{"update":"setLyric","lines":[
+		{"startTime":1234.0,"endTime":2000.5,"words":[{"word":"a","startTime":1234.4,"endTime":1500.6,"emptyBeat":2.5}]}
+	]}`
+	got, err := DecodeStateUpdate(json.RawMessage(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := got.(SetLyricUpdate).Lines[0]
+	word := line.Words[0]
+	if line.StartTime != 1234 || line.EndTime != 2001 || word.StartTime != 1234 || word.EndTime != 1501 {
+		t.Fatalf("times not rounded: %+v", line)
+	}
+	if word.EmptyBeat == nil || *word.EmptyBeat != 3 {
+		t.Fatalf("emptyBeat = %v, want 3", word.EmptyBeat)
+	}
+}
+
+func TestDecodeStateUpdateFieldErrors(t *testing.T) {
+	cases := []struct {
+		raw   string
+		field string
+	}{
+		{`{"progress":1}`, "update"},
+		{`{"update":"progress"}`, "progress"},
+		{`{"update":"progress","progress":"soon"}`, "progress"},
+		{`{"update":"setMusic","musicName":"x"}`, "musicId"},
+		{`{"update":"setLyric","lines":[{},{"startTime":"x"}]}`, "lines[1].startTime"},
+		{`{"update":"setCover","source":"data"}`, "image"},
+	}
+	for _, tc := range cases {
+		_, err := DecodeStateUpdate(json.RawMessage(tc.raw))
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("DecodeStateUpdate(%s) err = %v, want *FieldError", tc.raw, err)
+		}
+		if fieldErr.Field != tc.field {
+			t.Fatalf("DecodeStateUpdate(%s) field = %q, want %q (%v)", tc.raw, fieldErr.Field, tc.field, err)
+		}
+	}
+}
+
+func TestDecodeStateUpdateUnknownKeepsData(t *testing.T) {
+	got, err := DecodeStateUpdate(json.RawMessage(`{"update":"setFontConfig","size":32}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, ok := got.(StateUpdate)
+	if !ok || u.Update != "setFontConfig" || u.Data["size"] != float64(32) {
+		t.Fatalf("unexpected value %#v", got)
+	}
+}
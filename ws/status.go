@@ -0,0 +1,98 @@
+package ws
+
+// 文件说明：汇总当前播放状态，供 HTTP 控制接口查询。
+// 主要职责：订阅 ws:* 主题，记录当前歌曲、歌词和播放进度，并计算正在演唱的歌词行。
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/evbus"
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+)
+
+// ActiveLine 是 /status 中正在演唱的一行歌词。
+type ActiveLine struct {
+	Index       int    `json:"index"`
+	StartTime   int    `json:"startTime"`
+	EndTime     int    `json:"endTime"`
+	Text        string `json:"text"`
+	Translation string `json:"translation,omitempty"`
+}
+
+type statusTracker struct {
+	mu       sync.RWMutex
+	music    *MusicInfo
+	lines    []ttml.LyricLine
+	metadata []ttml.TTMLMetadata
+	progress time.Duration
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{}
+}
+
+// defaultStatus 记录 Initws 发布到主题上的状态。
+var defaultStatus = newStatusTracker()
+
+// bind 订阅主题。回调在发布方 goroutine 中直接执行，只做赋值。
+func (s *statusTracker) bind() evbus.Unsubscribe {
+	var group evbus.Group
+	group.Add(TopicSetMusic.Subscribe(func(music MusicInfo) {
+		s.mu.Lock()
+		s.music = &music
+		s.mu.Unlock()
+	}))
+	group.Add(TopicLyricMetadata.Subscribe(func(metadata []ttml.TTMLMetadata) {
+		s.mu.Lock()
+		s.metadata = metadata
+		s.mu.Unlock()
+	}))
+	group.Add(TopicLyrics.Subscribe(func(lines []ttml.LyricLine) {
+		s.mu.Lock()
+		s.lines = lines
+		s.mu.Unlock()
+	}))
+	group.Add(TopicProgress.Subscribe(func(progress time.Duration) {
+		s.mu.Lock()
+		s.progress = progress
+		s.mu.Unlock()
+	}))
+	return group.Close
+}
+
+// snapshot 返回当前歌曲、进度以及覆盖该进度的全部歌词行。
+func (s *statusTracker) snapshot() (*MusicInfo, time.Duration, []ActiveLine) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.music, s.progress, activeLinesAt(s.lines, s.progress)
+}
+
+func (s *statusTracker) lyricMetadata() []ttml.TTMLMetadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.metadata
+}
+
+func activeLinesAt(lines []ttml.LyricLine, progress time.Duration) []ActiveLine {
+	ms := int(progress / time.Millisecond)
+	active := []ActiveLine{}
+	for i, line := range lines {
+		if ms < line.StartTime || ms >= line.EndTime {
+			continue
+		}
+		var text strings.Builder
+		for _, word := range line.Words {
+			text.WriteString(word.Word)
+		}
+		active = append(active, ActiveLine{
+			Index:       i,
+			StartTime:   line.StartTime,
+			EndTime:     line.EndTime,
+			Text:        text.String(),
+			Translation: line.TranslatedLyric,
+		})
+	}
+	return active
+}
@@ -0,0 +1,46 @@
+package ws
+
+// 文件说明：WebSocket 数据流对外发布的事件主题。
+// 主要职责：为页面和渲染模块提供带类型的订阅入口，替代字符串主题。
+
+import (
+	"image"
+	"time"
+
+	"github.com/xiaowumin-mark/EbitenLyrics/evbus"
+	"github.com/xiaowumin-mark/EbitenLyrics/ttml"
+)
+
+var (
+	// TopicSetMusic 携带 setMusic 状态更新中的歌曲信息。
+	TopicSetMusic = evbus.NewTopic[MusicInfo]("ws:setMusic")
+	// TopicNowPlaying 携带合并了歌曲信息与封面来源的当前播放状态。
+	TopicNowPlaying = evbus.NewTopic[NowPlaying]("ws:nowPlaying")
+	// TopicLyrics 携带已合并背景行的歌词。
+	TopicLyrics = evbus.NewTopic[[]ttml.LyricLine]("ws:setLyric")
+	// TopicLyricMetadata 携带 TTML 歌词的元数据，非 TTML 来源的歌词会发布 nil。
+	// 总是先于对应的 TopicLyrics 发布。
+	TopicLyricMetadata = evbus.NewTopic[[]ttml.TTMLMetadata]("ws:lyricMetadata")
+	// TopicProgress 携带播放器上报的播放进度。
+	TopicProgress = evbus.NewTopic[time.Duration]("ws:progress")
+	// TopicPlaying 在 paused 时发布 false，resumed 时发布 true。
+	TopicPlaying = evbus.NewTopic[bool]("ws:playing")
+	// TopicFontConfig 携带 setFontConfig / setFont 的配置字典，每次发布的都是副本。
+	TopicFontConfig = evbus.NewTopic[map[string]any]("ws:fontConfig")
+	// TopicCover 携带经过处理链（默认为模糊、调色）加工后的封面图。
+	TopicCover = evbus.NewTopic[image.Image]("ws:cover")
+	// TopicCoverOriginal 携带解码后未经处理的封面原图，先于对应的 TopicCover 发布。
+	// 同一张封面被更新的封面取消时，可能只有原图而没有处理结果。
+	TopicCoverOriginal = evbus.NewTopic[image.Image]("ws:coverOriginal")
+	// TopicCoverPalette 携带从封面原图提取的调色板与处理后封面的平均色，紧接在对应的 TopicCover 之前发布。
+	TopicCoverPalette = evbus.NewTopic[CoverPalette]("ws:coverPalette")
+	// TopicLowFreqVolume 携带 0-1 范围的低频音量。
+	TopicLowFreqVolume = evbus.NewTopic[float64]("ws:lowFreqVolume")
+	// TopicSpectrum 携带按对数间隔划分、已平滑的 0-1 频段能量，从低频到高频排列。
+	// 每次发布的切片都是新分配的，订阅者可以直接保留。
+	TopicSpectrum = evbus.NewTopic[[]float64]("ws:spectrum")
+	// TopicBeat 在检测到起音时发布，携带强度、估计的 BPM 与置信度。
+	TopicBeat = evbus.NewTopic[Beat]("ws:beat")
+	// TopicSeekRequest 由界面发布（如点击歌词行），携带目标位置，Initws 会把它转发给活动数据源。
+	TopicSeekRequest = evbus.NewTopic[time.Duration]("ws:seekRequest")
+)
@@ -8,11 +8,11 @@ import (
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"math"
-	"math/cmplx"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,9 +21,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/xiaowumin-mark/EbitenLyrics/evbus"
-
-	"github.com/disintegration/imaging"
 	"github.com/gorilla/websocket"
 )
 
@@ -50,6 +47,8 @@ const (
 	TypePong       V2PayloadType = "pong"
 	TypeCommand    V2PayloadType = "command"
 	TypeState      V2PayloadType = "state"
+	// TypeError 由本程序发回给播放器，报告无法处理的消息
+	TypeError V2PayloadType = "error"
 )
 
 // GenericV2Payload 用于第一次解析，读取 type 字段
@@ -64,18 +63,21 @@ type Command struct {
 	Data    map[string]interface{} `json:"-"`       // 存储完整的数据字典 (手动填充)
 }
 
-// StateUpdate 结构体调整
+// StateUpdate 保存未知或程序自定义的状态更新（如 setFontConfig），
+// 已知更新会被解码为 state.go 中的具体类型。
 type StateUpdate struct {
-	Update string                 `json:"update"` // 更新类型，如 "setMusic"
+	Update string                 `json:"update"` // 更新类型，如 "setFontConfig"
 	Data   map[string]interface{} `json:"-"`      // 存储完整的数据字典 (手动填充)
 }
 
-// MusicInfo 示例具体数据结构
+// MusicInfo 是 setMusic 携带的歌曲信息
 type MusicInfo struct {
-	MusicId   string `json:"music_id"`
-	MusicName string `json:"music_name"`
-	// ... 其他字段
-
+	MusicId   string   `json:"musicId"`
+	MusicName string   `json:"musicName"`
+	AlbumId   string   `json:"albumId"`
+	AlbumName string   `json:"albumName"`
+	Artists   []Artist `json:"artists"`
+	Duration  int64    `json:"duration"` // 毫秒
 }
 
 type LyricContent struct {
@@ -101,225 +103,6 @@ type V1Body struct {
 	ID  uint32
 	Raw []byte
 }
-type lowFreqAnalyzer struct {
-	sampleRate float64
-	emaValue   float64
-	peakLevel  float64 // 用于动态调整增益的峰值
-	formatHint string
-}
-
-func newLowFreqAnalyzer(sampleRate float64) *lowFreqAnalyzer {
-	return &lowFreqAnalyzer{
-		sampleRate: sampleRate,
-		emaValue:   0,
-		peakLevel:  0.2, // 初始预设一个较小的峰值
-	}
-}
-
-func (a *lowFreqAnalyzer) AnalyzePCM(data []byte) (float64, bool) {
-	if a == nil {
-		return 0, false
-	}
-	samples, format, ok := decodePCMToMono(data)
-	if !ok || len(samples) < 128 {
-		return 0, false
-	}
-	if a.formatHint == "" {
-		a.formatHint = format
-		log.Printf("audio analyzer format=%s samples=%d", format, len(samples))
-	}
-
-	// 1. 确定 FFT 长度
-	sampleCount := len(samples)
-	nfft := 1
-	for nfft*2 <= sampleCount && nfft < 2048 {
-		nfft *= 2
-	}
-	if nfft < 256 {
-		return 0, false
-	}
-
-	// 2. 预处理：去直流分量 + 汉宁窗
-	fftBuf := make([]complex128, nfft)
-	var mean float64
-	for i := 0; i < nfft; i++ {
-		mean += samples[i]
-	}
-	mean /= float64(nfft)
-
-	denom := float64(nfft - 1)
-	for i := 0; i < nfft; i++ {
-		// Hann Window
-		w := 0.5 * (1.0 - math.Cos(2.0*math.Pi*float64(i)/denom))
-		fftBuf[i] = complex((samples[i]-mean)*w, 0)
-	}
-
-	// 3. 执行 FFT
-	fftInPlace(fftBuf)
-
-	// 4. 计算目标频段能量 (20Hz - 300Hz)
-	freqRes := a.sampleRate / float64(nfft)
-	minBin := int(math.Ceil(20.0 / freqRes))
-	maxBin := int(math.Floor(300.0 / freqRes))
-	if minBin < 1 {
-		minBin = 1
-	}
-	nyquist := nfft / 2
-	if maxBin > nyquist {
-		maxBin = nyquist
-	}
-
-	var lowEndEnergy float64
-	for k := minBin; k <= maxBin; k++ {
-		mag := cmplx.Abs(fftBuf[k])
-		// 使用幅度的平方作为能量
-		lowEndEnergy += mag * mag
-	}
-
-	// 归一化能量值 (取均值后开方，得到类似 RMS 的指标)
-	currentLevel := math.Sqrt(lowEndEnergy / float64(maxBin-minBin+1))
-
-	// 5. 动态范围压缩与归一化
-	// 更新历史峰值（缓慢下降，确保环境适应性）
-	a.peakLevel = math.Max(a.peakLevel*0.999, currentLevel)
-	if a.peakLevel < 0.01 {
-		a.peakLevel = 0.01
-	}
-
-	// 将当前能量映射到 0-1 范围
-	// 使用 linear 比例，如果想要更夸张的效果可以使用 math.Log10 映射
-	rawNorm := math.Log10(1 + 9*currentLevel/a.peakLevel)
-	if rawNorm > 1.0 {
-		rawNorm = 1.0
-	}
-
-	// 6. 平滑滤波 (Attack 快速上升, Decay 缓慢下降)
-	var sensitivity float64
-	if rawNorm > a.emaValue {
-		sensitivity = 0.5 // 反应灵敏：上升系数
-	} else {
-		sensitivity = 0.2 // 丝滑回落：下降系数
-	}
-	a.emaValue = a.emaValue*(1-sensitivity) + rawNorm*sensitivity
-
-	// 最终输出强制限制在 0-1
-	finalValue := math.Max(0, math.Min(1, a.emaValue))
-
-	return finalValue, true
-}
-
-func decodePCMToMono(data []byte) ([]float64, string, bool) {
-	if len(data) >= 4*64 && len(data)%4 == 0 {
-		signed16 := likelySignedPCM16(data)
-		frames := len(data) / 4
-		out := make([]float64, frames)
-		if signed16 {
-			for i := 0; i < frames; i++ {
-				base := i * 4
-				l := float64(int16(binary.LittleEndian.Uint16(data[base : base+2])))
-				r := float64(int16(binary.LittleEndian.Uint16(data[base+2 : base+4])))
-				out[i] = (l + r) * (0.5 / 32768.0)
-			}
-			return out, "i16-stereo-le", true
-		}
-		for i := 0; i < frames; i++ {
-			base := i * 4
-			l := float64(binary.LittleEndian.Uint16(data[base:base+2])) - 32768.0
-			r := float64(binary.LittleEndian.Uint16(data[base+2:base+4])) - 32768.0
-			out[i] = (l + r) * (0.5 / 32768.0)
-		}
-		return out, "u16-stereo-le", true
-	}
-
-	if len(data) >= 2*64 && len(data)%2 == 0 {
-		signed16 := likelySignedPCM16(data)
-		sampleCount := len(data) / 2
-		out := make([]float64, sampleCount)
-		if signed16 {
-			for i := 0; i < sampleCount; i++ {
-				base := i * 2
-				out[i] = float64(int16(binary.LittleEndian.Uint16(data[base:base+2]))) / 32768.0
-			}
-			return out, "i16-mono-le", true
-		}
-		for i := 0; i < sampleCount; i++ {
-			base := i * 2
-			out[i] = (float64(binary.LittleEndian.Uint16(data[base:base+2])) - 32768.0) / 32768.0
-		}
-		return out, "u16-mono-le", true
-	}
-
-	// Fallback for non-standard custom sender implementation.
-	if len(data) >= 8*64 && len(data)%8 == 0 {
-		sampleCount := len(data) / 8
-		out := make([]float64, sampleCount)
-		const invMaxI64 = 1.0 / 9223372036854775807.0
-		for i := 0; i < sampleCount; i++ {
-			base := i * 8
-			out[i] = float64(int64(binary.LittleEndian.Uint64(data[base:base+8]))) * invMaxI64
-		}
-		return out, "i64-mono-le", true
-	}
-
-	return nil, "", false
-}
-
-func likelySignedPCM16(data []byte) bool {
-	sampleCount := len(data) / 2
-	if sampleCount < 4 {
-		return false
-	}
-	const jumpThreshold = 30000
-	jumps := 0
-	prev := binary.LittleEndian.Uint16(data[0:2])
-	for i := 1; i < sampleCount; i++ {
-		base := i * 2
-		cur := binary.LittleEndian.Uint16(data[base : base+2])
-		diff := int(cur) - int(prev)
-		if diff < 0 {
-			diff = -diff
-		}
-		if diff > jumpThreshold {
-			jumps++
-		}
-		prev = cur
-	}
-	return float64(jumps)/float64(sampleCount-1) > 0.02
-}
-
-func fftInPlace(a []complex128) {
-	n := len(a)
-	if n <= 1 {
-		return
-	}
-	j := 0
-	for i := 1; i < n; i++ {
-		bit := n >> 1
-		for ; (j & bit) != 0; bit >>= 1 {
-			j ^= bit
-		}
-		j ^= bit
-		if i < j {
-			a[i], a[j] = a[j], a[i]
-		}
-	}
-
-	for step := 2; step <= n; step <<= 1 {
-		half := step >> 1
-		ang := -2.0 * math.Pi / float64(step)
-		wStep := cmplx.Exp(complex(0, ang))
-		for i := 0; i < n; i += step {
-			w := complex(1.0, 0)
-			for k := 0; k < half; k++ {
-				u := a[i+k]
-				v := a[i+k+half] * w
-				a[i+k] = u + v
-				a[i+k+half] = u - v
-				w *= wStep
-			}
-		}
-	}
-}
 
 // ===========================
 // 2. 服务器框架定义
@@ -331,14 +114,41 @@ const (
 	Unknown ProtocolType = iota
 	BinaryV1
 	HybridV2
+	// MPRIS 是 Linux 桌面播放器通过 D-Bus 暴露的数据源，见 mpris 包。
+	MPRIS
 )
 
 type ProtocolPayload interface{} // 消息通道传递的通用接口
-type MessageChannel chan ProtocolPayload
+
+// Envelope 为消息标记来源连接，供仲裁器区分多个播放器。
+type Envelope struct {
+	Source  string
+	Payload ProtocolPayload
+}
+
+type MessageChannel chan Envelope
+
+// SourceConnected / SourceDisconnected 是服务器在连接建立和断开时投递的生命周期消息。
+type SourceConnected struct {
+	Protocol ProtocolType
+	// Audio 是播放器在 initialize 中声明的音频格式，未声明时为零值。
+	Audio AudioFormat
+}
+
+type SourceDisconnected struct{}
 
 type ConnectionInfo struct {
 	Conn     *websocket.Conn
 	Protocol ProtocolType
+	// writeMu 保证同一连接上只有一个写者
+	writeMu *sync.Mutex
+}
+
+// ErrorReply 是发回给播放器的错误说明
+type ErrorReply struct {
+	Update  string `json:"update,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
 }
 
 type AMLLWebSocketServer struct {
@@ -369,6 +179,14 @@ func (s *AMLLWebSocketServer) Close() {
 	wsActiveConnections.Store(0)
 }
 
+// Handler 返回只处理 WebSocket 连接的 http.Handler，不含控制接口。
+// 供集成测试用 httptest 启动服务，而不必占用固定端口。
+func (s *AMLLWebSocketServer) Handler(msgChan MessageChannel) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.acceptConn(w, r, msgChan)
+	})
+}
+
 func (s *AMLLWebSocketServer) Reopen(addr string, msgChan MessageChannel) {
 	s.Close()
 	s.stopChan = make(chan struct{})
@@ -379,9 +197,8 @@ func (s *AMLLWebSocketServer) Reopen(addr string, msgChan MessageChannel) {
 		defer wsServerListening.Store(false)
 		log.Printf("INFO: WebSocket 服务器监听中: %s", addr)
 		mux := http.NewServeMux()
-		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			s.acceptConn(w, r, msgChan)
-		})
+		mux.Handle("/", s.Handler(msgChan))
+		registerHTTPHandlers(mux, msgChan, defaultStatus, DefaultArbiter)
 
 		server := &http.Server{Addr: addr, Handler: mux}
 
@@ -415,6 +232,8 @@ func (s *AMLLWebSocketServer) acceptConn(w http.ResponseWriter, r *http.Request,
 
 	// --- 1. 握手与协议识别 ---
 	var protocolType ProtocolType
+	var audioFormat AudioFormat
+	var audioErr error
 
 	// 读取第一条消息
 	msgType, reader, err := conn.NextReader()
@@ -431,6 +250,7 @@ func (s *AMLLWebSocketServer) acceptConn(w http.ResponseWriter, r *http.Request,
 			if payload.Type == TypeInitialize {
 				log.Println("INFO: 协议识别 -> HybridV2")
 				protocolType = HybridV2
+				audioFormat, audioErr = parseInitializeAudioFormat(payload.Value)
 			} else {
 				log.Println("WARN: V2 握手失败，非 Initialize 消息")
 				return
@@ -442,18 +262,27 @@ func (s *AMLLWebSocketServer) acceptConn(w http.ResponseWriter, r *http.Request,
 	case websocket.BinaryMessage:
 		log.Println("INFO: 协议识别 -> BinaryV1")
 		protocolType = BinaryV1
-		// 处理第一条 V1 消息
-		s.processV1Message(msgType, data, msgChan)
 	}
 
 	if protocolType != Unknown {
 		s.mu.Lock()
-		s.connections[addr] = ConnectionInfo{Conn: conn, Protocol: protocolType}
+		s.connections[addr] = ConnectionInfo{Conn: conn, Protocol: protocolType, writeMu: &sync.Mutex{}}
 		s.mu.Unlock()
 		wsActiveConnections.Add(1)
+		msgChan <- Envelope{Source: addr, Payload: SourceConnected{Protocol: protocolType, Audio: audioFormat}}
 	} else {
 		return
 	}
+	if audioErr != nil {
+		// 格式声明有误时退回自动推测，连接照常建立
+		log.Printf("WARN: %s 的音频格式声明无效: %v", addr, audioErr)
+		s.replyError(addr, audioErr)
+	}
+
+	if protocolType == BinaryV1 {
+		// 处理第一条 V1 消息
+		s.processV1Message(addr, msgType, data, msgChan)
+	}
 
 	// --- 2. 消息循环 ---
 	for {
@@ -473,9 +302,9 @@ func (s *AMLLWebSocketServer) acceptConn(w http.ResponseWriter, r *http.Request,
 		var processErr error
 		switch info.Protocol {
 		case HybridV2:
-			processErr = s.processV2Message(msgType, msg, msgChan)
+			processErr = s.processV2Message(addr, msgType, msg, msgChan)
 		case BinaryV1:
-			processErr = s.processV1Message(msgType, msg, msgChan)
+			processErr = s.processV1Message(addr, msgType, msg, msgChan)
 		}
 
 		if processErr != nil {
@@ -488,18 +317,40 @@ func (s *AMLLWebSocketServer) acceptConn(w http.ResponseWriter, r *http.Request,
 	delete(s.connections, addr)
 	s.mu.Unlock()
 	wsActiveConnections.Add(-1)
+	msgChan <- Envelope{Source: addr, Payload: SourceDisconnected{}}
 }
 
-func (s *AMLLWebSocketServer) processV1Message(msgType int, data []byte, channel MessageChannel) error {
+// replyError 以 {"type":"error","value":{...}} 的形式把错误发回给 source。
+func (s *AMLLWebSocketServer) replyError(source string, err error) {
+	reply := ErrorReply{Message: err.Error()}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		reply.Update = fieldErr.Update
+		reply.Field = fieldErr.Field
+		reply.Message = fieldErr.Err.Error()
+	}
+	// 只有 HybridV2 连接能收到错误回复，其它情况静默忽略
+	s.mu.RLock()
+	info, ok := s.connections[source]
+	s.mu.RUnlock()
+	if !ok || info.Protocol != HybridV2 {
+		return
+	}
+	if writeErr := s.sendV2(source, TypeError, reply); writeErr != nil {
+		log.Printf("WARN: 向 %s 回复错误失败: %v", source, writeErr)
+	}
+}
+
+func (s *AMLLWebSocketServer) processV1Message(source string, msgType int, data []byte, channel MessageChannel) error {
 	if msgType == websocket.BinaryMessage {
 		// 模拟解析 V1
 		v1Body := V1Body{ID: 1, Raw: data}
-		channel <- v1Body
+		channel <- Envelope{Source: source, Payload: v1Body}
 	}
 	return nil
 }
 
-func (s *AMLLWebSocketServer) processV2Message(msgType int, data []byte, channel MessageChannel) error {
+func (s *AMLLWebSocketServer) processV2Message(source string, msgType int, data []byte, channel MessageChannel) error {
 	if msgType == websocket.TextMessage {
 		var generic GenericV2Payload
 		if err := json.Unmarshal(data, &generic); err != nil {
@@ -508,7 +359,7 @@ func (s *AMLLWebSocketServer) processV2Message(msgType int, data []byte, channel
 
 		switch generic.Type {
 		case TypeInitialize, TypePing, TypePong:
-			channel <- generic.Type
+			channel <- Envelope{Source: source, Payload: generic.Type}
 
 		case TypeCommand:
 			var rawMap map[string]interface{}
@@ -516,26 +367,17 @@ func (s *AMLLWebSocketServer) processV2Message(msgType int, data []byte, channel
 				return err
 			}
 			cmdStr, _ := rawMap["command"].(string)
-			channel <- Command{Command: cmdStr, Data: rawMap}
+			channel <- Envelope{Source: source, Payload: Command{Command: cmdStr, Data: rawMap}}
 
 		case TypeState:
-			var rawMap map[string]interface{}
-			if err := json.Unmarshal(generic.Value, &rawMap); err != nil {
-				log.Printf("ERROR: 解析 State Value 失败: %v", err)
-				return err
-			}
-
-			// 关键：确保 update 字段存在
-			updateStr, ok := rawMap["update"].(string)
-			if !ok {
-				log.Printf("WARN: State 消息缺少 update 字段: %+v", rawMap)
+			// 格式错误的状态更新只丢弃这一条并告知发送方，不断开连接
+			value, err := DecodeStateUpdate(generic.Value)
+			if err != nil {
+				log.Printf("WARN: 丢弃无效的 State 消息: %v", err)
+				s.replyError(source, err)
 				return nil
 			}
-
-			channel <- StateUpdate{
-				Update: updateStr,
-				Data:   rawMap,
-			}
+			channel <- Envelope{Source: source, Payload: value}
 		}
 
 	} else if msgType == websocket.BinaryMessage {
@@ -559,7 +401,7 @@ func (s *AMLLWebSocketServer) processV2Message(msgType int, data []byte, channel
 			msgTypeStr = "SetCoverData" // <--- 封面通常走这里
 		}
 
-		channel <- V2BinaryMessage{Type: msgTypeStr, Data: dataBytes}
+		channel <- Envelope{Source: source, Payload: V2BinaryMessage{Type: msgTypeStr, Data: dataBytes}}
 	}
 	return nil
 }
@@ -568,15 +410,116 @@ func (s *AMLLWebSocketServer) processV2Message(msgType int, data []byte, channel
 // 4. 主程序入口 (initws)
 // ===========================
 
-func Initws() {
+// DefaultAddr 是 WebSocket 服务默认监听地址。
+const DefaultAddr = "0.0.0.0:11445"
+
+// Options 控制 Initws 的数据来源。
+type Options struct {
+	// Addr 是 WebSocket 监听地址，为空时使用 DefaultAddr。
+	Addr string
+	// RecordPath 非空时把收到的每条消息录制到该文件。
+	RecordPath string
+	// ReplayPath 非空时不启动 WebSocket 服务，改为回放该录制文件。
+	ReplayPath string
+	// ReplaySpeed 是回放倍速，<= 0 表示尽快送出。
+	ReplaySpeed float64
+	// ReplayLoop 为 true 时循环回放。
+	ReplayLoop bool
+	// Audio 是配置的 OnAudioData 格式，零值字段表示使用默认值或按数据推测。
+	// 播放器在 initialize 中声明的字段优先于这里的配置。
+	Audio AudioFormat
+	// SpectrumBands 是频谱频段数量，<= 0 时使用 DefaultSpectrumBands。
+	SpectrumBands int
+	// CoverChain 是发布到 TopicCover 之前的封面处理链，nil 时使用 DefaultCoverChain。
+	CoverChain CoverChain
+	// CoverLimits 限制封面的大小与解码耗时。
+	CoverLimits CoverLimits
+	// CoverFiles 控制 setCover 能否让程序读取本地路径，零值时不读取。
+	CoverFiles CoverFiles
+	// Sources 是 WebSocket 之外的数据源，与 WebSocket 服务同时运行；回放模式下不启动。
+	Sources []Source
+}
+
+// Source 是向消息通道投递 Envelope 的数据源，如回放文件或 MPRIS 播放器。
+// Run 应阻塞到数据源结束或 stop 被关闭。
+type Source interface {
+	Run(msgChan MessageChannel, stop <-chan struct{}) error
+}
+
+func Initws(opts Options) {
 	log.SetFlags(log.Ltime | log.Lmicroseconds)
 
 	messageChannel := make(MessageChannel, 100) // 带缓冲，防止阻塞
-	audioAnalyzer := newLowFreqAnalyzer(48000)
+	dispatcher := &payloadDispatcher{
+		audio: newSpectrumAnalyzer(opts.Audio, opts.SpectrumBands),
+		cover: newCoverWorker(opts.CoverChain, opts.CoverLimits, opts.CoverFiles, TopicCoverOriginal, TopicCover, TopicCoverPalette),
+	}
 	server := NewAMLLWebSocketServer()
+	arbiter := DefaultArbiter
+	stopSources := make(chan struct{})
+	defaultStatus.bind()
+	// 各数据源在 initialize 中声明的音频格式，随活动源切换
+	audioFormats := make(map[string]AudioFormat)
+
+	if opts.ReplayPath != "" {
+		// 回放模式下不监听端口，避免真实播放器的消息混入
+		replay := ReplaySource{Path: opts.ReplayPath, Speed: opts.ReplaySpeed, Loop: opts.ReplayLoop}
+		go func() {
+			log.Printf("MAIN: 回放录制文件 %s (speed=%.2f)", opts.ReplayPath, opts.ReplaySpeed)
+			if err := replay.Run(messageChannel, stopSources); err != nil {
+				log.Printf("MAIN: 回放失败: %v", err)
+				return
+			}
+			log.Println("MAIN: 回放结束")
+		}()
+	} else {
+		addr := opts.Addr
+		if addr == "" {
+			addr = DefaultAddr
+		}
+		// 启动服务器
+		server.Reopen(addr, messageChannel)
+		for _, src := range opts.Sources {
+			go func(src Source) {
+				if err := src.Run(messageChannel, stopSources); err != nil {
+					log.Printf("MAIN: 数据源 %T 停止: %v", src, err)
+				}
+			}(src)
+		}
+	}
 
-	// 启动服务器
-	server.Reopen("0.0.0.0:11445", messageChannel)
+	var recorder *Recorder
+	if opts.RecordPath != "" {
+		r, err := CreateRecorder(opts.RecordPath)
+		if err != nil {
+			log.Printf("MAIN: 创建录制文件失败: %v", err)
+		} else {
+			log.Printf("MAIN: 录制会话到 %s", opts.RecordPath)
+			recorder = r
+		}
+	}
+
+	// 界面发起的跳转请求转发给活动数据源；来不及处理时只保留最新的一次
+	seekers := []Seeker{server}
+	for _, src := range opts.Sources {
+		if seeker, ok := src.(Seeker); ok {
+			seekers = append(seekers, seeker)
+		}
+	}
+	seekRequests := make(chan time.Duration, 1)
+	TopicSeekRequest.Subscribe(func(pos time.Duration) {
+		for {
+			select {
+			case seekRequests <- pos:
+				return
+			default:
+			}
+			select {
+			case <-seekRequests:
+			default:
+			}
+		}
+	})
 
 	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
@@ -585,143 +528,133 @@ func Initws() {
 
 	for {
 		select {
-		case payload := <-messageChannel:
-			// 核心修复：这里必须根据 processV2Message 发送的具体类型来断言
-			switch p := payload.(type) {
-
-			// 1. 处理简单的 V2 信号 (Initialize, Ping, Pong)
-			case V2PayloadType:
-				log.Printf("MAIN [V2-Signal]: %s", p)
-
-			// 2. 处理 V2 指令 (Command)
-			case Command:
-				log.Printf("MAIN [V2-Command]: %s", p.Command)
-
-			// 3. 处理 V2 状态更新 (StateUpdate)
-			case StateUpdate:
-				//log.Printf("MAIN [V2-State]: %s", p.Update)
-				// 示例：如果是 SetMusic，尝试二次解析 Value
-				switch p.Update {
-				case "setMusic":
-					log.Println(p.Data)
-					evbus.Bus.Publish("ws:setMusic", p.Data)
-				case "setLyric":
-					//log.Println(p.Data)
-					/*d, err := ParseLyricsFromMap(p.Data["lines"].([]interface{}))
-					if err != nil {
-						log.Println(err)
-					} else {
-						log.Println(d)
-						game.mu.Lock()
-
-						for _, ele := range game.Elements {
-
-							ele.CancelAllAnimate()
-							ele.DisposeAll()
-							if ele.TranslatedEle != nil {
-								ele.TranslatedEle.Image.Dispose()
-								ele.TranslatedEle = nil
-							}
-							for _, bg := range ele.BGs {
-								bg.CancelAllAnimate()
-								bg.DisposeAll()
-								if bg.TranslatedEle != nil {
-									bg.TranslatedEle.Image.Dispose()
-									bg.TranslatedEle = nil
-								}
-							}
-
-						}
-						game.Elements = nil
-						game.nowLyrics = d
-
-						game.Elements = initGamesLyrics(game.nowLyrics)
-						Scroll([]int{0}, game, 0)
-						game.mu.Unlock()
-					}*/
-					lines, ok := p.Data["lines"].([]interface{})
-					if !ok {
-						log.Printf("MAIN: lines has unexpected type %T", p.Data["lines"])
-						break
-					}
-					evbus.Bus.Publish("ws:setLyric", lines)
-				case "progress":
-					//log.Println(p.Data)
-					// float64
-					/*progress, ok := p.Data["progress"].(float64)
-					if !ok {
-						log.Println("MAIN: progress 不是 float64")
-						break
-					}
-					game.mu.Lock()
-					game.progress = time.Duration(progress) * time.Millisecond
-					game.mu.Unlock()*/
-
-					progress, ok := p.Data["progress"].(float64)
-					if !ok {
-						if v, ok := p.Data["progress"].(int); ok {
-							progress = float64(v)
-						} else {
-							log.Printf("MAIN: progress has unexpected type %T", p.Data["progress"])
-							break
-						}
-					}
-					evbus.Bus.Publish("ws:progress", progress)
-				case "volume":
-					log.Println(p.Data)
-				case "setCover":
-					log.Println(p.Data)
-				case "setFontConfig", "setFont":
-					evbus.Bus.Publish("ws:fontConfig", p.Data)
-				}
-
-				// --- 情况 B: 二进制封面 (通常是直接的图片数据) ---
-			case V2BinaryMessage:
-				//log.Printf("MAIN [Binary]: 类型=%s, 大小=%d bytes", p.Type, len(p.Data))
-
-				switch p.Type {
-				case "SetCoverData":
-					log.Println("   >>> 收到二进制封面数据 (SetCoverData)!")
-					// 这里 p.Data 就是图片的 byte 数组 (png/jpg)
-					// 你可以将 p.Data 保存为文件，或者发送给前端
-					/*log.Println("   >>> 尝试解码...", len(p.Data))
-					img, err := jpeg.Decode(bytes.NewReader(p.Data))
-					if err != nil {
-						log.Println("   >>> 解码失败:", err)
-						continue
-					}*/
-					img, err := imaging.Decode(bytes.NewReader(p.Data))
-					if err != nil {
-						log.Println("   >>> 解码失败:", err)
-						continue
-					}
-					blurred := imaging.Blur(img, 40)
-					// 亮度0.7
-					blurred = imaging.AdjustBrightness(blurred, -30)
-					//对比度
-					blurred = imaging.AdjustContrast(blurred, -30)
-					blurred = imaging.AdjustSaturation(blurred, 10)
-
-					evbus.Bus.Publish("ws:cover", blurred)
-				case "OnAudioData":
-					if lowFreqVolume, ok := audioAnalyzer.AnalyzePCM(p.Data); ok {
-						evbus.Bus.Publish("ws:lowFreqVolume", lowFreqVolume)
-						//log.Println("   >>> 频谱:", lowFreqVolume)
-					}
-				}
+		case msg := <-messageChannel:
+			if err := recorder.Record(msg); err != nil {
+				log.Printf("MAIN: 录制失败，停止录制: %v", err)
+				recorder.Close()
+				recorder = nil
+			}
+			switch p := msg.Payload.(type) {
+			case SourceConnected:
+				log.Printf("MAIN: 数据源接入 %s (audio=%s)", msg.Source, p.Audio)
+				audioFormats[msg.Source] = p.Audio
+				arbiter.Connect(msg.Source, time.Now())
+				continue
+			case SourceDisconnected:
+				log.Printf("MAIN: 数据源断开 %s", msg.Source)
+				delete(audioFormats, msg.Source)
+				arbiter.Disconnect(msg.Source)
+				continue
+			}
+			// 只有活动源的消息才会进入主题，HTTP 控制除外
+			payloads := routeEnvelope(arbiter, msg, time.Now())
+			dispatcher.audio.setFormat(opts.Audio.Merge(audioFormats[arbiter.Active()]))
+			for _, payload := range payloads {
+				dispatcher.dispatch(msg.Source, payload)
+			}
 
-			// 5. 处理 V1 二进制消息
-			case V1Body:
-				log.Printf("MAIN [V1-Binary]: ID=%d", p.ID)
+		case pos := <-seekRequests:
+			forwardSeek(arbiter.Active(), pos, seekers)
 
-			default:
-				log.Printf("MAIN [Unknown]: 收到未知类型 %T", payload)
+		case <-arbiter.Changed():
+			log.Printf("MAIN: 活动数据源切换为 %q", arbiter.Active())
+			dispatcher.audio.setFormat(opts.Audio.Merge(audioFormats[arbiter.Active()]))
+			for _, payload := range arbiter.Snapshot() {
+				dispatcher.dispatch(arbiter.Active(), payload)
 			}
 
 		case <-termChan:
 			log.Println("MAIN: 正在关闭...")
+			close(stopSources)
 			server.Close()
+			if err := recorder.Close(); err != nil {
+				log.Printf("MAIN: 关闭录制文件失败: %v", err)
+			}
 			os.Exit(0)
 		}
 	}
 }
+
+// payloadDispatcher 把已通过仲裁的消息转换为 ws:* 主题发布，
+// 并维护需要跨消息合并的状态。只在 Initws 的消息循环中使用。
+type payloadDispatcher struct {
+	audio      *spectrumAnalyzer
+	cover      *coverWorker
+	nowPlaying nowPlayingState
+}
+
+// dispatch 发布 source 发来的 payload；source 用于判断能否读取本地封面路径。
+func (d *payloadDispatcher) dispatch(source string, payload ProtocolPayload) {
+	switch p := payload.(type) {
+
+	// 1. 处理简单的 V2 信号 (Initialize, Ping, Pong)
+	case V2PayloadType:
+		log.Printf("MAIN [V2-Signal]: %s", p)
+
+	// 2. 处理 V2 指令 (Command)
+	case Command:
+		log.Printf("MAIN [V2-Command]: %s", p.Command)
+
+	// 3. 处理 V2 状态更新，已在 processV2Message 中解码为具体类型
+	case SetMusicUpdate:
+		log.Printf("MAIN [V2-State]: setMusic %q (%s)", p.MusicName, p.MusicId)
+		TopicSetMusic.Publish(p.MusicInfo)
+		TopicNowPlaying.Publish(d.nowPlaying.setMusic(p.MusicInfo))
+	case SetLyricUpdate:
+		TopicLyricMetadata.Publish(nil)
+		TopicLyrics.Publish(p.Lines)
+	case SetLyricFromTTMLUpdate:
+		log.Printf("MAIN [V2-State]: setLyricFromTTML %d 行, %d 项元数据", len(p.Lyric.LyricLines), len(p.Lyric.Metadata))
+		TopicLyricMetadata.Publish(p.Lyric.Metadata)
+		TopicLyrics.Publish(p.Lyric.LyricLines)
+	case ProgressUpdate:
+		TopicProgress.Publish(time.Duration(p.Progress) * time.Millisecond)
+	case VolumeUpdate:
+		log.Printf("MAIN [V2-State]: volume %.2f", p.Volume)
+	case SetCoverUpdate:
+		log.Printf("MAIN [V2-State]: setCover source=%s", p.Source)
+		TopicNowPlaying.Publish(d.nowPlaying.setCover(p))
+		d.cover.submitUpdate(source, p)
+	case PausedUpdate:
+		log.Println("MAIN [V2-State]: paused")
+		TopicPlaying.Publish(false)
+	case ResumedUpdate:
+		log.Println("MAIN [V2-State]: resumed")
+		TopicPlaying.Publish(true)
+	case StateUpdate:
+		switch p.Update {
+		case "setFontConfig", "setFont":
+			// 消息本身还会交给录制与快照，发布副本避免订阅方共享同一个字典
+			TopicFontConfig.Publish(maps.Clone(p.Data))
+		default:
+			log.Printf("MAIN [V2-State]: 未处理的更新 %s", p.Update)
+		}
+
+	// 4. 处理 V2 二进制消息 (封面图片、音频帧)
+	case V2BinaryMessage:
+		//log.Printf("MAIN [Binary]: 类型=%s, 大小=%d bytes", p.Type, len(p.Data))
+
+		switch p.Type {
+		case "SetCoverData":
+			log.Printf("   >>> 收到二进制封面数据 (SetCoverData) %d bytes", len(p.Data))
+			// 解码与模糊等处理在 worker 中进行，不阻塞进度和音频消息
+			d.cover.submit(p.Data)
+			TopicNowPlaying.Publish(d.nowPlaying.setCoverData(p.Data))
+		case "OnAudioData":
+			if frame, ok := d.audio.Analyze(p.Data); ok {
+				TopicLowFreqVolume.Publish(frame.LowFreq)
+				TopicSpectrum.Publish(frame.Bands)
+				if frame.HasBeat {
+					TopicBeat.Publish(frame.Beat)
+				}
+			}
+		}
+
+	// 5. 处理 V1 二进制消息
+	case V1Body:
+		log.Printf("MAIN [V1-Binary]: ID=%d", p.ID)
+
+	default:
+		log.Printf("MAIN [Unknown]: 收到未知类型 %T", payload)
+	}
+}
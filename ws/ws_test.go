@@ -0,0 +1,142 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestInvalidStateUpdateIsReportedToSender(t *testing.T) {
+	server := NewAMLLWebSocketServer()
+	ch := make(MessageChannel, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.acceptConn(w, r, ch)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"initialize"}`)); err != nil {
+		t.Fatal(err)
+	}
+	bad := `{"type":"state","value":{"update":"setLyricFromTTML","ttml":"not ttml"}}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(bad)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reply struct {
+		Type  V2PayloadType `json:"type"`
+		Value ErrorReply    `json:"value"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Type != TypeError || reply.Value.Update != "setLyricFromTTML" || reply.Value.Field != "ttml" || reply.Value.Message == "" {
+		t.Fatalf("unexpected reply %s", data)
+	}
+
+	// 连接仍然可用，后续消息照常进入通道
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"state","value":{"update":"paused"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-ch:
+			if _, ok := msg.Payload.(PausedUpdate); ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("paused update was not delivered")
+		}
+	}
+}
+
+func TestInitializeDeclaresAudioFormat(t *testing.T) {
+	server := NewAMLLWebSocketServer()
+	ch := make(MessageChannel, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.acceptConn(w, r, ch)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	init := `{"type":"initialize","value":{"audioFormat":{"sampleFormat":"i16","channels":1,"sampleRate":44100}}}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(init)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-ch:
+		connected, ok := msg.Payload.(SourceConnected)
+		want := AudioFormat{SampleFormat: SampleFormatI16, Channels: 1, SampleRate: 44100}
+		if !ok || connected.Audio != want {
+			t.Fatalf("first payload = %#v, want SourceConnected with %v", msg.Payload, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SourceConnected was not delivered")
+	}
+}
+
+func TestSeekIsSentToHybridV2Connection(t *testing.T) {
+	server := NewAMLLWebSocketServer()
+	ch := make(MessageChannel, 8)
+	srv := httptest.NewServer(server.Handler(ch))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"initialize"}`)); err != nil {
+		t.Fatal(err)
+	}
+	var source string
+	select {
+	case msg := <-ch:
+		source = msg.Source
+	case <-time.After(2 * time.Second):
+		t.Fatal("SourceConnected was not delivered")
+	}
+
+	if err := server.Seek("127.0.0.1:1", time.Second); !errors.Is(err, ErrUnknownSource) {
+		t.Fatalf("Seek(unknown) = %v, want ErrUnknownSource", err)
+	}
+	forwardSeek(source, 83500*time.Millisecond, []Seeker{server})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		Type  V2PayloadType `json:"type"`
+		Value seekCommand   `json:"value"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != TypeCommand || msg.Value.Command != CommandSeekPlayProgress || msg.Value.Progress != 83500 {
+		t.Fatalf("unexpected command %s", data)
+	}
+}